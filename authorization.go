@@ -5,9 +5,87 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"net/http"
+	"sync"
 )
 
+// ErrAuthorizationServiceDown is returned when the authorization service can not be reached
+// and the failure policy for the checked action is to fail closed.
+var ErrAuthorizationServiceDown = NewError(ErrorCodeUnavailable, "authorization service is unavailable")
+
+// AuthorizationFailurePolicy decides the outcome of an authorization check while the authorization service is down
+type AuthorizationFailurePolicy int
+
+const (
+	// AuthorizationFailClosed denies access while the authorization service is down, this is the default
+	AuthorizationFailClosed AuthorizationFailurePolicy = iota
+	// AuthorizationFailOpen grants access while the authorization service is down
+	AuthorizationFailOpen
+)
+
+// WithAuthorizationFailurePolicy Option that sets the default behaviour of authorization checks
+// when the authorization service is unavailable.
+func WithAuthorizationFailurePolicy(policy AuthorizationFailurePolicy) Option {
+	return func(s *Service) {
+		s.authorizationPolicy = policy
+	}
+}
+
+// WithAuthorizationActionFailurePolicy Option that overrides the failure policy for specific actions,
+// allowing security critical actions to fail closed while low risk ones fail open during an outage.
+func WithAuthorizationActionFailurePolicy(policy AuthorizationFailurePolicy, actions ...string) Option {
+	return func(s *Service) {
+		if s.authorizationPolicies == nil {
+			s.authorizationPolicies = map[string]AuthorizationFailurePolicy{}
+		}
+		for _, action := range actions {
+			s.authorizationPolicies[action] = policy
+		}
+	}
+}
+
+func (s *Service) authorizationFailurePolicy(action string) AuthorizationFailurePolicy {
+	if policy, ok := s.authorizationPolicies[action]; ok {
+		return policy
+	}
+	return s.authorizationPolicy
+}
+
+// authorizationDegradedCounter lazily creates the counter recording checks answered by the failure policy.
+func (s *Service) authorizationDegradedCounter() metric.Int64Counter {
+	s.authorizationMetricsOnce.Do(func() {
+		provider := s.meterProvider
+		if provider == nil {
+			provider = otel.GetMeterProvider()
+		}
+
+		s.authorizationDegraded, _ = provider.Meter(instrumentationName).Int64Counter("frame.authorization.degraded",
+			metric.WithDescription("Number of authorization checks decided by the failure policy while the authorization service was down"))
+	})
+	return s.authorizationDegraded
+}
+
+// authorizationServiceDown applies the failure policy for the action once the authorization service is found to be down
+func (s *Service) authorizationServiceDown(ctx context.Context, action string, cause error) (bool, error) {
+	policy := s.authorizationFailurePolicy(action)
+	failOpen := policy == AuthorizationFailOpen
+
+	s.authorizationDegradedCounter().Add(ctx, 1, metric.WithAttributes(
+		attribute.String("action", action),
+		attribute.Bool("fail_open", failOpen)))
+
+	s.L(ctx).WithError(cause).WithField("action", action).WithField("fail_open", failOpen).
+		Warn("authorization service is down, applying failure policy")
+
+	if failOpen {
+		return true, nil
+	}
+	return false, fmt.Errorf("%w : %w", ErrAuthorizationServiceDown, cause)
+}
+
 // AuthHasAccess binary check to confirm if subject can perform action specified
 func AuthHasAccess(ctx context.Context, action string, subject string) (bool, error) {
 	authClaims := ClaimsFromContext(ctx)
@@ -22,17 +100,85 @@ func AuthHasAccess(ctx context.Context, action string, subject string) (bool, er
 		return false, errors.New("only authenticated requsts should be used to check authorization")
 	}
 
+	return service.authorizationCheck(ctx, config, ObjectRef{
+		Namespace: authClaims.GetTenantId(),
+		ID:        authClaims.GetPartitionId(),
+	}, action, subject)
+}
+
+// ObjectRef identifies an object access is checked against
+type ObjectRef struct {
+	Namespace string
+	ID        string
+}
+
+const authorizationFilterConcurrency = 10
+
+// AuthFilterAllowed checks whether subject has permission on each of the supplied objects
+// and returns only the allowed ones in their original order. Checks run concurrently in batches,
+// any check that can not be answered fails the whole call unless the failure policy fails open.
+func AuthFilterAllowed(ctx context.Context, subject string, permission string, objects []ObjectRef) ([]ObjectRef, error) {
+	service := FromContext(ctx)
+
+	config, ok := service.Config().(ConfigurationAuthorization)
+	if !ok {
+		return nil, errors.New("could not cast setting to authorization config")
+	}
+
+	if ClaimsFromContext(ctx) == nil {
+		return nil, errors.New("only authenticated requsts should be used to check authorization")
+	}
+
+	allowed := make([]bool, len(objects))
+	errs := make([]error, len(objects))
+
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, authorizationFilterConcurrency)
+	for i, object := range objects {
+		wg.Add(1)
+		semaphore <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+			allowed[i], errs[i] = service.authorizationCheck(ctx, config, object, permission, subject)
+		}()
+	}
+	wg.Wait()
+
+	err := errors.Join(errs...)
+	if err != nil {
+		return nil, err
+	}
+
+	permitted := make([]ObjectRef, 0, len(objects))
+	for i, object := range objects {
+		if allowed[i] {
+			permitted = append(permitted, object)
+		}
+	}
+	return permitted, nil
+}
+
+// authorizationCheck asks the authorization service whether subject holds relation on the object
+func (s *Service) authorizationCheck(ctx context.Context, config ConfigurationAuthorization,
+	object ObjectRef, relation string, subject string) (bool, error) {
+
 	payload := map[string]any{
-		"namespace":  authClaims.GetTenantId(),
-		"object":     authClaims.GetPartitionId(),
-		"relation":   action,
+		"namespace":  object.Namespace,
+		"object":     object.ID,
+		"relation":   relation,
 		"subject_id": subject,
 	}
 
-	status, result, err := service.InvokeRestService(ctx, http.MethodPost,
+	status, result, err := s.InvokeRestService(ctx, http.MethodPost,
 		config.GetAuthorizationServiceReadURI(), payload, nil)
 	if err != nil {
-		return false, err
+		return s.authorizationServiceDown(ctx, relation, err)
+	}
+
+	if status >= http.StatusInternalServerError {
+		return s.authorizationServiceDown(ctx, relation,
+			fmt.Errorf(" invalid response status %d had message %s", status, string(result)))
 	}
 
 	if status > 299 || status < 200 {
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"github.com/pitabwire/frame"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
 	"testing"
 )
 
@@ -107,3 +109,143 @@ func TestAuthHasAccess(t *testing.T) {
 		return
 	}
 }
+
+func TestAuthHasAccessFailurePolicy(t *testing.T) {
+
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachableURL := unreachable.URL
+	unreachable.Close()
+
+	tests := []struct {
+		name       string
+		readURI    string
+		action     string
+		wantAccess bool
+		wantErr    bool
+	}{
+		{name: "Fail open on outage", readURI: outage.URL, action: "view_hint", wantAccess: true},
+		{name: "Fail closed override on outage", readURI: outage.URL, action: "delete", wantErr: true},
+		{name: "Fail open when unreachable", readURI: unreachableURL, action: "view_hint", wantAccess: true},
+		{name: "Fail closed override when unreachable", readURI: unreachableURL, action: "delete", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			meterProvider := newRecordingMeterProvider()
+
+			ctx, srv := frame.NewService("Test Srv",
+				frame.Config(&frame.ConfigurationDefault{AuthorizationServiceReadURI: tt.readURI}),
+				frame.MeterProvider(meterProvider),
+				frame.WithAuthorizationFailurePolicy(frame.AuthorizationFailOpen),
+				frame.WithAuthorizationActionFailurePolicy(frame.AuthorizationFailClosed, "delete"))
+			ctx = frame.ToContext(ctx, srv)
+
+			authClaim := frame.AuthenticationClaims{
+				Ext: map[string]any{
+					"partition_id": "partition",
+					"tenant_id":    "default",
+					"access_id":    "access",
+				}}
+			authClaim.Subject = "profile"
+			ctx = authClaim.ClaimsToContext(ctx)
+
+			access, err := frame.AuthHasAccess(ctx, tt.action, "reader")
+			if access != tt.wantAccess {
+				t.Errorf("AuthHasAccess() access = %v, want %v", access, tt.wantAccess)
+			}
+
+			if tt.wantErr && !errors.Is(err, frame.ErrAuthorizationServiceDown) {
+				t.Errorf("AuthHasAccess() error = %v, want %v", err, frame.ErrAuthorizationServiceDown)
+			}
+
+			if !tt.wantErr && err != nil {
+				t.Errorf("AuthHasAccess() unexpected error = %v", err)
+			}
+
+			if meterProvider.counter("frame.authorization.degraded", "") != 1 {
+				t.Errorf("degraded authorization check was not recorded")
+			}
+		})
+	}
+}
+
+func TestAuthFilterAllowed(t *testing.T) {
+
+	allowedObjects := map[string]bool{"doc-1": true, "doc-3": true, "doc-4": true}
+	checks := make(chan string, 10)
+
+	authorizationServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&payload)
+		object, _ := payload["object"].(string)
+		checks <- object
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"allowed": allowedObjects[object]})
+	}))
+	defer authorizationServer.Close()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.Config(&frame.ConfigurationDefault{AuthorizationServiceReadURI: authorizationServer.URL}))
+	ctx = frame.ToContext(ctx, srv)
+
+	authClaim := frame.AuthenticationClaims{Ext: map[string]any{"tenant_id": "default", "partition_id": "partition"}}
+	authClaim.Subject = "profile"
+	ctx = authClaim.ClaimsToContext(ctx)
+
+	var objects []frame.ObjectRef
+	for i := range 5 {
+		objects = append(objects, frame.ObjectRef{Namespace: "documents", ID: fmt.Sprintf("doc-%d", i)})
+	}
+
+	permitted, err := frame.AuthFilterAllowed(ctx, "reader", "view", objects)
+	if err != nil {
+		t.Fatalf("AuthFilterAllowed() unexpected error = %v", err)
+	}
+
+	want := []frame.ObjectRef{
+		{Namespace: "documents", ID: "doc-1"},
+		{Namespace: "documents", ID: "doc-3"},
+		{Namespace: "documents", ID: "doc-4"},
+	}
+	if !reflect.DeepEqual(permitted, want) {
+		t.Errorf("AuthFilterAllowed() = %v, want %v", permitted, want)
+	}
+
+	if len(checks) != len(objects) {
+		t.Errorf("every object should be checked, got %d checks", len(checks))
+	}
+}
+
+func TestAuthFilterAllowedFailClosed(t *testing.T) {
+
+	outage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer outage.Close()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.Config(&frame.ConfigurationDefault{AuthorizationServiceReadURI: outage.URL}))
+	ctx = frame.ToContext(ctx, srv)
+
+	authClaim := frame.AuthenticationClaims{Ext: map[string]any{"tenant_id": "default", "partition_id": "partition"}}
+	authClaim.Subject = "profile"
+	ctx = authClaim.ClaimsToContext(ctx)
+
+	permitted, err := frame.AuthFilterAllowed(ctx, "reader", "view", []frame.ObjectRef{
+		{Namespace: "documents", ID: "doc-1"},
+		{Namespace: "documents", ID: "doc-2"},
+	})
+	if !errors.Is(err, frame.ErrAuthorizationServiceDown) {
+		t.Errorf("AuthFilterAllowed() error = %v, want %v", err, frame.ErrAuthorizationServiceDown)
+	}
+	if len(permitted) != 0 {
+		t.Errorf("no object should be permitted while the authorization service is down, got %v", permitted)
+	}
+}
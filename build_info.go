@@ -0,0 +1,79 @@
+package frame
+
+import (
+	"net/http"
+	"runtime"
+	"runtime/debug"
+)
+
+const defaultVersionPath = "/version"
+
+// Build metadata injected at link time, for example
+//
+//	go build -ldflags "-X github.com/pitabwire/frame.buildCommit=$(git rev-parse HEAD) -X github.com/pitabwire/frame.buildTime=$(date -u +%FT%TZ)"
+//
+// When not injected the version control details recorded by the go toolchain are used.
+var (
+	buildCommit string
+	buildTime   string
+)
+
+// BuildInfo describes the release and build of the running binary
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+}
+
+// BuildInfo obtains the configured version of the service along with the build metadata of the binary
+func (s *Service) BuildInfo() BuildInfo {
+
+	info := BuildInfo{
+		Version:   s.Version(),
+		Commit:    buildCommit,
+		BuildTime: buildTime,
+		GoVersion: runtime.Version(),
+	}
+
+	binaryInfo, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+
+	info.Module = binaryInfo.Main.Path
+	if binaryInfo.GoVersion != "" {
+		info.GoVersion = binaryInfo.GoVersion
+	}
+
+	for _, setting := range binaryInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if info.Commit == "" {
+				info.Commit = setting.Value
+			}
+		case "vcs.time":
+			if info.BuildTime == "" {
+				info.BuildTime = setting.Value
+			}
+		case "vcs.modified":
+			info.Modified = setting.Value == "true"
+		}
+	}
+
+	return info
+}
+
+// HandleVersion writes the build info of the service as json
+func (s *Service) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	_ = WriteJSON(r.Context(), w, http.StatusOK, s.BuildInfo())
+}
+
+// VersionPath Option to change the path the build info is served on, by default this is /version
+func VersionPath(path string) Option {
+	return func(s *Service) {
+		s.versionPath = path
+	}
+}
@@ -0,0 +1,65 @@
+package frame_test
+
+import (
+	"encoding/json"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+)
+
+func TestService_VersionEndpoint(t *testing.T) {
+
+	tests := []struct {
+		name string
+		opts []frame.Option
+		path string
+	}{
+		{name: "Default path", path: "/version"},
+		{name: "Custom path", opts: []frame.Option{frame.VersionPath("/_build")}, path: "/_build"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			opts := append([]frame.Option{frame.NoopDriver(), frame.WithVersion("v1.4.2")}, tt.opts...)
+			ctx, srv := frame.NewService("Test Srv", opts...)
+			defer srv.Stop(ctx)
+
+			err := srv.Run(ctx, "")
+			if err != nil {
+				t.Fatalf("could not run service : %s", err)
+			}
+
+			ts := httptest.NewServer(srv.H())
+			defer ts.Close()
+
+			resp, err := http.Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatalf("could not fetch the build info : %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("build info was served with status %d", resp.StatusCode)
+			}
+
+			var info map[string]any
+			err = json.NewDecoder(resp.Body).Decode(&info)
+			if err != nil {
+				t.Fatalf("could not decode the build info : %s", err)
+			}
+
+			if info["version"] != "v1.4.2" {
+				t.Errorf("version %v, expected the configured v1.4.2", info["version"])
+			}
+			if info["go_version"] != runtime.Version() {
+				t.Errorf("go version %v, expected %s", info["go_version"], runtime.Version())
+			}
+			if srv.BuildInfo().Version != "v1.4.2" {
+				t.Errorf("BuildInfo should report the configured version")
+			}
+		})
+	}
+}
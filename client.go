@@ -9,12 +9,73 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"time"
 )
 
+// RoundTripMiddleware wraps the transport used for outbound http calls,
+// allowing per request logic like signing, custom headers or metrics to be injected.
+type RoundTripMiddleware func(next http.RoundTripper) http.RoundTripper
+
+// RoundTripperFunc adapts a function to the http.RoundTripper interface
+type RoundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f RoundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// WithHTTPClientMiddleware Option that wraps the transport of the service http client with the supplied middleware.
+// The first middleware is the outermost, so it sees each request first and each response last.
+func WithHTTPClientMiddleware(middleware ...RoundTripMiddleware) Option {
+	return func(s *Service) {
+		transport := s.client.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		for i := len(middleware) - 1; i >= 0; i-- {
+			transport = middleware[i](transport)
+		}
+
+		s.client.Transport = transport
+	}
+}
+
+type invokeOptions struct {
+	timeout time.Duration
+}
+
+// InvokeOption customizes a single outbound http call.
+type InvokeOption func(opts *invokeOptions)
+
+// WithHTTPTimeout InvokeOption that overrides the client timeout for a single call.
+// The shared service client is left untouched so other concurrent calls keep the default timeout.
+func WithHTTPTimeout(timeout time.Duration) InvokeOption {
+	return func(opts *invokeOptions) {
+		opts.timeout = timeout
+	}
+}
+
+// invokeClient obtains the http client to use for a call with the supplied options applied.
+func (s *Service) invokeClient(opts ...InvokeOption) *http.Client {
+
+	callOpts := &invokeOptions{}
+	for _, opt := range opts {
+		opt(callOpts)
+	}
+
+	if callOpts.timeout <= 0 {
+		return s.client
+	}
+
+	callClient := *s.client
+	callClient.Timeout = callOpts.timeout
+	return &callClient
+}
+
 // InvokeRestService convenience method to call a http endpoint and utilize the raw results
 func (s *Service) InvokeRestService(ctx context.Context,
 	method string, endpointURL string, payload map[string]any,
-	headers map[string][]string) (int, []byte, error) {
+	headers map[string][]string, opts ...InvokeOption) (int, []byte, error) {
 
 	if headers == nil {
 		headers = map[string][]string{
@@ -38,13 +99,14 @@ func (s *Service) InvokeRestService(ctx context.Context,
 		return 0, nil, err
 	}
 
-	req.Header = headers
+	req.Header = http.Header(headers).Clone()
+	injectContextHeaders(ctx, req.Header)
 
 	reqDump, _ := httputil.DumpRequestOut(req, true)
 
 	s.L(ctx).WithField("request", string(reqDump)).Debug("request out")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.invokeClient(opts...).Do(req)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -63,7 +125,7 @@ func (s *Service) InvokeRestService(ctx context.Context,
 // InvokeRestServiceUrlEncoded convenience method to call a http endpoint and utilize the raw results
 func (s *Service) InvokeRestServiceUrlEncoded(ctx context.Context,
 	method string, endpointURL string, payload url.Values,
-	headers map[string]string) (int, []byte, error) {
+	headers map[string]string, opts ...InvokeOption) (int, []byte, error) {
 
 	if headers == nil {
 		headers = map[string]string{
@@ -81,11 +143,12 @@ func (s *Service) InvokeRestServiceUrlEncoded(ctx context.Context,
 	for key, val := range headers {
 		req.Header.Set(key, val)
 	}
+	injectContextHeaders(ctx, req.Header)
 
 	reqDump, _ := httputil.DumpRequestOut(req, true)
 	logger.WithField("request", string(reqDump)).Info("request out")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.invokeClient(opts...).Do(req)
 	if err != nil {
 		return 0, nil, err
 	}
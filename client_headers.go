@@ -0,0 +1,44 @@
+package frame
+
+import (
+	"net/http"
+)
+
+// WithHTTPClientDefaultHeaders Option that adds headers, such as an api version or user agent, to every outbound http call
+// of the service. Headers set on a call take precedence over the defaults with the same name.
+// Register it before middleware that needs to see the headers, like a request signer covering them.
+func WithHTTPClientDefaultHeaders(headers http.Header) Option {
+	return WithHTTPClientMiddleware(DefaultHeaders(headers))
+}
+
+// DefaultHeaders creates a RoundTripMiddleware setting the supplied headers on requests that do not already have them.
+// The request is cloned before being changed, so the defaults are applied afresh whenever a request is resent.
+func DefaultHeaders(headers http.Header) RoundTripMiddleware {
+
+	defaults := make(http.Header, len(headers))
+	for key, values := range headers {
+		defaults[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			var outbound *http.Request
+			for key, values := range defaults {
+				if _, ok := req.Header[key]; ok {
+					continue
+				}
+
+				if outbound == nil {
+					outbound = req.Clone(req.Context())
+				}
+				outbound.Header[key] = append([]string(nil), values...)
+			}
+
+			if outbound == nil {
+				return next.RoundTrip(req)
+			}
+			return next.RoundTrip(outbound)
+		})
+	}
+}
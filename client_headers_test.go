@@ -0,0 +1,56 @@
+package frame_test
+
+import (
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestService_WithHTTPClientDefaultHeaders(t *testing.T) {
+
+	received := make(chan http.Header, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv", frame.WithHTTPClientDefaultHeaders(http.Header{
+		"user-agent":    {"frame-test/1.0"},
+		"X-Api-Version": {"2024-01-01"},
+	}))
+
+	tests := []struct {
+		name    string
+		headers map[string][]string
+		want    map[string]string
+	}{
+		{
+			name:    "Defaults applied",
+			headers: map[string][]string{"Accept": {"application/json"}},
+			want:    map[string]string{"User-Agent": "frame-test/1.0", "X-Api-Version": "2024-01-01", "Accept": "application/json"},
+		},
+		{
+			name:    "Call headers override defaults",
+			headers: map[string][]string{"X-Api-Version": {"2025-06-01"}},
+			want:    map[string]string{"User-Agent": "frame-test/1.0", "X-Api-Version": "2025-06-01"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, tt.headers)
+			if err != nil || status != http.StatusOK {
+				t.Fatalf("call failed %d : %v", status, err)
+			}
+
+			headers := <-received
+			for key, value := range tt.want {
+				if got := headers.Values(key); len(got) != 1 || got[0] != value {
+					t.Errorf("header %s is %v, expected %q", key, got, value)
+				}
+			}
+		})
+	}
+}
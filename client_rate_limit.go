@@ -0,0 +1,127 @@
+package frame
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrOutboundRateLimited is returned by fail fast outbound rate limits when no call can be made right now
+var ErrOutboundRateLimited = NewError(ErrorCodeRateLimited, "outbound rate limit exceeded")
+
+// WithOutboundRateLimit Option that throttles outbound http calls to rps requests per second for each host,
+// allowing bursts of up to burst calls. Calls over the limit wait for their turn or until their context is done.
+func WithOutboundRateLimit(rps float64, burst int) Option {
+	return WithHTTPClientMiddleware(OutboundRateLimiter(rps, burst, false))
+}
+
+// WithOutboundRateLimitFailFast Option that limits outbound http calls like WithOutboundRateLimit,
+// except calls over the limit fail immediately with ErrOutboundRateLimited instead of waiting.
+func WithOutboundRateLimitFailFast(rps float64, burst int) Option {
+	return WithHTTPClientMiddleware(OutboundRateLimiter(rps, burst, true))
+}
+
+// OutboundRateLimiter creates a RoundTripMiddleware applying a token bucket per request host
+func OutboundRateLimiter(rps float64, burst int, failFast bool) RoundTripMiddleware {
+
+	if burst < 1 {
+		burst = 1
+	}
+
+	var mu sync.Mutex
+	buckets := map[string]*tokenBucket{}
+
+	bucketFor := func(host string) *tokenBucket {
+		mu.Lock()
+		defer mu.Unlock()
+
+		bucket, ok := buckets[host]
+		if !ok {
+			bucket = newTokenBucket(rps, burst)
+			buckets[host] = bucket
+		}
+		return bucket
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+
+			bucket := bucketFor(req.URL.Host)
+
+			if failFast {
+				if !bucket.allow(time.Now()) {
+					return nil, ErrOutboundRateLimited
+				}
+				return next.RoundTrip(req)
+			}
+
+			delay := bucket.reserve(time.Now())
+			if delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-req.Context().Done():
+					timer.Stop()
+					bucket.release()
+					return nil, req.Context().Err()
+				case <-timer.C:
+				}
+			}
+
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// tokenBucket refills at rate tokens per second up to burst tokens
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// allow takes a token only when one is available right away
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// reserve takes a token returning how long the caller has to wait before it may be used
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 || b.rate <= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// release hands back a reserved token that ended up not being used
+func (b *tokenBucket) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.burst, b.tokens+1)
+}
@@ -0,0 +1,97 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_OutboundRateLimit(t *testing.T) {
+
+	var mu sync.Mutex
+	var calledAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calledAt = append(calledAt, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv", frame.WithOutboundRateLimit(10, 1))
+
+	start := time.Now()
+	for range 4 {
+		status, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, nil)
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("could not invoke service %d : %v", status, err)
+		}
+	}
+
+	// The first call uses the burst, the remaining three each wait for a token refilled every 100ms
+	if elapsed := time.Since(start); elapsed < 280*time.Millisecond {
+		t.Errorf("calls were not spaced to the configured rate, took %s", elapsed)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 1; i < len(calledAt); i++ {
+		if gap := calledAt[i].Sub(calledAt[i-1]); gap < 80*time.Millisecond {
+			t.Errorf("call %d followed the previous one after only %s", i, gap)
+		}
+	}
+}
+
+func TestService_OutboundRateLimitCanceledWait(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv", frame.WithOutboundRateLimit(0.5, 1))
+
+	status, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, nil)
+	if err != nil || status != http.StatusOK {
+		t.Fatalf("could not invoke service %d : %v", status, err)
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err = srv.InvokeRestService(waitCtx, http.MethodGet, server.URL, nil, nil)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("a waiting call should be aborted by its context, got %v", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Errorf("a waiting call did not return promptly once its context expired")
+	}
+}
+
+func TestService_OutboundRateLimitFailFast(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv", frame.WithOutboundRateLimitFailFast(1, 2))
+
+	for range 2 {
+		status, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, nil)
+		if err != nil || status != http.StatusOK {
+			t.Fatalf("calls within the burst should succeed %d : %v", status, err)
+		}
+	}
+
+	_, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, nil)
+	if !errors.Is(err, frame.ErrOutboundRateLimited) {
+		t.Errorf("calls over the limit should fail fast, got %v", err)
+	}
+}
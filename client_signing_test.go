@@ -0,0 +1,89 @@
+package frame_test
+
+import (
+	"errors"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_WithRequestSigner(t *testing.T) {
+
+	var mu sync.Mutex
+	var signatures []string
+	var verifyErrors []error
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keyID, err := frame.VerifyRequestSignature(r, "partner-secret")
+		if err == nil && keyID != "partner-key" {
+			err = errors.New("unexpected key id " + keyID)
+		}
+
+		mu.Lock()
+		signatures = append(signatures, r.Header.Get("Authorization"))
+		verifyErrors = append(verifyErrors, err)
+		mu.Unlock()
+
+		if err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.WithRequestSigner("partner-key", "partner-secret", "X-Partner-Id"))
+
+	headers := map[string][]string{
+		"Content-Type": {"application/json"},
+		"X-Partner-Id": {"partner-1"},
+	}
+
+	for i := range 2 {
+		if i > 0 {
+			time.Sleep(1100 * time.Millisecond)
+		}
+
+		status, _, err := srv.InvokeRestService(ctx, http.MethodPost, server.URL+"/orders?page=1",
+			map[string]any{"amount": 10}, headers)
+		if err != nil || status != http.StatusOK {
+			t.Errorf("signed request was not accepted %d : %v", status, err)
+		}
+	}
+
+	for _, err := range verifyErrors {
+		if err != nil {
+			t.Errorf("signature did not verify : %s", err)
+		}
+	}
+
+	if len(signatures) != 2 || signatures[0] == signatures[1] {
+		t.Errorf("each attempt should carry a freshly computed signature, got %v", signatures)
+	}
+}
+
+func TestVerifyRequestSignatureRejectsTampering(t *testing.T) {
+
+	var verifyErr error
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, verifyErr = frame.VerifyRequestSignature(r, "another-secret")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	ctx, srv := frame.NewService("Test Srv", frame.WithRequestSigner("partner-key", "partner-secret"))
+
+	_, _, err := srv.InvokeRestService(ctx, http.MethodPost, server.URL, map[string]any{"amount": 10}, nil)
+	if err != nil {
+		t.Errorf("could not invoke service : %s", err)
+		return
+	}
+
+	if !errors.Is(verifyErr, frame.ErrInvalidRequestSignature) {
+		t.Errorf("signature made with a different secret should not verify, got %v", verifyErr)
+	}
+}
@@ -0,0 +1,109 @@
+package frame_test
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestService_InvokeRestServiceWithHTTPTimeout(t *testing.T) {
+
+	slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slowServer.Close()
+
+	ctx, srv := frame.NewService("Test Srv")
+
+	var wg sync.WaitGroup
+	var timedOutErr, defaultErr error
+	var defaultStatus int
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _, timedOutErr = srv.InvokeRestService(ctx, http.MethodGet, slowServer.URL, nil, nil,
+			frame.WithHTTPTimeout(100*time.Millisecond))
+	}()
+	go func() {
+		defer wg.Done()
+		defaultStatus, _, defaultErr = srv.InvokeRestService(ctx, http.MethodGet, slowServer.URL, nil, nil)
+	}()
+	wg.Wait()
+
+	if timedOutErr == nil {
+		t.Errorf("call with a short timeout should have timed out")
+	}
+
+	if defaultErr != nil || defaultStatus != http.StatusOK {
+		t.Errorf("concurrent call with the default client should not be affected : %d %v", defaultStatus, defaultErr)
+	}
+
+	status, _, err := srv.InvokeRestServiceUrlEncoded(context.Background(), http.MethodPost, slowServer.URL, nil, nil,
+		frame.WithHTTPTimeout(2*time.Second))
+	if err != nil || status != http.StatusOK {
+		t.Errorf("call within its timeout should succeed : %d %v", status, err)
+	}
+}
+
+func TestService_HTTPClientMiddleware(t *testing.T) {
+
+	var receivedHeaders []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		receivedHeaders = append(receivedHeaders, r.Header.Get("X-Signature"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var order []string
+	var attempts int
+	tracking := func(name string) frame.RoundTripMiddleware {
+		return func(next http.RoundTripper) http.RoundTripper {
+			return frame.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				order = append(order, name)
+				return next.RoundTrip(req)
+			})
+		}
+	}
+
+	signing := func(next http.RoundTripper) http.RoundTripper {
+		return frame.RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			attempts++
+			req.Header.Set("X-Signature", "signed")
+			return next.RoundTrip(req)
+		})
+	}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.WithHTTPClientMiddleware(tracking("outer"), signing, tracking("inner")))
+
+	for range 3 {
+		status, _, err := srv.InvokeRestService(ctx, http.MethodGet, server.URL, nil, nil)
+		if err != nil || status != http.StatusOK {
+			t.Errorf("could not invoke service %d : %v", status, err)
+			return
+		}
+	}
+
+	if attempts != 3 {
+		t.Errorf("middleware should see every request, saw %d", attempts)
+	}
+
+	for _, header := range receivedHeaders {
+		if header != "signed" {
+			t.Errorf("middleware header mutation did not reach the server, got %q", header)
+		}
+	}
+
+	if len(order) != 6 || order[0] != "outer" || order[1] != "inner" {
+		t.Errorf("middleware should run in the order supplied, got %v", order)
+	}
+}
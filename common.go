@@ -10,6 +10,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -44,21 +45,27 @@ func (model *BaseModel) GenID(ctx context.Context) {
 
 	model.ID = xid.New().String()
 
+	partitionInfoFromClaims(ctx, &model.TenantID, &model.PartitionID, &model.AccessID)
+}
+
+// partitionInfoFromClaims fills in the empty tenancy fields from the authentication claims in the context
+func partitionInfoFromClaims(ctx context.Context, tenantID, partitionID, accessID *string) {
+
 	authClaim := ClaimsFromContext(ctx)
 	if authClaim == nil {
 		return
 	}
 
-	if model.AccessID == "" && authClaim.GetAccessId() != "" {
-		model.AccessID = authClaim.GetAccessId()
+	if *accessID == "" && authClaim.GetAccessId() != "" {
+		*accessID = authClaim.GetAccessId()
 	}
 
-	if model.PartitionID == "" && authClaim.GetPartitionId() != "" {
-		model.PartitionID = authClaim.GetPartitionId()
+	if *partitionID == "" && authClaim.GetPartitionId() != "" {
+		*partitionID = authClaim.GetPartitionId()
 	}
 
-	if model.TenantID == "" && authClaim.GetTenantId() != "" {
-		model.TenantID = authClaim.GetTenantId()
+	if *tenantID == "" && authClaim.GetTenantId() != "" {
+		*tenantID = authClaim.GetTenantId()
 	}
 }
 
@@ -109,6 +116,55 @@ func (model *BaseModel) CopyPartitionInfo(parent *BaseModel) {
 	}
 }
 
+// IntKeyBaseModel base table struct for models keyed by an auto incremented integer, such as legacy tables.
+// It tracks the same timestamps, version and tenancy info as BaseModel and works with BaseRepository,
+// which receives the id in its decimal string form.
+type IntKeyBaseModel struct {
+	ID          int64 `gorm:"primaryKey;autoIncrement"`
+	CreatedAt   time.Time
+	ModifiedAt  time.Time
+	Version     uint           `gorm:"DEFAULT 0"`
+	TenantID    string         `gorm:"type:varchar(50);"`
+	PartitionID string         `gorm:"type:varchar(50);"`
+	AccessID    string         `gorm:"type:varchar(50);"`
+	DeletedAt   gorm.DeletedAt `sql:"index"`
+}
+
+func (model *IntKeyBaseModel) GetID() string {
+	if model.ID == 0 {
+		return ""
+	}
+	return strconv.FormatInt(model.ID, 10)
+}
+
+func (model *IntKeyBaseModel) GetVersion() uint {
+	return model.Version
+}
+
+// BeforeSave Ensures we update a migrations time stamps
+func (model *IntKeyBaseModel) BeforeSave(db *gorm.DB) error {
+	return model.BeforeCreate(db)
+}
+
+func (model *IntKeyBaseModel) BeforeCreate(db *gorm.DB) error {
+
+	if model.Version <= 0 {
+		model.CreatedAt = time.Now()
+		model.ModifiedAt = time.Now()
+		model.Version = 1
+	}
+
+	partitionInfoFromClaims(db.Statement.Context, &model.TenantID, &model.PartitionID, &model.AccessID)
+	return nil
+}
+
+// BeforeUpdate Updates time stamp every time we update the model
+func (model *IntKeyBaseModel) BeforeUpdate(db *gorm.DB) error {
+	model.ModifiedAt = time.Now()
+	model.Version += 1
+	return nil
+}
+
 // Migration Our simple table holding all the migration data
 type Migration struct {
 	BaseModel
@@ -175,7 +231,28 @@ func GetMacAddress() string {
 	return ""
 }
 
-// ConfigProcess convenience method to processFunc configs
+// ConfigProcess convenience method to processFunc configs.
+// Values can also be supplied as files, as done for docker and kubernetes secrets, by setting the variable
+// name suffixed with _FILE to the file path e.g. DATABASE_PASSWORD_FILE=/run/secrets/db_password.
+// The file content takes precedence over a value set directly in the plain variable.
+// Only the files of the variables config reads are loaded and the environment is left untouched.
 func ConfigProcess(prefix string, config any) error {
-	return envconfig.Process(prefix, config)
+	fields, err := configFields(prefix, config)
+	if err != nil {
+		return err
+	}
+
+	hasFiles := false
+	for _, field := range fields {
+		if field.filePath() != "" {
+			hasFiles = true
+			break
+		}
+	}
+
+	if !hasFiles {
+		return envconfig.Process(prefix, config)
+	}
+
+	return processConfigFields(fields)
 }
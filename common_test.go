@@ -6,9 +6,12 @@ import (
 	"github.com/pitabwire/frame"
 	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
 	"slices"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetLocalIP(t *testing.T) {
@@ -124,3 +127,100 @@ func Test_ConfigCastingIssues(t *testing.T) {
 	}
 
 }
+
+func Test_ConfigProcessFromFiles(t *testing.T) {
+
+	secretsDir := t.TempDir()
+
+	passwordFile := filepath.Join(secretsDir, "db_url")
+	err := os.WriteFile(passwordFile, []byte("postgres://from-file\n"), 0o600)
+	if err != nil {
+		t.Fatalf("could not write secret file : %v", err)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://from-env")
+	t.Setenv("DATABASE_URL_FILE", passwordFile)
+	t.Setenv("PORT", ":7777")
+
+	var conf name
+	err = frame.ConfigProcess("", &conf)
+	if err != nil {
+		t.Fatalf(" could not load config from env : %v", err)
+	}
+
+	if !slices.Equal(conf.GetDatabasePrimaryHostURL(), []string{"postgres://from-file"}) {
+		t.Errorf("file content should take precedence over the plain variable, got %v", conf.GetDatabasePrimaryHostURL())
+	}
+
+	if conf.ServerPort != ":7777" {
+		t.Errorf("plain variables should still be processed, got %q", conf.ServerPort)
+	}
+
+	if os.Getenv("DATABASE_URL") != "postgres://from-env" {
+		t.Errorf("processing config should not change the environment, got %q", os.Getenv("DATABASE_URL"))
+	}
+
+	t.Setenv("DATABASE_URL_FILE", filepath.Join(secretsDir, "missing"))
+	err = frame.ConfigProcess("", &conf)
+	if err == nil {
+		t.Errorf("a missing config file should fail config loading")
+	}
+}
+
+func Test_ConfigProcessFromFilesOnlyConfigKeys(t *testing.T) {
+
+	secretsDir := t.TempDir()
+
+	tokenFile := filepath.Join(secretsDir, "token")
+	err := os.WriteFile(tokenFile, []byte("token-from-file\n"), 0o600)
+	if err != nil {
+		t.Fatalf("could not write secret file : %v", err)
+	}
+
+	t.Setenv("UNRELATED_SETTING_FILE", filepath.Join(secretsDir, "missing"))
+	t.Setenv("SERVICE_TOKEN_FILE", tokenFile)
+
+	var conf struct {
+		ServiceToken string        `envconfig:"SERVICE_TOKEN" required:"true"`
+		Timeout      time.Duration `envconfig:"SERVICE_TIMEOUT" default:"3s"`
+	}
+	err = frame.ConfigProcess("", &conf)
+	if err != nil {
+		t.Fatalf("files of variables the config does not read should be ignored : %v", err)
+	}
+
+	if conf.ServiceToken != "token-from-file" || conf.Timeout != 3*time.Second {
+		t.Errorf("required value should be read from its file along with the defaults, got %+v", conf)
+	}
+
+	if _, ok := os.LookupEnv("SERVICE_TOKEN"); ok {
+		t.Errorf("processing config should not set the variables read from files")
+	}
+}
+
+func Test_ConfigProcessFromFilesMatchesEnvironment(t *testing.T) {
+
+	urlFile := filepath.Join(t.TempDir(), "db_url")
+	err := os.WriteFile(urlFile, []byte("postgres://same"), 0o600)
+	if err != nil {
+		t.Fatalf("could not write secret file : %v", err)
+	}
+
+	t.Setenv("DATABASE_URL", "postgres://same")
+	var fromEnv frame.ConfigurationDefault
+	err = frame.ConfigProcess("", &fromEnv)
+	if err != nil {
+		t.Fatalf("could not load config from env : %v", err)
+	}
+
+	t.Setenv("DATABASE_URL_FILE", urlFile)
+	var fromFile frame.ConfigurationDefault
+	err = frame.ConfigProcess("", &fromFile)
+	if err != nil {
+		t.Fatalf("could not load config from file : %v", err)
+	}
+
+	if !reflect.DeepEqual(fromEnv, fromFile) {
+		t.Errorf("config read with a file differs from the one read from the environment\n%+v\n%+v", fromFile, fromEnv)
+	}
+}
@@ -12,9 +12,11 @@ import (
 const ctxKeyConfiguration = contextKey("configurationKey")
 
 // Config Option that helps to specify or override the configuration object of our service.
+// When a secret provider is setup, secret:// references in the configuration are resolved.
 func Config(config any) Option {
 	return func(s *Service) {
 		s.configuration = config
+		s.resolveConfigSecrets()
 	}
 }
 
@@ -22,6 +24,13 @@ func (s *Service) Config() any {
 	return s.configuration
 }
 
+// ConfigAs obtains the service configuration asserted to the type T.
+// ok is false when no configuration is set or it is of a different type.
+func ConfigAs[T any](s *Service) (T, bool) {
+	config, ok := s.Config().(T)
+	return config, ok
+}
+
 // ConfigToContext adds service configuration to the current supplied context
 func ConfigToContext(ctx context.Context, config any) context.Context {
 	return context.WithValue(ctx, ctxKeyConfiguration, config)
@@ -40,6 +49,11 @@ type ConfigurationDefault struct {
 	HttpServerPort string `default:":8080" envconfig:"HTTP_PORT"`
 	GrpcServerPort string `default:":50051" envconfig:"GRPC_PORT"`
 
+	HttpServerReadTimeout       time.Duration `default:"5s" envconfig:"HTTP_SERVER_READ_TIMEOUT"`
+	HttpServerReadHeaderTimeout time.Duration `default:"2s" envconfig:"HTTP_SERVER_READ_HEADER_TIMEOUT"`
+	HttpServerWriteTimeout      time.Duration `default:"10s" envconfig:"HTTP_SERVER_WRITE_TIMEOUT"`
+	HttpServerIdleTimeout       time.Duration `default:"120s" envconfig:"HTTP_SERVER_IDLE_TIMEOUT"`
+
 	CORSEnabled          bool     `default:"false" envconfig:"CORS_ENABLED"`
 	CORSAllowCredentials bool     `default:"false" envconfig:"CORS_ALLOW_CREDENTIALS"`
 	CORSAllowedHeaders   []string `default:"Authorization" envconfig:"CORS_ALLOWED_HEADERS"`
@@ -74,6 +88,10 @@ type ConfigurationDefault struct {
 
 	EventsQueueName string `default:"frame.events.internal_._queue" envconfig:"EVENTS_QUEUE_NAME"`
 	EventsQueueUrl  string `default:"mem://frame.events.internal_._queue" envconfig:"EVENTS_QUEUE_URL"`
+
+	FeatureFlags []string `envconfig:"FEATURE_FLAGS"`
+
+	QueueTopology QueueTopology `envconfig:"QUEUE_TOPOLOGY"`
 }
 
 type ConfigurationSecurity interface {
@@ -147,6 +165,31 @@ func (c *ConfigurationDefault) GrpcPort() string {
 	return c.Port()
 }
 
+type ConfigurationHTTPServer interface {
+	GetHTTPReadTimeout() time.Duration
+	GetHTTPReadHeaderTimeout() time.Duration
+	GetHTTPWriteTimeout() time.Duration
+	GetHTTPIdleTimeout() time.Duration
+}
+
+var _ ConfigurationHTTPServer = new(ConfigurationDefault)
+
+func (c *ConfigurationDefault) GetHTTPReadTimeout() time.Duration {
+	return c.HttpServerReadTimeout
+}
+
+func (c *ConfigurationDefault) GetHTTPReadHeaderTimeout() time.Duration {
+	return c.HttpServerReadHeaderTimeout
+}
+
+func (c *ConfigurationDefault) GetHTTPWriteTimeout() time.Duration {
+	return c.HttpServerWriteTimeout
+}
+
+func (c *ConfigurationDefault) GetHTTPIdleTimeout() time.Duration {
+	return c.HttpServerIdleTimeout
+}
+
 type ConfigurationCORS interface {
 	IsCORSEnabled() bool
 	IsCORSAllowCredentials() bool
@@ -312,3 +355,23 @@ func (c *ConfigurationDefault) SetTLSCertAndKeyPath(certificatePath, certificate
 	c.TLSCertificatePath = certificatePath
 	c.TLSCertificateKeyPath = certificateKeyPath
 }
+
+type ConfigurationFeatureFlags interface {
+	GetFeatureFlags() []string
+}
+
+var _ ConfigurationFeatureFlags = new(ConfigurationDefault)
+
+func (c *ConfigurationDefault) GetFeatureFlags() []string {
+	return c.FeatureFlags
+}
+
+type ConfigurationQueueTopology interface {
+	GetQueueTopology() QueueTopology
+}
+
+var _ ConfigurationQueueTopology = new(ConfigurationDefault)
+
+func (c *ConfigurationDefault) GetQueueTopology() QueueTopology {
+	return c.QueueTopology
+}
@@ -0,0 +1,216 @@
+package frame
+
+import (
+	"encoding"
+	"fmt"
+	"github.com/kelseyhightower/envconfig"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// configFileSuffix marks environment variables holding the path of a file with the actual value
+const configFileSuffix = "_FILE"
+
+// configField is a variable read by envconfig along with the struct field it sets
+type configField struct {
+	name  string
+	key   string
+	alt   string
+	value reflect.Value
+	tags  reflect.StructTag
+}
+
+// configFields lists the variables envconfig reads for config, obtained through its usage template
+// as that is the only way envconfig exposes them
+func configFields(prefix string, config any) ([]configField, error) {
+
+	var fields []configField
+	tmpl := template.Must(template.New("fields").Funcs(template.FuncMap{
+		"field": func(name string, key string, alt string, value reflect.Value, tags reflect.StructTag) string {
+			fields = append(fields, configField{name: name, key: key, alt: alt, value: value, tags: tags})
+			return ""
+		},
+	}).Parse(`{{range .}}{{field .Name .Key .Alt .Field .Tags}}{{end}}`))
+
+	err := envconfig.Usaget(prefix, config, io.Discard, tmpl)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// filePath obtains the path of the file holding the value of the variable if one is set
+func (cf configField) filePath() string {
+	if path := os.Getenv(cf.key + configFileSuffix); path != "" {
+		return path
+	}
+	if cf.alt != "" {
+		return os.Getenv(cf.alt + configFileSuffix)
+	}
+	return ""
+}
+
+// lookup resolves the value of the variable the way envconfig does, a file taking precedence over the environment
+func (cf configField) lookup() (string, bool, error) {
+
+	if path := cf.filePath(); path != "" {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return "", false, fmt.Errorf("could not read config file %s for %s : %w", path, cf.key, err)
+		}
+		return strings.TrimRight(string(content), "\r\n"), true, nil
+	}
+
+	value, ok := os.LookupEnv(cf.key)
+	if !ok && cf.alt != "" {
+		value, ok = os.LookupEnv(cf.alt)
+	}
+
+	if def := cf.tags.Get("default"); def != "" && !ok {
+		return def, true, nil
+	}
+	return value, ok, nil
+}
+
+// processConfigFields sets the fields from their resolved values, following envconfig.Process
+func processConfigFields(fields []configField) error {
+
+	for _, field := range fields {
+
+		value, ok, err := field.lookup()
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			if required, _ := strconv.ParseBool(field.tags.Get("required")); required {
+				key := field.key
+				if field.alt != "" {
+					key = field.alt
+				}
+				return fmt.Errorf("required key %s missing value", key)
+			}
+			continue
+		}
+
+		err = setConfigValue(field.value, value)
+		if err != nil {
+			return &envconfig.ParseError{
+				KeyName:   field.key,
+				FieldName: field.name,
+				TypeName:  field.value.Type().String(),
+				Value:     value,
+				Err:       err,
+			}
+		}
+	}
+
+	return nil
+}
+
+// setConfigValue decodes value into field the way envconfig does
+func setConfigValue(field reflect.Value, value string) error {
+
+	if field.CanAddr() {
+		switch target := field.Addr().Interface().(type) {
+		case envconfig.Decoder:
+			return target.Decode(value)
+		case envconfig.Setter:
+			return target.Set(value)
+		case encoding.TextUnmarshaler:
+			return target.UnmarshalText([]byte(value))
+		case encoding.BinaryUnmarshaler:
+			return target.UnmarshalBinary([]byte(value))
+		}
+	}
+
+	typ := field.Type()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+		if field.IsNil() {
+			field.Set(reflect.New(typ))
+		}
+		return setConfigValue(field.Elem(), value)
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if typ == reflect.TypeFor[time.Duration]() {
+			duration, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(duration))
+			return nil
+		}
+		val, err := strconv.ParseInt(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(val)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		val, err := strconv.ParseUint(value, 0, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(val)
+	case reflect.Bool:
+		val, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(val)
+	case reflect.Float32, reflect.Float64:
+		val, err := strconv.ParseFloat(value, typ.Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(val)
+	case reflect.Slice:
+		list := reflect.MakeSlice(typ, 0, 0)
+		if typ.Elem().Kind() == reflect.Uint8 {
+			list = reflect.ValueOf([]byte(value)).Convert(typ)
+		} else if strings.TrimSpace(value) != "" {
+			items := strings.Split(value, ",")
+			list = reflect.MakeSlice(typ, len(items), len(items))
+			for i, item := range items {
+				err := setConfigValue(list.Index(i), item)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		field.Set(list)
+	case reflect.Map:
+		entries := reflect.MakeMap(typ)
+		if strings.TrimSpace(value) != "" {
+			for _, pair := range strings.Split(value, ",") {
+				k, v, found := strings.Cut(pair, ":")
+				if !found || strings.Contains(v, ":") {
+					return fmt.Errorf("invalid map item: %q", pair)
+				}
+				key := reflect.New(typ.Key()).Elem()
+				err := setConfigValue(key, k)
+				if err != nil {
+					return err
+				}
+				val := reflect.New(typ.Elem()).Elem()
+				err = setConfigValue(val, v)
+				if err != nil {
+					return err
+				}
+				entries.SetMapIndex(key, val)
+			}
+		}
+		field.Set(entries)
+	}
+
+	return nil
+}
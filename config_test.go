@@ -0,0 +1,39 @@
+package frame_test
+
+import (
+	"github.com/pitabwire/frame"
+	"testing"
+)
+
+type otherConfiguration struct {
+	Name string
+}
+
+func TestConfigAs(t *testing.T) {
+
+	defaultConfig := &frame.ConfigurationDefault{ServerPort: ":7654"}
+	_, srv := frame.NewService("Test Srv", frame.Config(defaultConfig), frame.NoopDriver())
+
+	config, ok := frame.ConfigAs[*frame.ConfigurationDefault](srv)
+	if !ok {
+		t.Fatalf("configuration should be of type ConfigurationDefault")
+	}
+	if config.ServerPort != ":7654" {
+		t.Errorf("server port = %q, want %q", config.ServerPort, ":7654")
+	}
+
+	ports, ok := frame.ConfigAs[frame.ConfigurationPorts](srv)
+	if !ok || ports.Port() != ":7654" {
+		t.Errorf("configuration should satisfy ConfigurationPorts")
+	}
+
+	other, ok := frame.ConfigAs[*otherConfiguration](srv)
+	if ok || other != nil {
+		t.Errorf("configuration of a different type should not be returned, got %v", other)
+	}
+
+	_, emptySrv := frame.NewService("Test Srv", frame.NoopDriver())
+	if _, ok = frame.ConfigAs[*frame.ConfigurationDefault](emptySrv); ok {
+		t.Errorf("a service without configuration should not report a match")
+	}
+}
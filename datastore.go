@@ -9,20 +9,43 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/stdlib"
 	"gocloud.dev/server/health/sqlhealth"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
 	"gorm.io/datatypes"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/schema"
+	"math"
 	"math/rand"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultDatastoreName is the name of the datastore configured through DatastoreConnection and Datastore
+const DefaultDatastoreName = "default"
+
 type store struct {
 	writeDatabase []*gorm.DB
 	readDatabase  []*gorm.DB
 	randomSource  rand.Source
+
+	// connections holds the urls already connected, so registering one again does not open it twice
+	connections map[string]bool
+
+	// listenConfig connects to the first primary database for LISTEN, which needs a dedicated connection
+	listenConfig *pgx.ConnConfig
+
+	startupAttempts int
+	startupBackoff  time.Duration
+	lazyInit        bool
+	startupErr      error
+	namingStrategy  schema.Namer
+
+	// replicaLags caches the lag measured for each read connection
+	replicaLags sync.Map
 }
 
 func newDataStore() *store {
@@ -30,26 +53,68 @@ func newDataStore() *store {
 		randomSource:  rand.NewSource(time.Now().UnixNano()),
 		readDatabase:  []*gorm.DB{},
 		writeDatabase: []*gorm.DB{},
+		connections:   map[string]bool{},
 	}
 }
 
-// Returns a random item from the slice, or an error if the slice is empty
-func (s *Service) getRandomDatastoreConnection(readOnly bool) *gorm.DB {
+// Returns a random item from the slice, or nil if the slice is empty
+func (st *store) getRandomConnection(readOnly bool) *gorm.DB {
 
 	var connectionPool []*gorm.DB
 	if readOnly {
-		connectionPool = s.dataStore.readDatabase
+		connectionPool = st.readDatabase
 	} else {
-		connectionPool = s.dataStore.writeDatabase
+		connectionPool = st.writeDatabase
 	}
 
 	if len(connectionPool) == 0 {
 		return nil
 	}
-	randomIndex := rand.New(s.dataStore.randomSource).Intn(len(connectionPool))
+	randomIndex := rand.New(st.randomSource).Intn(len(connectionPool))
 	return connectionPool[randomIndex]
 }
 
+// namedDataStore obtains the datastore registered under name, creating it when asked to
+func (s *Service) namedDataStore(name string, create bool) *store {
+
+	if name == "" || name == DefaultDatastoreName {
+		if s.dataStore == nil && create {
+			s.dataStore = newDataStore()
+		}
+		return s.dataStore
+	}
+
+	st, ok := s.namedDataStores[name]
+	if !ok && create {
+		if s.namedDataStores == nil {
+			s.namedDataStores = map[string]*store{}
+		}
+		st = newDataStore()
+		s.namedDataStores[name] = st
+	}
+	return st
+}
+
+// datastoreSettings obtains the default datastore to configure through option.
+// Settings only apply to connections opened after them, so supplying one once a connection is open
+// records an error that Run returns instead of silently ignoring it.
+func (s *Service) datastoreSettings(option string) *store {
+
+	settings := s.namedDataStore(DefaultDatastoreName, true)
+
+	opened := len(settings.connections) > 0
+	for _, st := range s.namedDataStores {
+		opened = opened || len(st.connections) > 0
+	}
+
+	if opened {
+		err := NewError(ErrorCodeInvalidArgument,
+			fmt.Sprintf("%s has to be supplied before the datastore connections it applies to", option))
+		settings.startupErr = errors.Join(settings.startupErr, err)
+	}
+	return settings
+}
+
 func tenantPartition(ctx context.Context) func(db *gorm.DB) *gorm.DB {
 	return func(db *gorm.DB) *gorm.DB {
 
@@ -86,11 +151,22 @@ func DBPropertiesToMap(props datatypes.JSONMap) map[string]string {
 
 		case bool:
 			payload[k] = strconv.FormatBool(v)
-		case int, int64, int32, int16, int8:
-			payload[k] = strconv.FormatInt(int64(val.(int)), 10)
-
-		case float32, float64:
-			payload[k] = strconv.FormatFloat(val.(float64), 'g', -1, 64)
+		case json.Number:
+			payload[k] = v.String()
+		case int:
+			payload[k] = strconv.FormatInt(int64(v), 10)
+		case int64:
+			payload[k] = strconv.FormatInt(v, 10)
+		case int32:
+			payload[k] = strconv.FormatInt(int64(v), 10)
+		case int16:
+			payload[k] = strconv.FormatInt(int64(v), 10)
+		case int8:
+			payload[k] = strconv.FormatInt(int64(v), 10)
+		case float32:
+			payload[k] = strconv.FormatFloat(float64(v), 'g', -1, 32)
+		case float64:
+			payload[k] = strconv.FormatFloat(v, 'g', -1, 64)
 		default:
 
 			marVal, err1 := json.Marshal(val)
@@ -105,7 +181,9 @@ func DBPropertiesToMap(props datatypes.JSONMap) map[string]string {
 	return payload
 }
 
-// DBPropertiesFromMap converts a map into a JSONMap object
+// DBPropertiesFromMap converts a map into a JSONMap object.
+// Numbers within json content are kept as json.Number so large integers are not rounded through float64,
+// use JSONMapInt64 or JSONMapFloat64 to read them.
 func DBPropertiesFromMap(propsMap map[string]string) datatypes.JSONMap {
 	jsonMap := make(datatypes.JSONMap)
 
@@ -123,7 +201,7 @@ func DBPropertiesFromMap(propsMap map[string]string) datatypes.JSONMap {
 
 		var prop any
 		// Determine if the JSON is an object or an array and unmarshal accordingly
-		if err := json.Unmarshal([]byte(val), &prop); err != nil {
+		if err := unmarshalUseNumber([]byte(val), &prop); err != nil {
 			continue
 		}
 
@@ -133,24 +211,169 @@ func DBPropertiesFromMap(propsMap map[string]string) datatypes.JSONMap {
 	return jsonMap
 }
 
+// DBPropertiesToStruct converts the supplied db json content into a protobuf struct for use in grpc messages.
+// Values are converted through their json form so any json serializable content is supported.
+func DBPropertiesToStruct(props datatypes.JSONMap) (*structpb.Struct, error) {
+
+	result := &structpb.Struct{Fields: map[string]*structpb.Value{}}
+	if props == nil {
+		return result, nil
+	}
+
+	payload, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+
+	err = protojson.Unmarshal(payload, result)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DBPropertiesFromStruct converts a protobuf struct into a JSONMap object.
+// Protobuf represents every number as a float, whole numbers are restored as int64
+// so integer content survives a round trip unchanged.
+func DBPropertiesFromStruct(props *structpb.Struct) datatypes.JSONMap {
+	jsonMap := make(datatypes.JSONMap)
+
+	for k, val := range props.GetFields() {
+		jsonMap[k] = structValueToJSON(val)
+	}
+
+	return jsonMap
+}
+
+// maxExactFloatInt is the largest integer a float64 represents exactly
+const maxExactFloatInt = 1 << 53
+
+func structValueToJSON(val *structpb.Value) any {
+	switch v := val.GetKind().(type) {
+	case *structpb.Value_NumberValue:
+		number := v.NumberValue
+		if number == math.Trunc(number) && math.Abs(number) <= maxExactFloatInt {
+			return int64(number)
+		}
+		return number
+	case *structpb.Value_StructValue:
+		nested := make(map[string]any, len(v.StructValue.GetFields()))
+		for k, item := range v.StructValue.GetFields() {
+			nested[k] = structValueToJSON(item)
+		}
+		return nested
+	case *structpb.Value_ListValue:
+		items := make([]any, 0, len(v.ListValue.GetValues()))
+		for _, item := range v.ListValue.GetValues() {
+			items = append(items, structValueToJSON(item))
+		}
+		return items
+	default:
+		return val.AsInterface()
+	}
+}
+
+// JSONMapInt64 reads the supplied key of a JSONMap as an int64.
+// Values decoded from the database are json.Number, these and the go integer types are read without
+// going through float64 so 64-bit ids keep their precision, floats are only accepted when they are whole numbers.
+func JSONMapInt64(props datatypes.JSONMap, key string) (int64, bool) {
+
+	switch v := props[key].(type) {
+	case json.Number:
+		number, err := v.Int64()
+		return number, err == nil
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > maxExactFloatInt {
+			return 0, false
+		}
+		return int64(v), true
+	case string:
+		number, err := strconv.ParseInt(v, 10, 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// JSONMapFloat64 reads the supplied key of a JSONMap as a float64
+func JSONMapFloat64(props datatypes.JSONMap, key string) (float64, bool) {
+
+	switch v := props[key].(type) {
+	case json.Number:
+		number, err := v.Float64()
+		return number, err == nil
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case string:
+		number, err := strconv.ParseFloat(v, 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // DBErrorIsRecordNotFound validate if supplied error is because of record missing in DB
 func DBErrorIsRecordNotFound(err error) bool {
 	return errors.Is(err, gorm.ErrRecordNotFound)
 }
 
+// WithReadTransaction runs fn inside a read only repeatable read transaction on a read connection,
+// so every statement issued through the supplied transaction sees the same consistent snapshot.
+func (s *Service) WithReadTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	db := s.DB(ctx, true)
+	if db == nil {
+		return errors.New("no datastore is setup to run a read transaction")
+	}
+
+	return db.Transaction(fn, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+}
+
 // DB obtains an already instantiated db connection with the option
 // to specify if you want write or read only db connection
 func (s *Service) DB(ctx context.Context, readOnly bool) *gorm.DB {
+	return s.NamedDB(ctx, DefaultDatastoreName, readOnly)
+}
+
+// NamedDB obtains a connection to the datastore registered under name with WithNamedDatastore,
+// repositories are constructed against any named datastore by supplying its connections.
+// Read only connections come from the primary when the context is marked with WithPrimaryRead,
+// or when the replica lags more than the bound set with WithMaxReadLag.
+func (s *Service) NamedDB(ctx context.Context, name string, readOnly bool) *gorm.DB {
+	st := s.namedDataStore(name, false)
+	if st == nil {
+		s.L(ctx).WithField("datastore", name).Error("DB -- attempting to use a database that is not setup")
+		return nil
+	}
+
 	var db *gorm.DB
 
-	if readOnly {
-		db = s.getRandomDatastoreConnection(true)
+	if readOnly && !IsPrimaryRead(ctx) {
+		db = st.getRandomConnection(true)
+		if db != nil && !s.withinReadLag(ctx, st, db) {
+			db = nil
+		}
 	}
 
 	if db == nil {
-		db = s.getRandomDatastoreConnection(false)
+		db = st.getRandomConnection(false)
 		if db == nil {
-			s.L(ctx).Error("DB -- attempting to use a database when none is setup")
+			s.L(ctx).WithField("datastore", name).Error("DB -- attempting to use a database when none is setup")
 			return nil
 		}
 	}
@@ -165,13 +388,106 @@ func (s *Service) DB(ctx context.Context, readOnly bool) *gorm.DB {
 	return partitionedDb
 }
 
+// WithDatastoreStartupRetry retries the initial connection to each datastore up to attempts times,
+// waiting backoff between tries. When the datastore stays unreachable the service fails to Run.
+// It has to be supplied before the DatastoreConnection options it applies to, otherwise Run returns an error.
+func WithDatastoreStartupRetry(attempts int, backoff time.Duration) Option {
+	return func(s *Service) {
+		settings := s.datastoreSettings("WithDatastoreStartupRetry")
+		settings.startupAttempts = attempts
+		settings.startupBackoff = backoff
+	}
+}
+
+// WithDatastoreLazyInit defers connecting to datastores until they are first used.
+// The datastore health check reports the service as not ready until a connection succeeds.
+// It has to be supplied before the DatastoreConnection options it applies to, otherwise Run returns an error.
+func WithDatastoreLazyInit() Option {
+	return func(s *Service) {
+		settings := s.datastoreSettings("WithDatastoreLazyInit")
+		settings.lazyInit = true
+	}
+}
+
+// WithTablePrefix prefixes the tables and join tables models map to with prefix,
+// so models can be used against an existing schema whose tables share a prefix.
+// It has to be supplied before the DatastoreConnection options it applies to, otherwise Run returns an error.
+func WithTablePrefix(prefix string) Option {
+	return func(s *Service) {
+		settings := s.datastoreSettings("WithTablePrefix")
+
+		strategy, ok := settings.namingStrategy.(schema.NamingStrategy)
+		if !ok {
+			strategy = schema.NamingStrategy{}
+		}
+		strategy.TablePrefix = prefix
+		settings.namingStrategy = strategy
+	}
+}
+
+// WithNamingStrategy replaces how models are mapped to table and column names, for example to use singular tables.
+// It has to be supplied before the DatastoreConnection options it applies to, otherwise Run returns an error.
+func WithNamingStrategy(strategy schema.Namer) Option {
+	return func(s *Service) {
+		settings := s.datastoreSettings("WithNamingStrategy")
+		settings.namingStrategy = strategy
+	}
+}
+
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// pingWithRetry pings the datastore until it responds or the attempts are exhausted
+func pingWithRetry(ctx context.Context, db pinger, attempts int, backoff time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+	}
+	return err
+}
+
+// datastoreStartupError returns the error encountered while configuring or connecting to datastores on startup
+func (s *Service) datastoreStartupError() error {
+	if s.dataStore == nil {
+		return nil
+	}
+	return s.dataStore.startupErr
+}
+
 // DatastoreConnection Option method to store a connection that will be utilized when connecting to the database
 func DatastoreConnection(ctx context.Context, postgresqlConnection string, readOnly bool) Option {
+	return WithNamedDatastore(ctx, DefaultDatastoreName, postgresqlConnection, readOnly)
+}
+
+// WithNamedDatastore Option method to store a connection to an additional datastore registered under name,
+// for example a separate analytics database. Its connections are obtained through NamedDB.
+// Supplying a connection the datastore already has is a no-op.
+// Startup retry and lazy initialisation settings of the default datastore apply to it as well.
+func WithNamedDatastore(ctx context.Context, name string, postgresqlConnection string, readOnly bool) Option {
 
 	return func(s *Service) {
-		if s.dataStore == nil {
-			s.dataStore = newDataStore()
+		settings := s.namedDataStore(DefaultDatastoreName, true)
+		st := s.namedDataStore(name, true)
+
+		connectionKey := fmt.Sprintf("%t:%s", readOnly, postgresqlConnection)
+		if st.connections[connectionKey] {
+			return
 		}
+		st.connections[connectionKey] = true
 
 		dbQueryLogger := logger.Default.LogMode(logger.Warn)
 		logConfig, ok := s.Config().(ConfigurationLogLevel)
@@ -200,27 +516,42 @@ func DatastoreConnection(ctx context.Context, postgresqlConnection string, readO
 
 		}
 
+		if !settings.lazyInit && settings.startupAttempts > 0 {
+			err = pingWithRetry(ctx, db, settings.startupAttempts, settings.startupBackoff)
+			if err != nil {
+				s.L(ctx).WithError(err).WithField("datastore", name).WithField("attempts", settings.startupAttempts).
+					Error("Datastore -- could not connect to database")
+				settings.startupErr = errors.Join(settings.startupErr, err)
+			}
+		}
+
 		gormDB, _ := gorm.Open(
 			postgres.New(postgres.Config{Conn: db, PreferSimpleProtocol: true}),
 			&gorm.Config{
+				DisableAutomaticPing:   settings.lazyInit,
 				SkipDefaultTransaction: skipDefaultTx,
 				NowFunc: func() time.Time {
 					utc, _ := time.LoadLocation("")
 					return time.Now().In(utc)
 				},
-				Logger: dbQueryLogger,
+				Logger:         dbQueryLogger,
+				NamingStrategy: settings.namingStrategy,
 			},
 		)
 
 		//_ = gormDB.Use(tracing.NewPlugin())
+		_ = gormDB.Use(QueryTagPlugin{})
 
 		s.AddCleanupMethod(func(ctx context.Context) {
 			_ = db.Close()
 		})
 		if readOnly {
-			s.dataStore.readDatabase = append(s.dataStore.readDatabase, gormDB)
+			st.readDatabase = append(st.readDatabase, gormDB)
 		} else {
-			s.dataStore.writeDatabase = append(s.dataStore.writeDatabase, gormDB)
+			st.writeDatabase = append(st.writeDatabase, gormDB)
+			if st.listenConfig == nil {
+				st.listenConfig = connConfig
+			}
 		}
 
 		addSQLHealthChecker(s, db)
@@ -0,0 +1,150 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	listenBufferSize          = 64
+	listenReconnectBackoff    = time.Second
+	listenMaxReconnectBackoff = 30 * time.Second
+)
+
+// Notification is a payload sent with postgres NOTIFY on a channel being listened to
+type Notification struct {
+	Channel string
+	Payload string
+	PID     uint32
+}
+
+// Listen streams the notifications sent on channel of the default datastore, see NamedListen
+func (s *Service) Listen(ctx context.Context, channel string) (<-chan Notification, error) {
+	return s.NamedListen(ctx, DefaultDatastoreName, channel)
+}
+
+// NamedListen streams the notifications sent with postgres NOTIFY on channel of the datastore registered under name.
+// A dedicated connection to the primary database is held for listening, when it drops it is re-established
+// with backoff and notifications sent while disconnected are missed. The stream is closed once ctx is done.
+func (s *Service) NamedListen(ctx context.Context, name string, channel string) (<-chan Notification, error) {
+
+	st := s.namedDataStore(name, false)
+	if st == nil || st.listenConfig == nil {
+		return nil, fmt.Errorf("no primary datastore %s is setup to listen on", name)
+	}
+
+	conn, err := listenConnect(ctx, st.listenConfig, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(chan Notification, listenBufferSize)
+	go s.listen(ctx, st.listenConfig, channel, conn, notifications)
+	return notifications, nil
+}
+
+func listenConnect(ctx context.Context, config *pgx.ConnConfig, channel string) (*pgx.Conn, error) {
+
+	conn, err := pgx.ConnectConfig(ctx, config.Copy())
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize())
+	if err != nil {
+		_ = conn.Close(context.Background())
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (s *Service) listen(ctx context.Context, config *pgx.ConnConfig, channel string, conn *pgx.Conn, notifications chan<- Notification) {
+
+	defer close(notifications)
+	logger := s.L(ctx).WithField("channel", channel)
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err == nil {
+			select {
+			case notifications <- Notification{Channel: notification.Channel, Payload: notification.Payload, PID: notification.PID}:
+				continue
+			case <-ctx.Done():
+				_ = conn.Close(context.Background())
+				return
+			}
+		}
+
+		_ = conn.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+
+		logger.WithError(err).Warn("listen connection lost, reconnecting")
+
+		backoff := listenReconnectBackoff
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+
+			conn, err = listenConnect(ctx, config, channel)
+			if err == nil {
+				logger.Info("listen connection re-established")
+				break
+			}
+
+			logger.WithError(err).Debug("could not re-establish listen connection")
+			backoff = min(backoff*2, listenMaxReconnectBackoff)
+		}
+	}
+}
+
+// CreateChangeNotifyTrigger installs a trigger on the table of model that notifies channel of every insert, update and delete.
+// Payloads are json encoded EntityChanged events with the operation and id only, as postgres bounds payloads at 8000 bytes.
+// Soft deletes are updates to the row so they are notified as such.
+func CreateChangeNotifyTrigger(ctx context.Context, db *gorm.DB, model any, channel string) error {
+
+	stmt := &gorm.Statement{DB: db}
+	err := stmt.Parse(model)
+	if err != nil {
+		return err
+	}
+
+	return db.WithContext(ctx).Exec(changeNotifyTriggerSQL(stmt.Schema.Table, channel)).Error
+}
+
+func changeNotifyTriggerSQL(table string, channel string) string {
+
+	name := table + "_notify_" + channel
+	function := pgx.Identifier{name}.Sanitize()
+	trigger := pgx.Identifier{name + "_trigger"}.Sanitize()
+	channelLiteral := "'" + strings.ReplaceAll(channel, "'", "''") + "'"
+
+	return fmt.Sprintf(`CREATE OR REPLACE FUNCTION %[1]s() RETURNS trigger AS $$
+DECLARE
+	changed RECORD;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		changed := OLD;
+	ELSE
+		changed := NEW;
+	END IF;
+
+	PERFORM pg_notify(%[2]s, json_build_object(
+		'op', CASE TG_OP WHEN 'INSERT' THEN '%[5]s' WHEN 'UPDATE' THEN '%[6]s' ELSE '%[7]s' END,
+		'id', changed.id::text)::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS %[3]s ON %[4]s;
+CREATE TRIGGER %[3]s AFTER INSERT OR UPDATE OR DELETE ON %[4]s FOR EACH ROW EXECUTE FUNCTION %[1]s();`,
+		function, channelLiteral, trigger, pgx.Identifier{table}.Sanitize(),
+		ChangeOpCreated, ChangeOpUpdated, ChangeOpDeleted)
+}
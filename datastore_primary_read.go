@@ -0,0 +1,38 @@
+package frame
+
+import (
+	"context"
+	"net/http"
+)
+
+const ctxKeyPrimaryRead = contextKey("primaryReadKey")
+
+// WithPrimaryRead marks the context so read only connections obtained through DB or NamedDB come from the primary,
+// for reads that must observe writes not yet replicated.
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyPrimaryRead, true)
+}
+
+// IsPrimaryRead reports whether reads made with the context are directed to the primary
+func IsPrimaryRead(ctx context.Context) bool {
+	primaryRead, ok := ctx.Value(ctxKeyPrimaryRead).(bool)
+	return ok && primaryRead
+}
+
+// WithPrimaryReadRoutes Option to direct the reads of requests to routes matching any of the patterns,
+// for example "/payments/*", to the primary datastore instead of a replica. Patterns use path.Match syntax.
+func WithPrimaryReadRoutes(patterns ...string) Option {
+	return WithHTTPMiddleware(PrimaryReadRoutes(patterns...))
+}
+
+// PrimaryReadRoutes creates a middleware applying WithPrimaryRead to requests whose path matches any of the patterns
+func PrimaryReadRoutes(patterns ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if matchesAnyPath(r.URL.Path, patterns) {
+				r = r.WithContext(WithPrimaryRead(r.Context()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
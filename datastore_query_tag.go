@@ -0,0 +1,121 @@
+package frame
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"strings"
+)
+
+const queryTagClause = "FRAME_QUERY_TAG"
+
+type queryTagCtxKey struct{}
+
+// WithQueryTag returns a context whose database queries are prefixed with tag as an sql comment, for example
+// /* ListOrders */ SELECT ..., letting slow queries in the database logs be attributed to the operation issuing them.
+func WithQueryTag(ctx context.Context, tag string) context.Context {
+	return context.WithValue(ctx, queryTagCtxKey{}, tag)
+}
+
+// QueryTagFromContext obtains the query tag set on the context, if any
+func QueryTagFromContext(ctx context.Context) string {
+	tag, _ := ctx.Value(queryTagCtxKey{}).(string)
+	return tag
+}
+
+// QueryTagPlugin is a gorm plugin writing the query tag of the statement context as a leading sql comment.
+// It is installed on every datastore connection opened by the service.
+type QueryTagPlugin struct{}
+
+func (p QueryTagPlugin) Name() string {
+	return "frame:query_tag"
+}
+
+func (p QueryTagPlugin) Initialize(db *gorm.DB) error {
+
+	callbacks := db.Callback()
+
+	err := callbacks.Create().Before("gorm:create").Register("frame:query_tag", tagStatement)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Query().Before("gorm:query").Register("frame:query_tag", tagStatement)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Update().Before("gorm:update").Register("frame:query_tag", tagStatement)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Delete().Before("gorm:delete").Register("frame:query_tag", tagDeleteStatement)
+	if err != nil {
+		return err
+	}
+	err = callbacks.Row().Before("gorm:row").Register("frame:query_tag", tagStatement)
+	if err != nil {
+		return err
+	}
+	return callbacks.Raw().Before("gorm:raw").Register("frame:query_tag", tagStatement)
+}
+
+// tagDeleteStatement applies the delete clauses of the model first, soft deletes build their update
+// statement from them with a fixed set of clauses which would otherwise leave out the tag
+func tagDeleteStatement(db *gorm.DB) {
+
+	stmt := db.Statement
+	if stmt.Context == nil || QueryTagFromContext(stmt.Context) == "" {
+		return
+	}
+
+	if stmt.Schema != nil && !stmt.Unscoped {
+		for _, c := range stmt.Schema.DeleteClauses {
+			stmt.AddClause(c)
+		}
+	}
+
+	tagStatement(db)
+}
+
+// tagStatement prefixes raw sql directly while generated statements get a leading clause holding the comment
+func tagStatement(db *gorm.DB) {
+
+	stmt := db.Statement
+	if stmt.Context == nil {
+		return
+	}
+
+	tag := QueryTagFromContext(stmt.Context)
+	if tag == "" {
+		return
+	}
+
+	comment := "/* " + sanitizeQueryTag(tag) + " */"
+
+	if stmt.SQL.Len() > 0 {
+		query := stmt.SQL.String()
+		if strings.HasPrefix(query, comment) {
+			return
+		}
+		stmt.SQL.Reset()
+		stmt.SQL.WriteString(comment + " ")
+		stmt.SQL.WriteString(query)
+		return
+	}
+
+	if len(stmt.BuildClauses) > 0 && stmt.BuildClauses[0] == queryTagClause {
+		return
+	}
+
+	stmt.BuildClauses = append([]string{queryTagClause}, stmt.BuildClauses...)
+	stmt.Clauses[queryTagClause] = clause.Clause{
+		Name: queryTagClause,
+		Builder: func(_ clause.Clause, builder clause.Builder) {
+			builder.WriteString(comment)
+		},
+	}
+}
+
+// sanitizeQueryTag keeps the tag from closing the comment or introducing bind variables
+func sanitizeQueryTag(tag string) string {
+	return strings.NewReplacer("*/", "", "/*", "", "?", "", "@", "").Replace(tag)
+}
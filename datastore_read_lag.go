@@ -0,0 +1,95 @@
+package frame
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"time"
+)
+
+const (
+	ctxKeyMaxReadLag = contextKey("maxReadLagKey")
+
+	defaultReplicaLagCacheFor = time.Second
+)
+
+// WithMaxReadLag bounds how stale reads made with the context may be, read only connections whose replica lags
+// the primary by more than maxLag are swapped for the primary. Replicas whose lag can not be determined are avoided too.
+func WithMaxReadLag(ctx context.Context, maxLag time.Duration) context.Context {
+	return context.WithValue(ctx, ctxKeyMaxReadLag, maxLag)
+}
+
+func maxReadLagFromContext(ctx context.Context) (time.Duration, bool) {
+	maxLag, ok := ctx.Value(ctxKeyMaxReadLag).(time.Duration)
+	return maxLag, ok
+}
+
+// ReplicaLagProbe measures how far the replica behind db lags the primary
+type ReplicaLagProbe func(ctx context.Context, db *gorm.DB) (time.Duration, error)
+
+// PostgresReplicaLag is the default ReplicaLagProbe, using the time since the replica replayed its last transaction.
+// A replica that has replayed everything it received reports no lag, so idle databases are not mistaken for stale ones.
+func PostgresReplicaLag(ctx context.Context, db *gorm.DB) (time.Duration, error) {
+	var seconds float64
+	err := db.WithContext(ctx).Raw(`SELECT COALESCE(CASE
+		WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+		ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END, 0)`).Scan(&seconds).Error
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// WithReplicaLagProbe Option to change how replica lag is measured for WithMaxReadLag, by default PostgresReplicaLag.
+// Measurements are reused for cacheFor so bounded reads do not each query the replica.
+func WithReplicaLagProbe(probe ReplicaLagProbe, cacheFor time.Duration) Option {
+	return func(s *Service) {
+		s.replicaLagProbe = probe
+		s.replicaLagCacheFor = cacheFor
+	}
+}
+
+type replicaLagMeasurement struct {
+	lag        time.Duration
+	err        error
+	measuredAt time.Time
+}
+
+// replicaLag obtains the lag of the replica behind db, measuring it again once the cached measurement is too old
+func (s *Service) replicaLag(ctx context.Context, st *store, db *gorm.DB) (time.Duration, error) {
+
+	cacheFor := defaultReplicaLagCacheFor
+	probe := PostgresReplicaLag
+	if s.replicaLagProbe != nil {
+		probe = s.replicaLagProbe
+		cacheFor = s.replicaLagCacheFor
+	}
+
+	if cached, ok := st.replicaLags.Load(db); ok {
+		measurement := cached.(replicaLagMeasurement)
+		if time.Since(measurement.measuredAt) < cacheFor {
+			return measurement.lag, measurement.err
+		}
+	}
+
+	lag, err := probe(ctx, db)
+	st.replicaLags.Store(db, replicaLagMeasurement{lag: lag, err: err, measuredAt: time.Now()})
+	return lag, err
+}
+
+// withinReadLag reports whether reads with the context may use the replica behind db
+func (s *Service) withinReadLag(ctx context.Context, st *store, db *gorm.DB) bool {
+
+	maxLag, ok := maxReadLagFromContext(ctx)
+	if !ok {
+		return true
+	}
+
+	lag, err := s.replicaLag(ctx, st, db)
+	if err != nil {
+		s.L(ctx).WithError(err).Debug("DB -- could not measure replica lag, reading from the primary")
+		return false
+	}
+
+	return lag <= maxLag
+}
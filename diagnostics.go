@@ -0,0 +1,105 @@
+package frame
+
+import (
+	"context"
+	"sort"
+)
+
+// Diagnostics summarises the components a service wired up during startup
+type Diagnostics struct {
+	Name        string
+	Version     string
+	Environment string
+
+	HTTPAddress string
+	GRPCAddress string
+	TLS         bool
+
+	DatastoreWriteConnections int
+	DatastoreReadConnections  int
+
+	Publishers  []string
+	Subscribers []string
+	Events      []string
+
+	Tracing        bool
+	RunSecurely    bool
+	HealthCheckers int
+}
+
+// DatastoreEnabled reports whether at least one datastore connection was configured
+func (d Diagnostics) DatastoreEnabled() bool {
+	return d.DatastoreWriteConnections+d.DatastoreReadConnections > 0
+}
+
+// Diagnostics returns a snapshot of the components configured on the service
+func (s *Service) Diagnostics() Diagnostics {
+
+	d := Diagnostics{
+		Name:           s.Name(),
+		Version:        s.Version(),
+		Environment:    s.Environment(),
+		HTTPAddress:    s.httpAddress,
+		TLS:            s.TLSEnabled(),
+		Tracing:        s.traceExporter != nil,
+		HealthCheckers: len(s.healthCheckers),
+		Publishers:     []string{},
+		Subscribers:    []string{},
+		Events:         []string{},
+	}
+
+	if s.grpcServer != nil {
+		d.GRPCAddress = s.grpcPort
+	}
+
+	if s.dataStore != nil {
+		d.DatastoreWriteConnections = len(s.dataStore.writeDatabase)
+		d.DatastoreReadConnections = len(s.dataStore.readDatabase)
+	}
+
+	if s.queue != nil {
+		s.queue.publishQueueMap.Range(func(key, _ any) bool {
+			d.Publishers = append(d.Publishers, key.(string))
+			return true
+		})
+		s.queue.subscriptionQueueMap.Range(func(key, _ any) bool {
+			d.Subscribers = append(d.Subscribers, key.(string))
+			return true
+		})
+		sort.Strings(d.Publishers)
+		sort.Strings(d.Subscribers)
+	}
+
+	for name := range s.eventRegistry {
+		d.Events = append(d.Events, name)
+	}
+	sort.Strings(d.Events)
+
+	config, ok := s.Config().(ConfigurationSecurity)
+	if ok {
+		d.RunSecurely = config.IsRunSecurely()
+	}
+
+	return d
+}
+
+func (s *Service) logDiagnostics(ctx context.Context) {
+
+	d := s.Diagnostics()
+
+	s.L(ctx).
+		WithField("version", d.Version).
+		WithField("environment", d.Environment).
+		WithField("http_address", d.HTTPAddress).
+		WithField("grpc_address", d.GRPCAddress).
+		WithField("tls", d.TLS).
+		WithField("datastore_write_connections", d.DatastoreWriteConnections).
+		WithField("datastore_read_connections", d.DatastoreReadConnections).
+		WithField("publishers", d.Publishers).
+		WithField("subscribers", d.Subscribers).
+		WithField("events", d.Events).
+		WithField("tracing", d.Tracing).
+		WithField("run_securely", d.RunSecurely).
+		WithField("health_checkers", d.HealthCheckers).
+		Info("service startup summary")
+}
@@ -0,0 +1,166 @@
+package frame
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// DSN is a data source name used to connect to queues and datastores.
+// It wraps the raw url string so applications can build and validate
+// connection strings without manipulating urls by hand.
+type DSN string
+
+var (
+	ErrDSNEmpty             = NewError(ErrorCodeInvalidArgument, "dsn is empty")
+	ErrDSNUnsupportedScheme = NewError(ErrorCodeInvalidArgument, "dsn scheme is not supported")
+	ErrDSNMissingHost       = NewError(ErrorCodeInvalidArgument, "dsn host is required")
+	ErrDSNMissingDatabase   = NewError(ErrorCodeInvalidArgument, "dsn database name is required")
+	ErrDSNMissingSubject    = NewError(ErrorCodeInvalidArgument, "dsn subject is required")
+	ErrDSNMissingStreamName = NewError(ErrorCodeInvalidArgument, "dsn stream_name is required for jetstream")
+)
+
+// ParseDSN parses and validates the supplied data source name
+func ParseDSN(dsn string) (DSN, error) {
+	d := DSN(strings.TrimSpace(dsn))
+	err := d.Validate()
+	if err != nil {
+		return "", err
+	}
+	return d, nil
+}
+
+func (d DSN) String() string {
+	return string(d)
+}
+
+// ToURI parses the data source name into a url
+func (d DSN) ToURI() (*url.URL, error) {
+	if d == "" {
+		return nil, ErrDSNEmpty
+	}
+	return url.Parse(string(d))
+}
+
+func (d DSN) scheme() string {
+	u, err := d.ToURI()
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+func (d DSN) IsPostgres() bool {
+	s := d.scheme()
+	return s == "postgres" || s == "postgresql"
+}
+
+func (d DSN) IsNats() bool {
+	return d.scheme() == "nats"
+}
+
+func (d DSN) IsMem() bool {
+	return d.scheme() == "mem"
+}
+
+// IsJetStream reports whether the data source name targets a nats jetstream connection
+func (d DSN) IsJetStream() bool {
+	if !d.IsNats() {
+		return false
+	}
+	u, _ := d.ToURI()
+	return u.Query().Has("jetstream")
+}
+
+// ExtendQuery returns a copy of the data source name with the supplied query parameters set
+func (d DSN) ExtendQuery(params map[string]string) (DSN, error) {
+	u, err := d.ToURI()
+	if err != nil {
+		return "", err
+	}
+
+	query := u.Query()
+	for k, v := range params {
+		query.Set(k, v)
+	}
+	u.RawQuery = query.Encode()
+	return DSN(u.String()), nil
+}
+
+// ExtendPath returns a copy of the data source name with the supplied segments appended to its path
+func (d DSN) ExtendPath(segments ...string) (DSN, error) {
+	u, err := d.ToURI()
+	if err != nil {
+		return "", err
+	}
+
+	u.Path = path.Join(append([]string{"/", u.Path}, segments...)...)
+	return DSN(u.String()), nil
+}
+
+// WithUser returns a copy of the data source name using the supplied user while preserving any password
+func (d DSN) WithUser(user string) (DSN, error) {
+	u, err := d.ToURI()
+	if err != nil {
+		return "", err
+	}
+
+	if password, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(user, password)
+	} else {
+		u.User = url.User(user)
+	}
+	return DSN(u.String()), nil
+}
+
+// WithPassword returns a copy of the data source name using the supplied password
+func (d DSN) WithPassword(password string) (DSN, error) {
+	u, err := d.ToURI()
+	if err != nil {
+		return "", err
+	}
+
+	u.User = url.UserPassword(u.User.Username(), password)
+	return DSN(u.String()), nil
+}
+
+// Validate checks that the data source name uses a supported scheme and has the fields its driver requires
+func (d DSN) Validate() error {
+	u, err := d.ToURI()
+	if err != nil {
+		return err
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "postgres", "postgresql":
+		if u.Host == "" {
+			return ErrDSNMissingHost
+		}
+		if strings.Trim(u.Path, "/") == "" {
+			return ErrDSNMissingDatabase
+		}
+	case "nats":
+		if u.Host == "" {
+			return ErrDSNMissingHost
+		}
+		if u.Query().Get("subject") == "" && strings.Trim(u.Path, "/") == "" {
+			return ErrDSNMissingSubject
+		}
+		if u.Query().Has("jetstream") && u.Query().Get("stream_name") == "" {
+			return ErrDSNMissingStreamName
+		}
+	case "mem":
+		if u.Host == "" {
+			return ErrDSNMissingSubject
+		}
+	case "http", "https":
+		if u.Host == "" {
+			return ErrDSNMissingHost
+		}
+	default:
+		return fmt.Errorf("%w : %s", ErrDSNUnsupportedScheme, u.Scheme)
+	}
+
+	return nil
+}
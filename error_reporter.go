@@ -0,0 +1,116 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"maps"
+	"net/http"
+	"runtime/debug"
+)
+
+// ErrPanic wraps the value recovered from a panicking handler or consumer
+var ErrPanic = NewError(ErrorCodeInternal, "recovered from panic")
+
+const ctxKeyPanicFields = contextKey("panicFieldsKey")
+
+// ErrorReporter receives a crash report for every panic recovered by the service, for example to forward it
+// to an error tracker. The context carries the trace of the failed work and PanicFieldsFromContext describes it.
+type ErrorReporter interface {
+	Report(ctx context.Context, err error, stack []byte)
+}
+
+// WithErrorReporter Option to send crash reports of recovered panics to reporter in addition to the logs
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(s *Service) {
+		s.errorReporter = reporter
+	}
+}
+
+// PanicFieldsFromContext obtains the fields describing the work that panicked, such as the http method and path
+// or the queue reference, from the context handed to an ErrorReporter
+func PanicFieldsFromContext(ctx context.Context) map[string]string {
+	fields, _ := ctx.Value(ctxKeyPanicFields).(map[string]string)
+	return fields
+}
+
+// reportPanic logs a structured crash report of the recovered value and passes it to the error reporter,
+// it returns the error the failed work should complete with
+func (s *Service) reportPanic(ctx context.Context, recovered any, fields map[string]string) error {
+
+	stack := debug.Stack()
+
+	var err error
+	if recoveredErr, ok := recovered.(error); ok {
+		err = fmt.Errorf("%w : %w", ErrPanic, recoveredErr)
+	} else {
+		err = fmt.Errorf("%w : %v", ErrPanic, recovered)
+	}
+
+	fields = maps.Clone(fields)
+	if fields == nil {
+		fields = map[string]string{}
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if spanContext := span.SpanContext(); spanContext.IsValid() {
+		fields["trace_id"] = spanContext.TraceID().String()
+		fields["span_id"] = spanContext.SpanID().String()
+	}
+	span.RecordError(err, trace.WithStackTrace(true))
+	span.SetStatus(codes.Error, err.Error())
+
+	logger := s.L(ctx).WithError(err).WithField("stack", string(stack))
+	for key, value := range fields {
+		logger = logger.WithField(key, value)
+	}
+	logger.Error("recovered from panic")
+
+	if s.errorReporter != nil {
+		s.errorReporter.Report(context.WithValue(ctx, ctxKeyPanicFields, fields), err, stack)
+	}
+
+	return err
+}
+
+// recoveryMiddleware turns panics of http handlers into crash reports and internal server error responses
+func (s *Service) recoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+
+			if recovered == http.ErrAbortHandler {
+				// Aborting is the way handlers deliberately cut a response short
+				panic(recovered)
+			}
+
+			err := s.reportPanic(r.Context(), recovered, map[string]string{
+				"http.method": r.Method,
+				"http.path":   r.URL.Path,
+				"http.remote": r.RemoteAddr,
+			})
+			_ = WriteJSON(r.Context(), w, http.StatusInternalServerError, map[string]string{
+				"code":  string(ErrorCodeOf(err)),
+				"error": errorMessage(ErrPanic),
+			})
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// recoverAsError runs fn, a panic in it is reported and returned as an error wrapping ErrPanic
+func (s *Service) recoverAsError(ctx context.Context, fields map[string]string, fn func() error) (err error) {
+	defer func() {
+		recovered := recover()
+		if recovered != nil {
+			err = s.reportPanic(ctx, recovered, fields)
+		}
+	}()
+
+	return fn()
+}
@@ -0,0 +1,142 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type crashReport struct {
+	err    error
+	stack  []byte
+	fields map[string]string
+}
+
+type recordingErrorReporter struct {
+	mu      sync.Mutex
+	reports []crashReport
+	added   chan struct{}
+}
+
+func newRecordingErrorReporter() *recordingErrorReporter {
+	return &recordingErrorReporter{added: make(chan struct{}, 10)}
+}
+
+func (r *recordingErrorReporter) Report(ctx context.Context, err error, stack []byte) {
+	r.mu.Lock()
+	r.reports = append(r.reports, crashReport{err: err, stack: stack, fields: frame.PanicFieldsFromContext(ctx)})
+	r.mu.Unlock()
+
+	select {
+	case r.added <- struct{}{}:
+	default:
+	}
+}
+
+func (r *recordingErrorReporter) recorded() []crashReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]crashReport(nil), r.reports...)
+}
+
+func TestService_ErrorReporterHTTPPanic(t *testing.T) {
+
+	reporter := newRecordingErrorReporter()
+
+	handler := http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		panic("nil order total")
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.HttpHandler(handler), frame.WithErrorReporter(reporter))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	resp, err := http.Post(ts.URL+"/orders/checkout", "application/json", nil)
+	if err != nil {
+		t.Fatalf("panicking handler should still respond : %s", err)
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("status %d, expected 500", resp.StatusCode)
+	}
+
+	reports := reporter.recorded()
+	if len(reports) != 1 {
+		t.Fatalf("expected one crash report, got %d", len(reports))
+	}
+
+	report := reports[0]
+	if !errors.Is(report.err, frame.ErrPanic) || !strings.Contains(report.err.Error(), "nil order total") {
+		t.Errorf("report error %v should wrap ErrPanic and carry the panic value", report.err)
+	}
+	if !strings.Contains(string(report.stack), "error_reporter_test.go") {
+		t.Errorf("report stack does not lead to the panicking handler :\n%s", report.stack)
+	}
+
+	wantFields := map[string]string{"http.method": http.MethodPost, "http.path": "/orders/checkout"}
+	for key, value := range wantFields {
+		if report.fields[key] != value {
+			t.Errorf("report field %s is %q, expected %q", key, report.fields[key], value)
+		}
+	}
+}
+
+type panickingHandler struct{}
+
+func (h *panickingHandler) Handle(_ context.Context, _ map[string]string, _ []byte) error {
+	panic(errors.New("unexpected payload shape"))
+}
+
+func TestService_ErrorReporterQueuePanic(t *testing.T) {
+
+	reporter := newRecordingErrorReporter()
+	queueURL := "mem://topic-panicking-consumer"
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.WithErrorReporter(reporter),
+		frame.RegisterPublisher("panics", queueURL),
+		frame.RegisterSubscriber("panics", queueURL, 1, &panickingHandler{}))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	err = srv.Publish(ctx, "panics", []byte("{}"))
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	select {
+	case <-reporter.added:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("panicking consumer was not reported")
+	}
+
+	report := reporter.recorded()[0]
+	if !errors.Is(report.err, frame.ErrPanic) {
+		t.Errorf("report error %v should wrap ErrPanic", report.err)
+	}
+	if report.fields["queue.reference"] != "panics" {
+		t.Errorf("report fields %v should name the queue", report.fields)
+	}
+	if len(report.stack) == 0 {
+		t.Errorf("report should carry the stack")
+	}
+}
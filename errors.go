@@ -0,0 +1,173 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"net/http"
+)
+
+// ErrorCode is a stable classification of errors returned across the framework
+type ErrorCode string
+
+const (
+	ErrorCodeInternal         ErrorCode = "internal"
+	ErrorCodeInvalidArgument  ErrorCode = "invalid_argument"
+	ErrorCodeNotFound         ErrorCode = "not_found"
+	ErrorCodeConflict         ErrorCode = "conflict"
+	ErrorCodeUnauthenticated  ErrorCode = "unauthenticated"
+	ErrorCodePermissionDenied ErrorCode = "permission_denied"
+	ErrorCodeTooLarge         ErrorCode = "too_large"
+	ErrorCodeUnsupportedMedia ErrorCode = "unsupported_media"
+	ErrorCodeRateLimited      ErrorCode = "rate_limited"
+	ErrorCodeUnavailable      ErrorCode = "unavailable"
+	ErrorCodeDeadlineExceeded ErrorCode = "deadline_exceeded"
+	ErrorCodeCanceled         ErrorCode = "canceled"
+)
+
+var errorCodeHTTPStatus = map[ErrorCode]int{
+	ErrorCodeInternal:         http.StatusInternalServerError,
+	ErrorCodeInvalidArgument:  http.StatusBadRequest,
+	ErrorCodeNotFound:         http.StatusNotFound,
+	ErrorCodeConflict:         http.StatusConflict,
+	ErrorCodeUnauthenticated:  http.StatusUnauthorized,
+	ErrorCodePermissionDenied: http.StatusForbidden,
+	ErrorCodeTooLarge:         http.StatusRequestEntityTooLarge,
+	ErrorCodeUnsupportedMedia: http.StatusUnsupportedMediaType,
+	ErrorCodeRateLimited:      http.StatusTooManyRequests,
+	ErrorCodeUnavailable:      http.StatusServiceUnavailable,
+	ErrorCodeDeadlineExceeded: http.StatusGatewayTimeout,
+	ErrorCodeCanceled:         499,
+}
+
+var errorCodeGRPCCode = map[ErrorCode]codes.Code{
+	ErrorCodeInternal:         codes.Internal,
+	ErrorCodeInvalidArgument:  codes.InvalidArgument,
+	ErrorCodeNotFound:         codes.NotFound,
+	ErrorCodeConflict:         codes.Aborted,
+	ErrorCodeUnauthenticated:  codes.Unauthenticated,
+	ErrorCodePermissionDenied: codes.PermissionDenied,
+	ErrorCodeTooLarge:         codes.ResourceExhausted,
+	ErrorCodeUnsupportedMedia: codes.InvalidArgument,
+	ErrorCodeRateLimited:      codes.ResourceExhausted,
+	ErrorCodeUnavailable:      codes.Unavailable,
+	ErrorCodeDeadlineExceeded: codes.DeadlineExceeded,
+	ErrorCodeCanceled:         codes.Canceled,
+}
+
+// HTTPStatus is the http status code errors with this code are reported with
+func (c ErrorCode) HTTPStatus() int {
+	if httpStatus, ok := errorCodeHTTPStatus[c]; ok {
+		return httpStatus
+	}
+	return http.StatusInternalServerError
+}
+
+// GRPCCode is the grpc status code errors with this code are reported with
+func (c ErrorCode) GRPCCode() codes.Code {
+	if grpcCode, ok := errorCodeGRPCCode[c]; ok {
+		return grpcCode
+	}
+	return codes.Internal
+}
+
+// Error is a classified error carrying a stable code, a message safe to return to clients and an optional cause
+type Error struct {
+	Code    ErrorCode
+	Message string
+	Cause   error
+}
+
+// NewError creates a classified error
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// WrapError creates a classified error around the supplied cause
+func WrapError(code ErrorCode, cause error, message string) *Error {
+	return &Error{Code: code, Message: message, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s : %s", e.Message, e.Cause)
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+func (e *Error) HTTPStatus() int {
+	return e.Code.HTTPStatus()
+}
+
+func (e *Error) GRPCCode() codes.Code {
+	return e.Code.GRPCCode()
+}
+
+// ErrorCodeOf classifies any error, errors not carrying a code are reported as internal
+func ErrorCodeOf(err error) ErrorCode {
+	var frameErr *Error
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &frameErr):
+		return frameErr.Code
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrorCodeNotFound
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorCodeDeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		return ErrorCodeCanceled
+	default:
+		return ErrorCodeInternal
+	}
+}
+
+// errorMessage obtains the client safe message of an error, internal details are never exposed
+func errorMessage(err error) string {
+	var frameErr *Error
+	if errors.As(err, &frameErr) {
+		return frameErr.Message
+	}
+
+	code := ErrorCodeOf(err)
+	if text := http.StatusText(code.HTTPStatus()); text != "" {
+		return text
+	}
+	return string(code)
+}
+
+// ToGRPCError converts an error into a grpc status error using its code
+func ToGRPCError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	return status.Error(ErrorCodeOf(err).GRPCCode(), errorMessage(err))
+}
+
+// UnaryErrorInterceptor converts errors returned by unary handlers into grpc status errors using their codes
+func UnaryErrorInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		return resp, ToGRPCError(err)
+	}
+}
+
+// StreamErrorInterceptor converts errors returned by stream handlers into grpc status errors using their codes
+func StreamErrorInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return ToGRPCError(handler(srv, ss))
+	}
+}
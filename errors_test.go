@@ -0,0 +1,144 @@
+package frame_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestErrorCodeMapping(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		err        error
+		wantCode   frame.ErrorCode
+		wantStatus int
+		wantGRPC   codes.Code
+	}{
+		{
+			name:       "Authorization service down",
+			err:        fmt.Errorf("%w : %w", frame.ErrAuthorizationServiceDown, errors.New("connection refused")),
+			wantCode:   frame.ErrorCodeUnavailable,
+			wantStatus: http.StatusServiceUnavailable,
+			wantGRPC:   codes.Unavailable,
+		},
+		{
+			name:       "Invalid update",
+			err:        fmt.Errorf("name is not a valid column : %w", frame.ErrInvalidUpdate),
+			wantCode:   frame.ErrorCodeInvalidArgument,
+			wantStatus: http.StatusBadRequest,
+			wantGRPC:   codes.InvalidArgument,
+		},
+		{
+			name:       "Upload too large",
+			err:        frame.ErrUploadTooLarge,
+			wantCode:   frame.ErrorCodeTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantGRPC:   codes.ResourceExhausted,
+		},
+		{
+			name:       "Unsupported media type",
+			err:        frame.ErrUnsupportedMediaType,
+			wantCode:   frame.ErrorCodeUnsupportedMedia,
+			wantStatus: http.StatusUnsupportedMediaType,
+			wantGRPC:   codes.InvalidArgument,
+		},
+		{
+			name:       "Record not found",
+			err:        gorm.ErrRecordNotFound,
+			wantCode:   frame.ErrorCodeNotFound,
+			wantStatus: http.StatusNotFound,
+			wantGRPC:   codes.NotFound,
+		},
+		{
+			name:       "Deadline exceeded",
+			err:        context.DeadlineExceeded,
+			wantCode:   frame.ErrorCodeDeadlineExceeded,
+			wantStatus: http.StatusGatewayTimeout,
+			wantGRPC:   codes.DeadlineExceeded,
+		},
+		{
+			name:       "Wrapped cause",
+			err:        frame.WrapError(frame.ErrorCodeConflict, errors.New("duplicate key"), "order already exists"),
+			wantCode:   frame.ErrorCodeConflict,
+			wantStatus: http.StatusConflict,
+			wantGRPC:   codes.Aborted,
+		},
+		{
+			name:       "Unclassified",
+			err:        errors.New("boom"),
+			wantCode:   frame.ErrorCodeInternal,
+			wantStatus: http.StatusInternalServerError,
+			wantGRPC:   codes.Internal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := frame.ErrorCodeOf(tt.err)
+			if code != tt.wantCode {
+				t.Errorf("ErrorCodeOf() = %s, want %s", code, tt.wantCode)
+			}
+
+			if code.HTTPStatus() != tt.wantStatus {
+				t.Errorf("HTTPStatus() = %d, want %d", code.HTTPStatus(), tt.wantStatus)
+			}
+
+			grpcErr := frame.ToGRPCError(tt.err)
+			if status.Code(grpcErr) != tt.wantGRPC {
+				t.Errorf("ToGRPCError() code = %s, want %s", status.Code(grpcErr), tt.wantGRPC)
+			}
+		})
+	}
+}
+
+func TestWriteError(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantMessage string
+	}{
+		{
+			name:        "Classified error",
+			err:         frame.WrapError(frame.ErrorCodeNotFound, errors.New("select failed"), "order not found"),
+			wantStatus:  http.StatusNotFound,
+			wantMessage: "order not found",
+		},
+		{
+			name:        "Internal details are hidden",
+			err:         errors.New("pq: password authentication failed"),
+			wantStatus:  http.StatusInternalServerError,
+			wantMessage: http.StatusText(http.StatusInternalServerError),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			_ = frame.WriteError(ctx, rr, tt.err)
+
+			if rr.Code != tt.wantStatus {
+				t.Errorf("WriteError() status = %d, want %d", rr.Code, tt.wantStatus)
+			}
+
+			var body map[string]string
+			_ = json.Unmarshal(rr.Body.Bytes(), &body)
+			if body["error"] != tt.wantMessage || body["code"] != string(frame.ErrorCodeOf(tt.err)) {
+				t.Errorf("WriteError() body = %v", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package frame
+
+import (
+	"context"
+	"strings"
+)
+
+// FeatureFlags is used to determine if a feature should be active for the supplied context.
+// The context is available to allow targeting of flags to specific tenants, partitions or users.
+// Remote flag providers can be plugged in by implementing this interface and supplying it via WithFeatureFlags.
+type FeatureFlags interface {
+	Enabled(ctx context.Context, flag string) bool
+}
+
+// WithFeatureFlags Option to specify the feature flag provider used by the service
+func WithFeatureFlags(featureFlags FeatureFlags) Option {
+	return func(s *Service) {
+		s.featureFlags = featureFlags
+	}
+}
+
+// FeatureFlags obtains the feature flag provider of the service.
+// If none was supplied the flags defined in the service configuration are used, parsed on first use.
+func (s *Service) FeatureFlags() FeatureFlags {
+	if s.featureFlags != nil {
+		return s.featureFlags
+	}
+
+	s.configFeatureFlagsOnce.Do(func() {
+		config, ok := s.Config().(ConfigurationFeatureFlags)
+		if !ok {
+			s.configFeatureFlags = NewConfigFeatureFlags()
+			return
+		}
+
+		s.configFeatureFlags = NewConfigFeatureFlags(config.GetFeatureFlags()...)
+	})
+	return s.configFeatureFlags
+}
+
+type configFeatureFlags struct {
+	flags map[string][]string
+}
+
+// NewConfigFeatureFlags creates a statically defined feature flag provider.
+// Each definition is either a flag name, enabling it for everyone, or is of the form
+// flag=target1|target2 enabling the flag only when the tenant, partition or subject
+// of the authenticated claims in the context matches one of the targets.
+func NewConfigFeatureFlags(definitions ...string) FeatureFlags {
+
+	flags := make(map[string][]string)
+
+	for _, definition := range definitions {
+		definition = strings.TrimSpace(definition)
+		if definition == "" {
+			continue
+		}
+
+		flag, targets, isTargeted := strings.Cut(definition, "=")
+		flag = strings.TrimSpace(flag)
+
+		if !isTargeted {
+			flags[flag] = nil
+			continue
+		}
+
+		for _, target := range strings.Split(targets, "|") {
+			target = strings.TrimSpace(target)
+			if target != "" {
+				flags[flag] = append(flags[flag], target)
+			}
+		}
+	}
+
+	return &configFeatureFlags{flags: flags}
+}
+
+func (cf *configFeatureFlags) Enabled(ctx context.Context, flag string) bool {
+
+	targets, ok := cf.flags[flag]
+	if !ok {
+		return false
+	}
+
+	if targets == nil {
+		return true
+	}
+
+	claims := ClaimsFromContext(ctx)
+	if claims == nil {
+		return false
+	}
+
+	for _, target := range targets {
+		if target == claims.GetTenantId() ||
+			target == claims.GetPartitionId() ||
+			target == claims.Subject {
+			return true
+		}
+	}
+
+	return false
+}
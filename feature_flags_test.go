@@ -0,0 +1,156 @@
+package frame_test
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFeatureFlags_Static(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.Config(&frame.ConfigurationDefault{
+		FeatureFlags: []string{"new_ui", " dark_mode "},
+	}))
+
+	if !srv.FeatureFlags().Enabled(ctx, "new_ui") {
+		t.Errorf("statically configured flag new_ui should be enabled")
+	}
+
+	if !srv.FeatureFlags().Enabled(ctx, "dark_mode") {
+		t.Errorf("statically configured flag dark_mode should be enabled")
+	}
+
+	if srv.FeatureFlags().Enabled(ctx, "unknown") {
+		t.Errorf("flags that are not configured should not be enabled")
+	}
+
+	if srv.FeatureFlags() != srv.FeatureFlags() {
+		t.Errorf("configured flags should be parsed once and reused")
+	}
+}
+
+func TestFeatureFlags_ContextTargeting(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.Config(&frame.ConfigurationDefault{
+		FeatureFlags: []string{"beta_search=tenantA|profileB"},
+	}))
+
+	if srv.FeatureFlags().Enabled(ctx, "beta_search") {
+		t.Errorf("targeted flag should not be enabled without claims in context")
+	}
+
+	tests := []struct {
+		name     string
+		tenantID string
+		subject  string
+		enabled  bool
+	}{
+		{name: "Targeted tenant", tenantID: "tenantA", subject: "profileZ", enabled: true},
+		{name: "Targeted subject", tenantID: "tenantZ", subject: "profileB", enabled: true},
+		{name: "Not targeted", tenantID: "tenantZ", subject: "profileZ", enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := frame.AuthenticationClaims{TenantID: tt.tenantID}
+			claims.Subject = tt.subject
+
+			claimsCtx := claims.ClaimsToContext(ctx)
+
+			if srv.FeatureFlags().Enabled(claimsCtx, "beta_search") != tt.enabled {
+				t.Errorf("flag enabled state should be %v", tt.enabled)
+			}
+		})
+	}
+}
+
+type testFeatureFlags struct {
+	flags map[string]bool
+}
+
+func (tf *testFeatureFlags) Enabled(_ context.Context, flag string) bool {
+	return tf.flags[flag]
+}
+
+func TestFeatureFlags_CustomProvider(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.Config(&frame.ConfigurationDefault{FeatureFlags: []string{"new_ui"}}),
+		frame.WithFeatureFlags(&testFeatureFlags{flags: map[string]bool{"remote_flag": true}}))
+
+	if !srv.FeatureFlags().Enabled(ctx, "remote_flag") {
+		t.Errorf("flag from the supplied provider should be enabled")
+	}
+
+	if srv.FeatureFlags().Enabled(ctx, "new_ui") {
+		t.Errorf("supplied provider should take precedence over configured flags")
+	}
+}
+
+func TestService_FeatureGatedRoute(t *testing.T) {
+
+	// tenantFromHeader stands in for the authentication middleware placing claims in the request context
+	tenantFromHeader := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenantID := r.Header.Get("X-Tenant"); tenantID != "" {
+				claims := frame.AuthenticationClaims{TenantID: tenantID}
+				r = r.WithContext(claims.ClaimsToContext(r.Context()))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.Config(&frame.ConfigurationDefault{FeatureFlags: []string{"payments_v2=tenantA"}}),
+		frame.HttpHandler(handler),
+		frame.WithHTTPMiddleware(tenantFromHeader),
+		frame.WithFeatureGatedRoute("/v2/payments/*", "payments_v2"),
+		frame.WithFeatureGatedRouteStatus("/v2/refunds/*", "refunds_v2", http.StatusForbidden))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	tests := []struct {
+		name   string
+		path   string
+		tenant string
+		status int
+	}{
+		{name: "Enabled for tenant", path: "/v2/payments/1", tenant: "tenantA", status: http.StatusOK},
+		{name: "Hidden from other tenants", path: "/v2/payments/1", tenant: "tenantB", status: http.StatusNotFound},
+		{name: "Hidden without claims", path: "/v2/payments/1", status: http.StatusNotFound},
+		{name: "Configured status", path: "/v2/refunds/1", tenant: "tenantA", status: http.StatusForbidden},
+		{name: "Ungated route", path: "/v1/payments/1", tenant: "tenantB", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, ts.URL+tt.path, nil)
+			if tt.tenant != "" {
+				req.Header.Set("X-Tenant", tt.tenant)
+			}
+
+			resp, err0 := http.DefaultClient.Do(req)
+			if err0 != nil {
+				t.Fatalf("could not call %s : %s", tt.path, err0)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.status {
+				t.Errorf("status %d, expected %d", resp.StatusCode, tt.status)
+			}
+		})
+	}
+}
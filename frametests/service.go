@@ -0,0 +1,35 @@
+// Package frametests provides helpers that reduce the boilerplate of testing frame services.
+package frametests
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewTestService creates and runs an in process frame service for the duration of a test.
+// The service uses the noop driver and its handler is served by a test http server whose
+// base url is returned. Both are stopped automatically when the test completes.
+func NewTestService(t testing.TB, opts ...frame.Option) (context.Context, *frame.Service, string) {
+	t.Helper()
+
+	opts = append([]frame.Option{frame.NoopDriver()}, opts...)
+
+	ctx, srv := frame.NewService(t.Name(), opts...)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		srv.Stop(ctx)
+		t.Fatalf("could not run test service : %s", err)
+	}
+
+	ts := httptest.NewServer(srv.H())
+
+	t.Cleanup(func() {
+		ts.Close()
+		srv.Stop(ctx)
+	})
+
+	return ctx, srv, ts.URL
+}
@@ -0,0 +1,46 @@
+package frametests_test
+
+import (
+	"fmt"
+	"github.com/pitabwire/frame"
+	"github.com/pitabwire/frame/frametests"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestNewTestService(t *testing.T) {
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/greet", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from frame")
+	})
+
+	var srv *frame.Service
+
+	t.Run("serves registered handler", func(t *testing.T) {
+
+		var baseURL string
+		_, srv, baseURL = frametests.NewTestService(t, frame.HttpHandler(mux))
+
+		resp, err := http.Get(fmt.Sprintf("%s/greet", baseURL))
+		if err != nil {
+			t.Errorf("could not invoke test service : %s", err)
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || string(body) != "hello from frame" {
+			t.Errorf("unexpected response %d : %s", resp.StatusCode, body)
+		}
+
+		if srv.IsStopping() {
+			t.Errorf("test service should not be stopped while the test is running")
+		}
+	})
+
+	if srv == nil || !srv.IsStopping() {
+		t.Errorf("test service should be stopped once the test completes")
+	}
+}
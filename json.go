@@ -0,0 +1,379 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// JSONNamingPolicy determines how field names are presented in json payloads handled by the service.
+type JSONNamingPolicy int
+
+const (
+	// JSONNamingDefault leaves field names as declared on the go structs or in their json tags.
+	JSONNamingDefault JSONNamingPolicy = iota
+	// JSONNamingSnakeCase presents field names in snake_case e.g. tenant_id.
+	JSONNamingSnakeCase
+	// JSONNamingCamelCase presents field names in camelCase e.g. tenantId.
+	JSONNamingCamelCase
+)
+
+// JSONCodec encodes and decodes json payloads for api requests and responses.
+type JSONCodec interface {
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// WithJSONNamingPolicy Option to specify the naming policy applied to json keys of api payloads.
+// This removes the need to tag every struct exposed over the api.
+func WithJSONNamingPolicy(policy JSONNamingPolicy) Option {
+	return func(s *Service) {
+		s.jsonNamingPolicy = policy
+	}
+}
+
+// JSONCodec obtains the codec used by the service to encode and decode api payloads.
+func (s *Service) JSONCodec() JSONCodec {
+	return &namingJSONCodec{policy: s.jsonNamingPolicy}
+}
+
+// jsonCodecFromContext obtains the codec of the service in the context or a default one if none exists.
+func jsonCodecFromContext(ctx context.Context) JSONCodec {
+	service := FromContext(ctx)
+	if service == nil {
+		return &namingJSONCodec{}
+	}
+	return service.JSONCodec()
+}
+
+// BindJSON decodes the json body of the supplied request into v using the codec of the service in the request context.
+func BindJSON(r *http.Request, v any) error {
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+
+	return jsonCodecFromContext(r.Context()).Unmarshal(body, v)
+}
+
+type namingJSONCodec struct {
+	policy JSONNamingPolicy
+}
+
+func (nc *namingJSONCodec) Marshal(v any) ([]byte, error) {
+
+	data, err := json.Marshal(v)
+	if err != nil || nc.policy == JSONNamingDefault {
+		return data, err
+	}
+
+	var generic any
+	err = unmarshalUseNumber(data, &generic)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(nc.renameFields(reflect.ValueOf(v), generic))
+}
+
+func (nc *namingJSONCodec) Unmarshal(data []byte, v any) error {
+
+	if nc.policy == JSONNamingDefault {
+		return json.Unmarshal(data, v)
+	}
+
+	var generic any
+	err := unmarshalUseNumber(data, &generic)
+	if err != nil {
+		return err
+	}
+
+	data, err = json.Marshal(nc.restoreFields(reflect.TypeOf(v), generic))
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// rename presents a json key of a struct field following the naming policy
+func (nc *namingJSONCodec) rename(key string) string {
+	switch nc.policy {
+	case JSONNamingSnakeCase:
+		return toSnakeCase(key)
+	case JSONNamingCamelCase:
+		return toCamelCase(key)
+	default:
+		return key
+	}
+}
+
+// jsonKeyFromContext presents a json key of a struct field following the naming policy of the service in the context
+func jsonKeyFromContext(ctx context.Context, key string) string {
+	service := FromContext(ctx)
+	if service == nil {
+		return key
+	}
+	return (&namingJSONCodec{policy: service.jsonNamingPolicy}).rename(key)
+}
+
+func unmarshalUseNumber(data []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+var (
+	jsonMarshalerType   = reflect.TypeFor[json.Marshaler]()
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	jsonFieldsCache     sync.Map
+)
+
+// jsonField is a struct field as encoding/json presents it, fields of embedded structs are promoted
+type jsonField struct {
+	name  string
+	key   string
+	index []int
+}
+
+// jsonFieldsOf lists the fields encoding/json reads and writes for the struct type t
+func jsonFieldsOf(t reflect.Type) []jsonField {
+
+	if cached, ok := jsonFieldsCache.Load(t); ok {
+		return cached.([]jsonField)
+	}
+
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		key, _, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && key == "" && fieldType.Kind() == reflect.Struct {
+			for _, promoted := range jsonFieldsOf(fieldType) {
+				promoted.index = append([]int{i}, promoted.index...)
+				fields = append(fields, promoted)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if key == "" {
+			key = field.Name
+		}
+		fields = append(fields, jsonField{name: field.Name, key: key, index: []int{i}})
+	}
+
+	jsonFieldsCache.Store(t, fields)
+	return fields
+}
+
+// hasCustomJSON reports whether values of t encode themselves, their json is then left as it is
+func hasCustomJSON(t reflect.Type) bool {
+	return t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType) ||
+		t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType)
+}
+
+// renameFields renames the keys of the struct fields within generic, the decoded json of v, following the policy.
+// Keys of maps are data rather than field names and are kept as they are.
+func (nc *namingJSONCodec) renameFields(v reflect.Value, generic any) any {
+
+	if !v.IsValid() || generic == nil || hasCustomJSON(v.Type()) {
+		return generic
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return generic
+		}
+		return nc.renameFields(v.Elem(), generic)
+
+	case reflect.Struct:
+		object, ok := generic.(map[string]any)
+		if !ok {
+			return generic
+		}
+
+		renamed := make(map[string]any, len(object))
+		for _, field := range jsonFieldsOf(v.Type()) {
+			value, present := object[field.key]
+			if !present {
+				continue
+			}
+
+			fieldValue, err := v.FieldByIndexErr(field.index)
+			if err != nil {
+				renamed[nc.rename(field.key)] = value
+				continue
+			}
+			renamed[nc.rename(field.key)] = nc.renameFields(fieldValue, value)
+		}
+		return renamed
+
+	case reflect.Slice, reflect.Array:
+		list, ok := generic.([]any)
+		if !ok {
+			return generic
+		}
+		for i := 0; i < len(list) && i < v.Len(); i++ {
+			list[i] = nc.renameFields(v.Index(i), list[i])
+		}
+		return list
+
+	case reflect.Map:
+		object, ok := generic.(map[string]any)
+		if !ok || v.Type().Key().Kind() != reflect.String {
+			return generic
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if value, present := object[key]; present {
+				object[key] = nc.renameFields(iter.Value(), value)
+			}
+		}
+		return object
+
+	default:
+		return generic
+	}
+}
+
+// restoreFields renames the policy keys of the struct fields within generic back to those encoding/json expects
+// when decoding into a value of type t. Keys of maps, including those decoded into interfaces, are kept as they are.
+func (nc *namingJSONCodec) restoreFields(t reflect.Type, generic any) any {
+
+	if t == nil || generic == nil || hasCustomJSON(t) {
+		return generic
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nc.restoreFields(t.Elem(), generic)
+
+	case reflect.Struct:
+		object, ok := generic.(map[string]any)
+		if !ok {
+			return generic
+		}
+
+		fields := map[string]reflect.StructField{}
+		keys := map[string]string{}
+		for _, field := range jsonFieldsOf(t) {
+			keys[nc.rename(field.key)] = field.key
+			fields[field.key] = t.FieldByIndex(field.index)
+		}
+
+		restored := make(map[string]any, len(object))
+		for key, value := range object {
+			fieldKey, ok := keys[key]
+			if !ok {
+				restored[key] = value
+				continue
+			}
+			restored[fieldKey] = nc.restoreFields(fields[fieldKey].Type, value)
+		}
+		return restored
+
+	case reflect.Slice, reflect.Array:
+		list, ok := generic.([]any)
+		if !ok {
+			return generic
+		}
+		for i, value := range list {
+			list[i] = nc.restoreFields(t.Elem(), value)
+		}
+		return list
+
+	case reflect.Map:
+		object, ok := generic.(map[string]any)
+		if !ok {
+			return generic
+		}
+		for key, value := range object {
+			object[key] = nc.restoreFields(t.Elem(), value)
+		}
+		return object
+
+	default:
+		return generic
+	}
+}
+
+// splitNameWords breaks up a field name into its words, acronyms like ID or HTTP are kept as a single word.
+func splitNameWords(name string) []string {
+
+	var words []string
+	runes := []rune(name)
+	start := 0
+
+	for i := 0; i < len(runes); i++ {
+
+		r := runes[i]
+		if r == '_' || r == '-' || r == ' ' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+
+		if i == start || !unicode.IsUpper(r) {
+			continue
+		}
+
+		previous := runes[i-1]
+		nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+
+		if !unicode.IsUpper(previous) || nextIsLower {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+
+	if start < len(runes) {
+		words = append(words, string(runes[start:]))
+	}
+
+	return words
+}
+
+func toSnakeCase(name string) string {
+	words := splitNameWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+func toCamelCase(name string) string {
+	words := splitNameWords(name)
+	for i, word := range words {
+		wordRunes := []rune(strings.ToLower(word))
+		if i > 0 {
+			wordRunes[0] = unicode.ToUpper(wordRunes[0])
+		}
+		words[i] = string(wordRunes)
+	}
+	return strings.Join(words, "")
+}
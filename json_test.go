@@ -0,0 +1,127 @@
+package frame_test
+
+import (
+	"encoding/json"
+	"github.com/pitabwire/frame"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type jsonPolicyNested struct {
+	DisplayName string
+}
+
+type jsonPolicyPayload struct {
+	TenantID  string
+	CreatedAt string
+	HTTPPort  int
+	Nested    jsonPolicyNested
+	Items     []jsonPolicyNested
+}
+
+func TestJSONNamingPolicy(t *testing.T) {
+
+	payload := jsonPolicyPayload{
+		TenantID:  "tenant",
+		CreatedAt: "today",
+		HTTPPort:  8080,
+		Nested:    jsonPolicyNested{DisplayName: "nested"},
+		Items:     []jsonPolicyNested{{DisplayName: "item"}},
+	}
+
+	tests := []struct {
+		name   string
+		policy frame.JSONNamingPolicy
+		want   map[string]any
+	}{
+		{
+			name:   "Snake case",
+			policy: frame.JSONNamingSnakeCase,
+			want: map[string]any{
+				"tenant_id":  "tenant",
+				"created_at": "today",
+				"http_port":  float64(8080),
+				"nested":     map[string]any{"display_name": "nested"},
+				"items":      []any{map[string]any{"display_name": "item"}},
+			},
+		},
+		{
+			name:   "Camel case",
+			policy: frame.JSONNamingCamelCase,
+			want: map[string]any{
+				"tenantId":  "tenant",
+				"createdAt": "today",
+				"httpPort":  float64(8080),
+				"nested":    map[string]any{"displayName": "nested"},
+				"items":     []any{map[string]any{"displayName": "item"}},
+			},
+		},
+		{
+			name:   "Default",
+			policy: frame.JSONNamingDefault,
+			want: map[string]any{
+				"TenantID":  "tenant",
+				"CreatedAt": "today",
+				"HTTPPort":  float64(8080),
+				"Nested":    map[string]any{"DisplayName": "nested"},
+				"Items":     []any{map[string]any{"DisplayName": "item"}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			_, srv := frame.NewService("Test Srv", frame.WithJSONNamingPolicy(tt.policy))
+
+			data, err := srv.JSONCodec().Marshal(payload)
+			if err != nil {
+				t.Errorf("could not marshal payload : %s", err)
+				return
+			}
+
+			var got map[string]any
+			err = json.Unmarshal(data, &got)
+			if err != nil {
+				t.Errorf("could not read marshalled payload : %s", err)
+				return
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("marshalled payload %v is not %v", got, tt.want)
+			}
+
+			var decoded jsonPolicyPayload
+			err = srv.JSONCodec().Unmarshal(data, &decoded)
+			if err != nil {
+				t.Errorf("could not unmarshal payload : %s", err)
+				return
+			}
+
+			if !reflect.DeepEqual(decoded, payload) {
+				t.Errorf("payload did not round trip %v is not %v", decoded, payload)
+			}
+		})
+	}
+}
+
+func TestBindJSON(t *testing.T) {
+
+	ctx, _ := frame.NewService("Test Srv", frame.WithJSONNamingPolicy(frame.JSONNamingSnakeCase))
+
+	req := httptest.NewRequest("POST", "/", strings.NewReader(`{"tenant_id": "tenant", "http_port": 80}`))
+	req = req.WithContext(ctx)
+
+	var bound jsonPolicyPayload
+	err := frame.BindJSON(req, &bound)
+	if err != nil {
+		t.Errorf("could not bind request body : %s", err)
+		return
+	}
+
+	if bound.TenantID != "tenant" || bound.HTTPPort != 80 {
+		t.Errorf("request body was not bound using the naming policy : %v", bound)
+	}
+}
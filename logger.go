@@ -10,7 +10,6 @@ import (
 	"google.golang.org/grpc/status"
 	"io"
 	"os"
-	"runtime/debug"
 )
 
 // Logger Option that helps with initialization of our internal logger
@@ -119,8 +118,7 @@ func LoggingInterceptor(l logrus.FieldLogger) logging.Logger {
 func RecoveryHandlerFun(ctx context.Context, p interface{}) error {
 
 	s := FromContext(ctx)
-	logger := s.L(ctx)
-	logger.WithField("trigger", p).Errorf("recovered from panic %s", debug.Stack())
+	_ = s.reportPanic(ctx, p, map[string]string{"rpc.system": "grpc"})
 
 	// Return a gRPC error
 	return status.Errorf(codes.Internal, "Internal server error")
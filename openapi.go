@@ -0,0 +1,258 @@
+package frame
+
+import (
+	"encoding/json"
+	"gorm.io/gorm"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	openAPIVersion = "3.0.3"
+	// OpenAPIPath is where the service serves the OpenAPI document of its REST resources
+	OpenAPIPath = "/openapi.json"
+)
+
+// openAPIResource is implemented by the REST resources describing themselves in the OpenAPI document
+type openAPIResource interface {
+	openAPIPaths(schemas map[string]any) map[string]map[string]any
+}
+
+func (s *Service) addRESTResource(res openAPIResource) {
+	s.restResourcesMutex.Lock()
+	defer s.restResourcesMutex.Unlock()
+	s.restResources = append(s.restResources, res)
+}
+
+// OpenAPISpec generates an OpenAPI 3 document describing the endpoints and models of the REST resources created
+// for the service. The document is served at OpenAPIPath once a REST resource exists.
+func (s *Service) OpenAPISpec() map[string]any {
+
+	s.restResourcesMutex.Lock()
+	resources := slices.Clone(s.restResources)
+	s.restResourcesMutex.Unlock()
+
+	paths := map[string]any{}
+	schemas := map[string]any{}
+	for _, res := range resources {
+		for path, operations := range res.openAPIPaths(schemas) {
+			paths[path] = operations
+		}
+	}
+
+	version := s.version
+	if version == "" {
+		version = "0.0.0"
+	}
+
+	return map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":   s.Name(),
+			"version": version,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+}
+
+// openAPIHandler serves the OpenAPI document, services without REST resources leave the path to the application
+func (s *Service) openAPIHandler(applicationHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		s.restResourcesMutex.Lock()
+		hasResources := len(s.restResources) > 0
+		s.restResourcesMutex.Unlock()
+
+		if !hasResources || r.Method != http.MethodGet {
+			applicationHandler.ServeHTTP(w, r)
+			return
+		}
+
+		_ = WriteJSON(r.Context(), w, http.StatusOK, s.OpenAPISpec())
+	})
+}
+
+func (res *RESTResource[T, PT]) openAPIPaths(schemas map[string]any) map[string]map[string]any {
+
+	modelType := reflect.TypeFor[T]()
+	schemaName := modelType.Name()
+	schemas[schemaName] = openAPISchema(modelType)
+
+	ref := map[string]any{"$ref": "#/components/schemas/" + schemaName}
+	tag := strings.Trim(res.basePath, "/")
+
+	jsonContent := func(schema any) map[string]any {
+		return map[string]any{"application/json": map[string]any{"schema": schema}}
+	}
+	response := func(description string, schema any) map[string]any {
+		if schema == nil {
+			return map[string]any{"description": description}
+		}
+		return map[string]any{"description": description, "content": jsonContent(schema)}
+	}
+	queryParameter := func(name, schemaType, description string) map[string]any {
+		return map[string]any{"name": name, "in": "query", "description": description, "schema": map[string]any{"type": schemaType}}
+	}
+
+	idParameter := map[string]any{"name": "id", "in": "path", "required": true, "schema": map[string]any{"type": "string"}}
+	fieldsParameter := queryParameter("fields", "string", "comma separated model fields to include in the response")
+
+	itemPath := strings.TrimSuffix(res.basePath, "/") + "/{id}"
+	operations := map[string][]struct {
+		method    string
+		operation map[string]any
+	}{
+		res.basePath: {
+			{method: http.MethodGet, operation: map[string]any{
+				"summary": "List " + schemaName,
+				"parameters": []any{
+					queryParameter("limit", "integer", "maximum number of records to return"),
+					queryParameter("offset", "integer", "number of records to skip"),
+					fieldsParameter,
+				},
+				"responses": map[string]any{"200": response("records", map[string]any{"type": "array", "items": ref})},
+			}},
+			{method: http.MethodPost, operation: map[string]any{
+				"summary":     "Create " + schemaName,
+				"requestBody": map[string]any{"required": true, "content": jsonContent(ref)},
+				"responses":   map[string]any{"201": response("created record", ref), "400": response("invalid record", nil)},
+			}},
+		},
+		itemPath: {
+			{method: http.MethodGet, operation: map[string]any{
+				"summary":    "Get " + schemaName,
+				"parameters": []any{idParameter, fieldsParameter},
+				"responses":  map[string]any{"200": response("record", ref), "404": response("record not found", nil)},
+			}},
+			{method: http.MethodPatch, operation: map[string]any{
+				"summary":     "Update " + schemaName,
+				"parameters":  []any{idParameter},
+				"requestBody": map[string]any{"required": true, "content": jsonContent(map[string]any{"type": "object"})},
+				"responses":   map[string]any{"200": response("updated record", ref), "404": response("record not found", nil)},
+			}},
+			{method: http.MethodDelete, operation: map[string]any{
+				"summary":    "Delete " + schemaName,
+				"parameters": []any{idParameter},
+				"responses":  map[string]any{"204": response("record deleted", nil), "404": response("record not found", nil)},
+			}},
+		},
+	}
+
+	paths := map[string]map[string]any{}
+	for path, pathOperations := range operations {
+		for _, op := range pathOperations {
+			if _, disabled := res.options.disabledVerbs[op.method]; disabled {
+				continue
+			}
+			op.operation["tags"] = []string{tag}
+			if paths[path] == nil {
+				paths[path] = map[string]any{}
+			}
+			paths[path][strings.ToLower(op.method)] = op.operation
+		}
+	}
+	return paths
+}
+
+var (
+	timeType      = reflect.TypeFor[time.Time]()
+	deletedAtType = reflect.TypeFor[gorm.DeletedAt]()
+	marshalerType = reflect.TypeFor[json.Marshaler]()
+)
+
+// openAPISchema describes how values of t are encoded to json
+func openAPISchema(t reflect.Type) map[string]any {
+
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema map[string]any
+	switch {
+	case t == timeType:
+		schema = map[string]any{"type": "string", "format": "date-time"}
+	case t == deletedAtType:
+		schema = map[string]any{"type": "string", "format": "date-time"}
+		nullable = true
+	case t.Kind() == reflect.Struct && (t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType)):
+		// The encoding is up to the type, any value is allowed
+		schema = map[string]any{}
+	default:
+		schema = openAPIKindSchema(t)
+	}
+
+	if nullable {
+		schema["nullable"] = true
+	}
+	return schema
+}
+
+func openAPIKindSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": openAPISchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": openAPISchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		openAPIProperties(t, properties)
+		return map[string]any{"type": "object", "properties": properties}
+	default:
+		return map[string]any{}
+	}
+}
+
+// openAPIProperties adds the json encoded fields of the struct t to properties, promoting those of embedded structs
+func openAPIProperties(t reflect.Type, properties map[string]any) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			if fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				openAPIProperties(fieldType, properties)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = openAPISchema(field.Type)
+	}
+}
@@ -0,0 +1,67 @@
+package frame
+
+// Paginator tracks the progress of loading a limited number of results in batches.
+type Paginator struct {
+	offset    int
+	limit     int
+	batchSize int
+	total     int64
+}
+
+// NewPaginator creates a paginator that loads up to limit results, batchSize at a time.
+func NewPaginator(limit, batchSize int) *Paginator {
+
+	if batchSize <= 0 || batchSize > limit {
+		batchSize = limit
+	}
+
+	return &Paginator{
+		limit:     limit,
+		batchSize: batchSize,
+	}
+}
+
+// Offset is the position from which the next batch should be loaded.
+func (p *Paginator) Offset() int {
+	return p.offset
+}
+
+// BatchSize is the number of results the next batch should load.
+func (p *Paginator) BatchSize() int {
+	return p.batchSize
+}
+
+// CanLoad reports whether more results can be loaded within the limit.
+func (p *Paginator) CanLoad() bool {
+	return p.offset < p.limit
+}
+
+// Stop records the count of results loaded in the last batch and reports whether loading should stop.
+// Loading stops once a batch returns fewer results than requested or the limit is reached.
+func (p *Paginator) Stop(loadedCount int) bool {
+
+	requested := p.batchSize
+	p.offset += loadedCount
+
+	if p.offset+p.batchSize > p.limit {
+		p.batchSize = p.limit - p.offset
+	}
+
+	return loadedCount < requested || !p.CanLoad()
+}
+
+// Progress returns the count of results loaded so far and the limit being loaded towards.
+func (p *Paginator) Progress() (int, int) {
+	return p.offset, p.limit
+}
+
+// SetTotal records an estimate of all the results available, search functions like BaseRepository.Find
+// set it so that callers can report progress e.g. showing X of Y.
+func (p *Paginator) SetTotal(total int64) {
+	p.total = total
+}
+
+// Total returns the estimate of all the results available, zero when unknown.
+func (p *Paginator) Total() int64 {
+	return p.total
+}
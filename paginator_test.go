@@ -0,0 +1,64 @@
+package frame_test
+
+import (
+	"github.com/pitabwire/frame"
+	"testing"
+)
+
+func TestPaginator_Progress(t *testing.T) {
+
+	paginator := frame.NewPaginator(25, 10)
+	paginator.SetTotal(120)
+
+	steps := []struct {
+		loaded       int
+		wantOffset   int
+		wantBatch    int
+		wantStop     bool
+		wantProgress int
+	}{
+		{loaded: 10, wantOffset: 10, wantBatch: 10, wantStop: false, wantProgress: 10},
+		{loaded: 10, wantOffset: 20, wantBatch: 5, wantStop: false, wantProgress: 20},
+		{loaded: 5, wantOffset: 25, wantBatch: 0, wantStop: true, wantProgress: 25},
+	}
+
+	for i, step := range steps {
+
+		stop := paginator.Stop(step.loaded)
+		if stop != step.wantStop {
+			t.Errorf("step %d: Stop() = %v, want %v", i, stop, step.wantStop)
+		}
+
+		if paginator.Offset() != step.wantOffset || paginator.BatchSize() != step.wantBatch {
+			t.Errorf("step %d: offset %d and batch %d, want %d and %d", i,
+				paginator.Offset(), paginator.BatchSize(), step.wantOffset, step.wantBatch)
+		}
+
+		loaded, limit := paginator.Progress()
+		if loaded != step.wantProgress || limit != 25 {
+			t.Errorf("step %d: Progress() = %d of %d, want %d of 25", i, loaded, limit, step.wantProgress)
+		}
+
+		if paginator.Total() != 120 {
+			t.Errorf("step %d: Total() = %d, want 120", i, paginator.Total())
+		}
+	}
+}
+
+func TestPaginator_StopOnShortBatch(t *testing.T) {
+
+	paginator := frame.NewPaginator(100, 10)
+
+	if paginator.Total() != 0 {
+		t.Errorf("Total() should be zero when unknown")
+	}
+
+	if !paginator.Stop(4) {
+		t.Errorf("loading should stop once a batch returns fewer results than requested")
+	}
+
+	loaded, limit := paginator.Progress()
+	if loaded != 4 || limit != 100 {
+		t.Errorf("Progress() = %d of %d, want 4 of 100", loaded, limit)
+	}
+}
@@ -0,0 +1,96 @@
+package frame
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/propagation"
+	"net/http"
+	"slices"
+)
+
+// contextPropagator carries the w3c trace context and baggage across service boundaries.
+// It is used regardless of the globally registered propagator so that baggage
+// set upstream keeps flowing even when tracing is not exported.
+var contextPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+const ctxKeyPropagatedHeaders = contextKey("propagatedHeadersKey")
+
+// WithPropagatedHeaders Option to list request headers, like a locale or tenant tier, that are carried across services.
+// The server reads the listed headers of inbound requests into the request context
+// and outbound calls made with that context send them on unless already set by the caller.
+func WithPropagatedHeaders(headers ...string) Option {
+	return func(s *Service) {
+		for _, header := range headers {
+			s.propagatedHeaders = append(s.propagatedHeaders, http.CanonicalHeaderKey(header))
+		}
+	}
+}
+
+// PropagatedHeadersToContext adds the supplied headers to the context for propagation on outbound calls
+func PropagatedHeadersToContext(ctx context.Context, headers http.Header) context.Context {
+	merged := PropagatedHeadersFromContext(ctx).Clone()
+	if merged == nil {
+		merged = make(http.Header, len(headers))
+	}
+
+	for key, values := range headers {
+		merged[http.CanonicalHeaderKey(key)] = slices.Clone(values)
+	}
+	return context.WithValue(ctx, ctxKeyPropagatedHeaders, merged)
+}
+
+// PropagatedHeadersFromContext obtains the headers carried in the context for propagation if any exist
+func PropagatedHeadersFromContext(ctx context.Context) http.Header {
+	headers, ok := ctx.Value(ctxKeyPropagatedHeaders).(http.Header)
+	if !ok {
+		return nil
+	}
+	return headers
+}
+
+// injectContextHeaders writes the trace context, baggage and propagated headers in ctx into the supplied http headers
+func injectContextHeaders(ctx context.Context, header http.Header) {
+	contextPropagator.Inject(ctx, propagation.HeaderCarrier(header))
+
+	for key, values := range PropagatedHeadersFromContext(ctx) {
+		if _, ok := header[key]; ok {
+			continue
+		}
+		header[key] = slices.Clone(values)
+	}
+}
+
+// injectContextMetadata writes the trace context and baggage in ctx into the supplied message metadata
+func injectContextMetadata(ctx context.Context, metadata map[string]string) {
+	contextPropagator.Inject(ctx, propagation.MapCarrier(metadata))
+}
+
+// extractContextMetadata restores the trace context and baggage carried in message metadata
+func extractContextMetadata(ctx context.Context, metadata map[string]string) context.Context {
+	if len(metadata) == 0 {
+		return ctx
+	}
+	return contextPropagator.Extract(ctx, propagation.MapCarrier(metadata))
+}
+
+// propagationMiddleware restores the trace context, baggage and propagated headers sent by the caller into the request context
+func (s *Service) propagationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := contextPropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		propagated := make(http.Header, len(s.propagatedHeaders))
+		for _, key := range s.propagatedHeaders {
+			if values := r.Header.Values(key); len(values) > 0 {
+				propagated[key] = slices.Clone(values)
+			}
+		}
+
+		if len(propagated) > 0 {
+			ctx = PropagatedHeadersToContext(ctx, propagated)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,170 @@
+package frame_test
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"go.opentelemetry.io/otel/baggage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func contextWithBaggage(t *testing.T, ctx context.Context) context.Context {
+	member, err := baggage.NewMember("tenant_tier", "gold")
+	if err != nil {
+		t.Fatalf("could not create baggage member : %s", err)
+	}
+
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatalf("could not create baggage : %s", err)
+	}
+
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+func TestService_BaggagePropagatesOverHTTP(t *testing.T) {
+
+	received := make(chan string, 1)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- baggage.FromContext(r.Context()).Member("tenant_tier").Value()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(handler))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	status, _, err := srv.InvokeRestService(contextWithBaggage(t, ctx), http.MethodGet, ts.URL+"/baggage", nil, nil)
+	if err != nil || status != http.StatusOK {
+		t.Errorf("could not invoke downstream service %d : %v", status, err)
+		return
+	}
+
+	select {
+	case tier := <-received:
+		if tier != "gold" {
+			t.Errorf("baggage set upstream should be visible downstream, got %q", tier)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("downstream handler was not called")
+	}
+}
+
+func TestService_PropagatedHeadersOverHTTP(t *testing.T) {
+
+	received := make(chan http.Header, 1)
+	downstreamHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- frame.PropagatedHeadersFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	downstreamCtx, downstream := frame.NewService("Downstream Srv", frame.NoopDriver(),
+		frame.HttpHandler(downstreamHandler), frame.WithPropagatedHeaders("X-Locale", "X-Tenant-Tier"))
+	defer downstream.Stop(downstreamCtx)
+
+	err := downstream.Run(downstreamCtx, "")
+	if err != nil {
+		t.Errorf("could not run downstream service : %s", err)
+		return
+	}
+
+	downstreamServer := httptest.NewServer(downstream.H())
+	defer downstreamServer.Close()
+
+	var upstream *frame.Service
+	upstreamHandler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _, err0 := upstream.InvokeRestService(r.Context(), http.MethodGet, downstreamServer.URL+"/locale", nil, nil)
+		if err0 != nil {
+			status = http.StatusBadGateway
+		}
+		w.WriteHeader(status)
+	})
+
+	ctx, upstream := frame.NewService("Upstream Srv", frame.NoopDriver(),
+		frame.HttpHandler(upstreamHandler), frame.WithPropagatedHeaders("x-locale", "X-Tenant-Tier"))
+	defer upstream.Stop(ctx)
+
+	err = upstream.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run upstream service : %s", err)
+		return
+	}
+
+	upstreamServer := httptest.NewServer(upstream.H())
+	defer upstreamServer.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, upstreamServer.URL+"/locale", nil)
+	req.Header.Set("X-Locale", "fr-FR")
+	req.Header.Set("X-Unlisted", "dropped")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("could not invoke upstream service %v : %v", resp, err)
+		return
+	}
+	_ = resp.Body.Close()
+
+	select {
+	case headers := <-received:
+		if headers.Get("X-Locale") != "fr-FR" {
+			t.Errorf("propagated header should reach the downstream service, got %v", headers)
+		}
+
+		if headers.Get("X-Unlisted") != "" || headers.Get("X-Tenant-Tier") != "" {
+			t.Errorf("only listed headers sent upstream should be propagated, got %v", headers)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("downstream handler was not called")
+	}
+}
+
+type baggageHandler struct {
+	received chan string
+}
+
+func (h *baggageHandler) Handle(ctx context.Context, _ map[string]string, _ []byte) error {
+	h.received <- baggage.FromContext(ctx).Member("tenant_tier").Value()
+	return nil
+}
+
+func TestService_BaggagePropagatesOverQueue(t *testing.T) {
+
+	handler := &baggageHandler{received: make(chan string, 1)}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-baggage", "mem://topicBaggage"),
+		frame.RegisterSubscriber("test-baggage", "mem://topicBaggage", 1, handler),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	err = srv.Publish(contextWithBaggage(t, ctx), "test-baggage", []byte("with baggage"))
+	if err != nil {
+		t.Errorf("could not publish message : %s", err)
+		return
+	}
+
+	select {
+	case tier := <-handler.received:
+		if tier != "gold" {
+			t.Errorf("baggage set by the publisher should be visible to subscribers, got %q", tier)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("subscriber did not receive the message")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
 	_ "github.com/pitabwire/natspubsub"
 	"github.com/sirupsen/logrus"
 	"gocloud.dev/pubsub"
@@ -12,11 +14,13 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type queue struct {
 	publishQueueMap      *sync.Map
 	subscriptionQueueMap *sync.Map
+	handlers             *sync.Map
 }
 
 func (q queue) getPublisherByReference(reference string) (*publisher, error) {
@@ -31,6 +35,7 @@ func newQueue(_ context.Context) *queue {
 	q := &queue{
 		publishQueueMap:      &sync.Map{},
 		subscriptionQueueMap: &sync.Map{},
+		handlers:             &sync.Map{},
 	}
 
 	return q
@@ -42,10 +47,50 @@ type publisher struct {
 	topic     *pubsub.Topic
 }
 
+// SubjectMetadataKey is the metadata key under which subscribers receive the
+// actual subject a message was delivered on. This allows a single handler
+// registered on a wildcard subject such as `orders.>` to route by subject.
+const SubjectMetadataKey = "subject"
+
+// SubscribeWorker handles messages received by a subscriber.
+// Messages with an empty body are delivered like any other as they may carry meaning through their metadata,
+// subscribers registered with WithSkipEmptyMessages acknowledge and drop them instead.
 type SubscribeWorker interface {
 	Handle(ctx context.Context, metadata map[string]string, message []byte) error
 }
 
+// messageSubject extracts the subject a message was delivered on
+// from the underlying nats message where the driver exposes it.
+func messageSubject(msg *pubsub.Message) string {
+	var jsMsg jetstream.Msg
+	if msg.As(&jsMsg) && jsMsg != nil {
+		return jsMsg.Subject()
+	}
+
+	var natsMsg *nats.Msg
+	if msg.As(&natsMsg) && natsMsg != nil {
+		return natsMsg.Subject
+	}
+
+	return ""
+}
+
+// messageMetadata returns the metadata handed to subscribers,
+// enriched with the delivery subject when one is available.
+func messageMetadata(msg *pubsub.Message) map[string]string {
+	subject := messageSubject(msg)
+	if subject == "" {
+		return msg.Metadata
+	}
+
+	metadata := make(map[string]string, len(msg.Metadata)+1)
+	for k, v := range msg.Metadata {
+		metadata[k] = v
+	}
+	metadata[SubjectMetadataKey] = subject
+	return metadata
+}
+
 type subscriber struct {
 	logger *logrus.Entry
 
@@ -53,8 +98,72 @@ type subscriber struct {
 	url          string
 	concurrency  int
 	handler      SubscribeWorker
+	options      []SubscriberOption
+	batchHandler BatchSubscribeWorker
+	batchSize    int
+	batchWait    time.Duration
 	subscription *pubsub.Subscription
 	isInit       atomic.Bool
+	listening    atomic.Bool
+	pause        subscriberPause
+
+	drainMu  sync.Mutex
+	draining bool
+	inFlight sync.WaitGroup
+
+	// deliveries counts delivery attempts by message id for drivers that do not track them
+	deliveries sync.Map
+
+	// acks batches the acknowledgements of handled messages when WithAckBatching is set
+	acks *ackBatcher
+}
+
+// startHandling registers a received message as in flight, it reports false once the subscriber is draining
+// in which case the message should be handed back to the queue instead of being handled
+func (s *subscriber) startHandling(count int) bool {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	if s.draining {
+		return false
+	}
+	s.inFlight.Add(count)
+	return true
+}
+
+// drain stops the subscriber from handling newly received messages and waits for the in flight ones to complete
+func (s *subscriber) drain(ctx context.Context) error {
+	s.drainMu.Lock()
+	s.draining = true
+	s.drainMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.inFlight.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-done:
+	}
+
+	// Handled messages are acknowledged before the subscription closes so they are not redelivered
+	if s.acks != nil {
+		s.acks.flush()
+	}
+	return err
+}
+
+// releaseMessages hands messages received while draining back to the queue for redelivery where supported
+func releaseMessages(messages ...*pubsub.Message) {
+	for _, msg := range messages {
+		if msg.Nackable() {
+			msg.Nack()
+		}
+	}
 }
 
 func (s *subscriber) listen(ctx context.Context, _ JobResultPipe) error {
@@ -62,6 +171,11 @@ func (s *subscriber) listen(ctx context.Context, _ JobResultPipe) error {
 	service := FromContext(ctx)
 	logger := service.L(ctx).WithField("name", s.reference).WithField("function", "subscription").WithField("url", s.url)
 	logger.Debug("starting to listen for messages")
+
+	s.listening.Store(true)
+	defer s.listening.Store(false)
+
+	options := newSubscriberOptions(s.options...)
 	for {
 
 		select {
@@ -72,41 +186,112 @@ func (s *subscriber) listen(ctx context.Context, _ JobResultPipe) error {
 
 		default:
 
-			msg, err := s.subscription.Receive(ctx)
+			receiveCtx, cancelReceive, err := s.receiveContext(ctx)
+			if err != nil {
+				s.isInit.Store(false)
+				logger.Debug("exiting due to canceled context")
+				return err
+			}
+
+			msg, err := s.subscription.Receive(receiveCtx)
+			cancelReceive()
 			if err != nil {
 				if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					continue
 				}
 
+				if service.IsStopping() {
+					s.isInit.Store(false)
+					logger.Debug("exiting as the service is stopping")
+					return nil
+				}
+
 				logger.WithError(err).Error(" could not pull message")
 				s.isInit.Store(false)
 				return err
 			}
 
+			if options.skipEmpty && len(msg.Body) == 0 {
+				logger.Debug("skipping empty message")
+				msg.Ack()
+				continue
+			}
+
+			if !s.startHandling(1) {
+				releaseMessages(msg)
+				s.isInit.Store(false)
+				logger.Debug("exiting as the subscriber is draining")
+				return nil
+			}
+
 			job := service.NewJob(func(ctx context.Context, _ JobResultPipe) error {
-				authClaim := ClaimsFromMap(msg.Metadata)
+				defer s.inFlight.Done()
+
+				metadata := messageMetadata(msg)
+				authClaim := ClaimsFromMap(metadata)
 
-				var ctx2 context.Context
+				ctx2 := extractContextMetadata(ctx, metadata)
 				if nil != authClaim {
-					ctx2 = authClaim.ClaimsToContext(ctx)
-				} else {
-					ctx2 = ctx
+					ctx2 = authClaim.ClaimsToContext(ctx2)
+				}
+
+				attempt := s.deliveryAttempt(msg)
+				ctx2, span := startProcessSpan(ctx2, s.reference, attempt)
+
+				handleStartedAt := time.Now()
+				meta := receivedMessageMeta(msg, metadata, attempt, handleStartedAt)
+
+				dedupKey := s.dedupKey(options, meta)
+				if dedupKey != "" {
+					seen, err1 := options.dedupStore.Seen(ctx2, dedupKey)
+					if err1 != nil {
+						logger.WithError(err1).Warn(" could not check if message was processed")
+					} else if seen {
+						logger.WithField("request_id", meta.RequestID()).Debug("skipping already processed message")
+						endSpan(span, nil)
+						s.forgetDeliveries(msg)
+						s.ack(msg.Ack)
+						return nil
+					}
 				}
 
-				err0 := s.handler.Handle(ctx2, msg.Metadata, msg.Body)
+				panicFields := map[string]string{"queue.reference": s.reference, "queue.message_id": msg.LoggableID}
+				err0 := service.recoverAsError(ctx2, panicFields, func() error {
+					if metaHandler, ok := s.handler.(MessageMetaSubscribeWorker); ok {
+						return metaHandler.HandleMessage(ctx2, meta, msg.Body)
+					}
+					return s.handler.Handle(ctx2, metadata, msg.Body)
+				})
+				service.queueMetrics().recordHandled(ctx2, service.queueMetricAttributes(s.reference, metadata), handleStartedAt, err0)
+				endSpan(span, err0)
 				if err0 != nil {
 					logger.WithError(err0).Warn(" could not handle message")
+					if s.deadLetter(ctx2, options, msg, metadata, attempt, err0) {
+						s.forgetDeliveries(msg)
+						msg.Ack()
+						return err0
+					}
 					if msg.Nackable() {
 						msg.Nack()
+					} else {
+						s.forgetDeliveries(msg)
 					}
 					return err0
 				}
-				msg.Ack()
+				if dedupKey != "" {
+					err1 := options.dedupStore.Record(ctx2, dedupKey, options.dedupTTL)
+					if err1 != nil {
+						logger.WithError(err1).Warn(" could not record message as processed")
+					}
+				}
+				s.forgetDeliveries(msg)
+				s.ack(msg.Ack)
 				return nil
 			})
 
-			err = service.SubmitJob(ctx, job)
+			err = service.submitInternalJob(ctx, job)
 			if err != nil {
+				s.inFlight.Done()
 				logger.WithError(err).Warn(" Ignoring handle error message")
 				return err
 			}
@@ -115,25 +300,29 @@ func (s *subscriber) listen(ctx context.Context, _ JobResultPipe) error {
 	}
 }
 
-// RegisterPublisher Option to register publishing path referenced within the system
+// RegisterPublisher Option to register publishing path referenced within the system,
+// registering a reference again keeps the publisher registered first
 func RegisterPublisher(reference string, queueURL string) Option {
 	return func(s *Service) {
-		s.queue.publishQueueMap.Store(reference, &publisher{
+		s.queue.publishQueueMap.LoadOrStore(reference, &publisher{
 			reference: reference,
 			url:       queueURL,
 		})
 	}
 }
 
-// RegisterSubscriber Option to register a new subscription handler
+// RegisterSubscriber Option to register a new subscription handler,
+// registering a reference again keeps the subscriber registered first
 func RegisterSubscriber(reference string, queueURL string, concurrency int,
-	handler SubscribeWorker) Option {
+	handler SubscribeWorker, opts ...SubscriberOption) Option {
 	return func(s *Service) {
-		s.queue.subscriptionQueueMap.Store(reference, &subscriber{
+		s.queue.subscriptionQueueMap.LoadOrStore(reference, &subscriber{
 			reference:   reference,
 			url:         queueURL,
 			concurrency: concurrency,
 			handler:     handler,
+			options:     opts,
+			acks:        newAckBatcher(newSubscriberOptions(opts...)),
 		})
 	}
 }
@@ -170,8 +359,19 @@ func (s *Service) AddPublisher(ctx context.Context, reference string, queueURL s
 	return nil
 }
 
-// Publish Queue method to write a new message into the queue pre initialized with the supplied reference
+// Publish Queue method to write a new message into the queue pre initialized with the supplied reference.
+// The publish is aborted as soon as the supplied context is canceled or its deadline is exceeded. A message already
+// handed to the broker may still be delivered, so publishing it again after such an error can produce a duplicate.
 func (s *Service) Publish(ctx context.Context, reference string, payload any) error {
+	return s.publish(ctx, reference, payload, nil)
+}
+
+func (s *Service) publish(ctx context.Context, reference string, payload any, extraMetadata map[string]string) (err error) {
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
 	var metadata map[string]string
 
 	authClaim := ClaimsFromContext(ctx)
@@ -181,6 +381,19 @@ func (s *Service) Publish(ctx context.Context, reference string, payload any) er
 		metadata = make(map[string]string)
 	}
 
+	if requestID := publishRequestIDFromContext(ctx); requestID != "" {
+		metadata[RequestIDMetadataKey] = requestID
+	}
+
+	for k, v := range extraMetadata {
+		metadata[k] = v
+	}
+
+	ctx, span := startPublishSpan(ctx, reference)
+	defer func() { endSpan(span, err) }()
+
+	injectContextMetadata(ctx, metadata)
+
 	pub, err := s.queue.getPublisherByReference(reference)
 	if err != nil {
 		return err
@@ -191,19 +404,33 @@ func (s *Service) Publish(ctx context.Context, reference string, payload any) er
 	if !ok {
 		msg0, err0 := json.Marshal(payload)
 		if err0 != nil {
-			return err
+			return err0
 		}
 		message = msg0
 	} else {
 		message = msg
 	}
 
+	outbox := outboxFromContext(ctx)
+	if outbox != nil {
+		return outbox.enqueue(ctx, reference, message, metadata)
+	}
+
 	topic := pub.topic
 
-	return topic.Send(ctx, &pubsub.Message{
-		Body:     message,
-		Metadata: metadata,
+	// Send stops waiting for the broker once ctx is done, the message may still be delivered after the context error
+	err = s.sendWithRetry(ctx, reference, func() error {
+		return topic.Send(ctx, &pubsub.Message{
+			Body:     message,
+			Metadata: metadata,
+		})
 	})
+	if err != nil {
+		return err
+	}
+
+	s.queueMetrics().recordPublish(ctx, s.queueMetricAttributes(reference, metadata))
+	return nil
 
 }
 
@@ -236,7 +463,12 @@ func (s *Service) initSubscriber(ctx context.Context, sub *subscriber) error {
 
 	if !strings.HasPrefix(sub.url, "http") {
 
-		subsc, err := pubsub.OpenSubscription(ctx, sub.url)
+		subscriptionURL, err := subscriberURL(sub.url, sub.options...)
+		if err != nil {
+			return err
+		}
+
+		subsc, err := pubsub.OpenSubscription(ctx, subscriptionURL)
 		if err != nil {
 			return fmt.Errorf("could not open topic subscription: %s", err)
 		}
@@ -270,6 +502,11 @@ func (s *Service) initPubsub(ctx context.Context) error {
 		return nil
 	}
 
+	err := s.initQueueTopology(ctx)
+	if err != nil {
+		return err
+	}
+
 	var publishers []*publisher
 
 	s.queue.publishQueueMap.Range(func(key, value any) bool {
@@ -302,6 +539,9 @@ func (s *Service) initPubsub(ctx context.Context) error {
 
 	s.subscribe(ctx)
 
+	// registers the consumer pending gauge before any message is handled
+	s.queueMetrics()
+
 	return nil
 }
 
@@ -317,9 +557,14 @@ func (s *Service) subscribe(ctx context.Context) {
 		}
 		subsc.logger = logger
 
-		job := s.NewJob(subsc.listen)
+		listen := subsc.listen
+		if subsc.batchHandler != nil {
+			listen = subsc.listenBatch
+		}
+
+		job := s.NewJob(listen)
 
-		err := s.SubmitJob(ctx, job)
+		err := s.SubmitJobWithCancellation(ctx, job)
 		if err != nil {
 			logger.WithError(err).WithField("subscriber", subsc).Error(" could not listen or subscribe for messages")
 			return false
@@ -0,0 +1,96 @@
+package frame
+
+import (
+	"sync"
+	"time"
+)
+
+// WithAckBatching holds back the acknowledgements of handled messages and sends them together once count are pending
+// or interval has passed since the first of them, whichever happens first, for high throughput JetStream subscribers.
+// Pending acknowledgements are flushed when the subscriber drains on shutdown, so handled messages are not redelivered.
+// The interval has to stay well below the ack wait of the consumer, otherwise messages are redelivered before their
+// acknowledgement is sent; delivery remains at least once. Without an interval pending acknowledgements are sent
+// after a second, so a count that low traffic never reaches does not hold them back.
+func WithAckBatching(count int, interval time.Duration) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.ackBatchSize = count
+		opts.ackBatchInterval = interval
+	}
+}
+
+// defaultAckBatchInterval bounds how long acknowledgements wait when ack batching is configured without an interval
+const defaultAckBatchInterval = time.Second
+
+// ackBatcher collects acknowledgements and sends them in batches
+type ackBatcher struct {
+	count    int
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending []func()
+	timer   *time.Timer
+}
+
+func newAckBatcher(options *subscriberOptions) *ackBatcher {
+	if options.ackBatchSize <= 0 && options.ackBatchInterval <= 0 {
+		return nil
+	}
+
+	interval := options.ackBatchInterval
+	if interval <= 0 {
+		interval = defaultAckBatchInterval
+	}
+	return &ackBatcher{count: options.ackBatchSize, interval: interval}
+}
+
+// add queues ack to be sent with the next batch
+func (b *ackBatcher) add(ack func()) {
+	b.mu.Lock()
+	b.pending = append(b.pending, ack)
+
+	if b.count > 0 && len(b.pending) >= b.count {
+		acks := b.take()
+		b.mu.Unlock()
+		sendAcks(acks)
+		return
+	}
+
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.interval, b.flush)
+	}
+	b.mu.Unlock()
+}
+
+// take removes the pending acknowledgements, it is called with the lock held
+func (b *ackBatcher) take() []func() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	acks := b.pending
+	b.pending = nil
+	return acks
+}
+
+// flush sends the pending acknowledgements
+func (b *ackBatcher) flush() {
+	b.mu.Lock()
+	acks := b.take()
+	b.mu.Unlock()
+	sendAcks(acks)
+}
+
+func sendAcks(acks []func()) {
+	for _, ack := range acks {
+		ack()
+	}
+}
+
+// ack acknowledges a handled message, through the ack batcher when the subscriber has one
+func (s *subscriber) ack(ack func()) {
+	if s.acks == nil {
+		ack()
+		return
+	}
+	s.acks.add(ack)
+}
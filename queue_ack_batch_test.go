@@ -0,0 +1,153 @@
+package frame
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAckBatcher(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		count    int
+		interval time.Duration
+		added    int
+		wantNow  int64
+		wantSoon int64
+	}{
+		{name: "Below count", count: 3, interval: time.Hour, added: 2, wantNow: 0, wantSoon: 0},
+		{name: "Count reached", count: 3, interval: time.Hour, added: 3, wantNow: 3, wantSoon: 3},
+		{name: "Interval elapsed", count: 100, interval: 20 * time.Millisecond, added: 2, wantNow: 0, wantSoon: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			batcher := newAckBatcher(newSubscriberOptions(WithAckBatching(tt.count, tt.interval)))
+
+			var acked atomic.Int64
+			for i := 0; i < tt.added; i++ {
+				batcher.add(func() { acked.Add(1) })
+			}
+
+			if acked.Load() != tt.wantNow {
+				t.Errorf("acked %d right away, expected %d", acked.Load(), tt.wantNow)
+			}
+
+			time.Sleep(200 * time.Millisecond)
+			if acked.Load() != tt.wantSoon {
+				t.Errorf("acked %d after waiting, expected %d", acked.Load(), tt.wantSoon)
+			}
+		})
+	}
+}
+
+func TestAckBatcher_CountWithoutInterval(t *testing.T) {
+
+	batcher := newAckBatcher(newSubscriberOptions(WithAckBatching(100, 0)))
+
+	var acked atomic.Int64
+	for i := 0; i < 2; i++ {
+		batcher.add(func() { acked.Add(1) })
+	}
+
+	if acked.Load() != 0 {
+		t.Fatalf("acked %d right away, expected them to wait for the batch", acked.Load())
+	}
+
+	deadline := time.Now().Add(defaultAckBatchInterval + time.Second)
+	for time.Now().Before(deadline) && acked.Load() != 2 {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if acked.Load() != 2 {
+		t.Errorf("acked %d when fewer than count arrived, expected them to be sent after the default interval", acked.Load())
+	}
+}
+
+func TestAckBatcher_Disabled(t *testing.T) {
+	if newAckBatcher(newSubscriberOptions()) != nil {
+		t.Errorf("subscribers without WithAckBatching should acknowledge right away")
+	}
+}
+
+func TestSubscriber_DrainFlushesAcks(t *testing.T) {
+
+	sub := &subscriber{acks: newAckBatcher(newSubscriberOptions(WithAckBatching(10, time.Hour)))}
+
+	var acked atomic.Int64
+	for i := 0; i < 4; i++ {
+		sub.ack(func() { acked.Add(1) })
+	}
+
+	if acked.Load() != 0 {
+		t.Fatalf("acknowledgements should be pending before the drain, %d were sent", acked.Load())
+	}
+
+	err := sub.drain(context.Background())
+	if err != nil {
+		t.Fatalf("could not drain subscriber : %s", err)
+	}
+
+	if acked.Load() != 4 {
+		t.Errorf("drain sent %d acknowledgements, expected the 4 pending ones", acked.Load())
+	}
+}
+
+type countingBatchHandler struct {
+	handled atomic.Int64
+}
+
+func (h *countingBatchHandler) HandleBatch(_ context.Context, messages []QueueMessage) error {
+	h.handled.Add(int64(len(messages)))
+	return nil
+}
+
+func TestRegisterBatchSubscriber_AckBatching(t *testing.T) {
+
+	handler := &countingBatchHandler{}
+	ctx, srv := NewService("Test Srv", NoopDriver(),
+		RegisterPublisher("batched-acks", "mem://topicBatchedAcks"),
+		RegisterBatchSubscriber("batched-acks", "mem://topicBatchedAcks", 5, 50*time.Millisecond, handler,
+			WithAckBatching(10, time.Hour)))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		err = srv.Publish(ctx, "batched-acks", []byte("message"))
+		if err != nil {
+			t.Fatalf("could not publish message : %s", err)
+		}
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for handler.handled.Load() < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if handler.handled.Load() != 3 {
+		t.Fatalf("handled %d messages, expected 3", handler.handled.Load())
+	}
+
+	value, _ := srv.queue.subscriptionQueueMap.Load("batched-acks")
+	sub := value.(*subscriber)
+	if sub.acks == nil {
+		t.Fatalf("batch subscribers should batch their acknowledgements when WithAckBatching is set")
+	}
+
+	deadline = time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		sub.acks.mu.Lock()
+		pending := len(sub.acks.pending)
+		sub.acks.mu.Unlock()
+		if pending == 3 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("the acknowledgements of the handled batch should be held back until the ack batch fills")
+}
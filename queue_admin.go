@@ -0,0 +1,108 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// ErrQueueAdminDisabled is returned by queue admin operations unless they were enabled with WithQueueAdminOperations
+var ErrQueueAdminDisabled = NewError(ErrorCodePermissionDenied, "queue admin operations are not enabled")
+
+// WithQueueAdminOperations Option that enables destructive queue operations such as PurgeStream and ResetConsumer.
+// They are disabled by default so they can only be used by services built for admin tooling.
+func WithQueueAdminOperations() Option {
+	return func(s *Service) {
+		s.queueAdminEnabled = true
+	}
+}
+
+// PurgeStream removes every message stored for the queue reference. For jetstream queues the messages on the
+// queue subject are purged from its stream, other queues do not retain messages so there is nothing to remove.
+func (s *Service) PurgeStream(ctx context.Context, reference string) error {
+
+	if !s.queueAdminEnabled {
+		return ErrQueueAdminDisabled
+	}
+
+	target, err := s.openJetStream(reference)
+	if err != nil {
+		if errors.Is(err, ErrNotJetStreamQueue) {
+			return nil
+		}
+		return err
+	}
+	defer target.close()
+
+	stream, err := target.js.Stream(ctx, target.stream)
+	if err != nil {
+		return err
+	}
+
+	var purgeOpts []jetstream.StreamPurgeOpt
+	if target.subject != "" {
+		purgeOpts = append(purgeOpts, jetstream.WithPurgeSubject(target.subject))
+	}
+
+	err = stream.Purge(ctx, purgeOpts...)
+	if err != nil {
+		return err
+	}
+
+	s.L(ctx).WithField("reference", reference).WithField("stream", target.stream).Warn("queue stream purged")
+	return nil
+}
+
+// ResetConsumer moves the durable consumer of the subscriber reference to the position described by policy,
+// for example jetstream.DeliverAllPolicy to redeliver the whole stream or jetstream.DeliverNewPolicy to skip
+// everything pending. The consumer is recreated with its existing configuration and the new deliver policy.
+// Queues other than jetstream keep no consumer position so resetting them does nothing.
+func (s *Service) ResetConsumer(ctx context.Context, reference string, policy jetstream.DeliverPolicy) error {
+
+	if !s.queueAdminEnabled {
+		return ErrQueueAdminDisabled
+	}
+
+	target, err := s.openJetStream(reference)
+	if err != nil {
+		if errors.Is(err, ErrNotJetStreamQueue) {
+			return nil
+		}
+		return err
+	}
+	defer target.close()
+
+	if target.durable == "" {
+		return fmt.Errorf("queue %s has no durable consumer to reset", reference)
+	}
+
+	stream, err := target.js.Stream(ctx, target.stream)
+	if err != nil {
+		return err
+	}
+
+	consumer, err := stream.Consumer(ctx, target.durable)
+	if err != nil {
+		return err
+	}
+
+	config := consumer.CachedInfo().Config
+	config.DeliverPolicy = policy
+	config.OptStartSeq = 0
+	config.OptStartTime = nil
+
+	err = stream.DeleteConsumer(ctx, target.durable)
+	if err != nil {
+		return err
+	}
+
+	_, err = stream.CreateConsumer(ctx, config)
+	if err != nil {
+		return err
+	}
+
+	s.L(ctx).WithField("reference", reference).WithField("consumer", target.durable).
+		WithField("policy", policy.String()).Warn("queue consumer reset")
+	return nil
+}
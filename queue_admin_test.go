@@ -0,0 +1,124 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/pitabwire/frame"
+	"testing"
+	"time"
+)
+
+type rejectingHandler struct{}
+
+func (h *rejectingHandler) Handle(_ context.Context, _ map[string]string, _ []byte) error {
+	return errors.New("message kept for later")
+}
+
+func streamMessageCount(ctx context.Context, natsURL string, streamName string) (uint64, error) {
+	conn, err := nats.Connect(natsURL)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	stream, err := js.Stream(ctx, streamName)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := stream.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return info.State.Msgs, nil
+}
+
+func TestService_PurgeStream(t *testing.T) {
+
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+	suffix := time.Now().UnixNano()
+	streamName := fmt.Sprintf("frame_purge_%d", suffix)
+	queueURL := fmt.Sprintf("%s?jetstream=true&stream_name=%s&subject=frame.purge.%d", natsURL, streamName, suffix)
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("purge", queueURL),
+		frame.RegisterSubscriber("purge-sub", queueURL, 1, &rejectingHandler{}, frame.WithDurableConsumer("purge_sub")),
+		frame.WithQueueAdminOperations(),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	for i := range 3 {
+		err = srv.Publish(ctx, "purge", []byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Errorf("could not publish message : %s", err)
+			return
+		}
+	}
+
+	count, err := streamMessageCount(ctx, natsURL, streamName)
+	if err != nil || count != 3 {
+		t.Errorf("stream should hold the published messages, got %d : %v", count, err)
+		return
+	}
+
+	err = srv.ResetConsumer(ctx, "purge-sub", jetstream.DeliverAllPolicy)
+	if err != nil {
+		t.Errorf("could not reset consumer : %s", err)
+		return
+	}
+
+	err = srv.PurgeStream(ctx, "purge")
+	if err != nil {
+		t.Errorf("could not purge stream : %s", err)
+		return
+	}
+
+	count, err = streamMessageCount(ctx, natsURL, streamName)
+	if err != nil || count != 0 {
+		t.Errorf("stream should be empty after a purge, got %d : %v", count, err)
+	}
+}
+
+func TestService_QueueAdminOperationsGuard(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("admin-mem", "mem://topicAdmin"),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.PurgeStream(ctx, "admin-mem")
+	if !errors.Is(err, frame.ErrQueueAdminDisabled) {
+		t.Errorf("purging without enabling admin operations should be refused, got %v", err)
+	}
+
+	err = srv.ResetConsumer(ctx, "admin-mem", jetstream.DeliverNewPolicy)
+	if !errors.Is(err, frame.ErrQueueAdminDisabled) {
+		t.Errorf("resetting without enabling admin operations should be refused, got %v", err)
+	}
+
+	srv.Init(frame.WithQueueAdminOperations())
+
+	err = srv.PurgeStream(ctx, "admin-mem")
+	if err != nil {
+		t.Errorf("purging an in memory queue should do nothing, got %v", err)
+	}
+
+	err = srv.ResetConsumer(ctx, "admin-mem", jetstream.DeliverNewPolicy)
+	if err != nil {
+		t.Errorf("resetting an in memory queue should do nothing, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"gocloud.dev/pubsub"
+	"slices"
+	"time"
+)
+
+// QueueMessage is a message received from a queue
+type QueueMessage struct {
+	Metadata map[string]string
+	Body     []byte
+}
+
+// BatchSubscribeWorker handles messages received from a queue in batches
+type BatchSubscribeWorker interface {
+	HandleBatch(ctx context.Context, messages []QueueMessage) error
+}
+
+// RegisterBatchSubscriber Option to register a subscription whose handler receives messages in batches.
+// Up to maxBatch messages are collected, waiting at most maxWait after the first one arrives,
+// the batch is acknowledged when the handler succeeds and negatively acknowledged when it fails.
+// Registering a reference again keeps the subscriber registered first.
+func RegisterBatchSubscriber(reference string, queueURL string, maxBatch int, maxWait time.Duration,
+	handler BatchSubscribeWorker, opts ...SubscriberOption) Option {
+	return func(s *Service) {
+		if maxBatch <= 0 {
+			maxBatch = 1
+		}
+
+		s.queue.subscriptionQueueMap.LoadOrStore(reference, &subscriber{
+			reference:    reference,
+			url:          queueURL,
+			concurrency:  1,
+			batchHandler: handler,
+			batchSize:    maxBatch,
+			batchWait:    maxWait,
+			options:      opts,
+			acks:         newAckBatcher(newSubscriberOptions(opts...)),
+		})
+	}
+}
+
+func (s *subscriber) listenBatch(ctx context.Context, _ JobResultPipe) error {
+
+	service := FromContext(ctx)
+	logger := service.L(ctx).WithField("name", s.reference).WithField("function", "batchSubscription").WithField("url", s.url)
+	logger.Debug("starting to listen for message batches")
+
+	s.listening.Store(true)
+	defer s.listening.Store(false)
+
+	skipEmpty := newSubscriberOptions(s.options...).skipEmpty
+
+	for {
+		batch, err := s.receiveBatch(ctx)
+		if err != nil {
+			if ctx.Err() == nil && errors.Is(err, context.Canceled) {
+				// The receive was interrupted by pausing the subscriber
+				continue
+			}
+
+			s.isInit.Store(false)
+			if ctx.Err() != nil {
+				logger.Debug("exiting due to canceled context")
+				return ctx.Err()
+			}
+
+			if service.IsStopping() {
+				logger.Debug("exiting as the service is stopping")
+				return nil
+			}
+
+			logger.WithError(err).Error(" could not pull messages")
+			return err
+		}
+
+		if skipEmpty {
+			batch = slices.DeleteFunc(batch, func(msg *pubsub.Message) bool {
+				if len(msg.Body) > 0 {
+					return false
+				}
+				s.ack(msg.Ack)
+				return true
+			})
+			if len(batch) == 0 {
+				continue
+			}
+		}
+
+		if !s.startHandling(1) {
+			releaseMessages(batch...)
+			s.isInit.Store(false)
+			logger.Debug("exiting as the subscriber is draining")
+			return nil
+		}
+
+		messages := make([]QueueMessage, 0, len(batch))
+		for _, msg := range batch {
+			messages = append(messages, QueueMessage{Metadata: messageMetadata(msg), Body: msg.Body})
+		}
+
+		handleStartedAt := time.Now()
+		err = service.recoverAsError(ctx, map[string]string{"queue.reference": s.reference}, func() error {
+			return s.batchHandler.HandleBatch(ctx, messages)
+		})
+		service.queueMetrics().recordHandled(ctx, service.queueMetricAttributes(s.reference, nil), handleStartedAt, err)
+
+		for _, msg := range batch {
+			if err == nil {
+				s.ack(msg.Ack)
+			} else if msg.Nackable() {
+				msg.Nack()
+			}
+		}
+
+		s.inFlight.Done()
+
+		if err != nil {
+			logger.WithError(err).WithField("size", len(batch)).Warn(" could not handle message batch")
+		}
+	}
+}
+
+// receiveBatch blocks for the first message then collects more until the batch is full or the wait elapses
+func (s *subscriber) receiveBatch(ctx context.Context) ([]*pubsub.Message, error) {
+
+	receiveCtx, cancelReceive, err := s.receiveContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	first, err := s.subscription.Receive(receiveCtx)
+	cancelReceive()
+	if err != nil {
+		return nil, err
+	}
+
+	batch := []*pubsub.Message{first}
+
+	waitCtx, cancel := context.WithTimeout(ctx, s.batchWait)
+	defer cancel()
+
+	for len(batch) < s.batchSize {
+		msg, err := s.subscription.Receive(waitCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				// The collected messages will not be handled, hand them back for redelivery
+				releaseMessages(batch...)
+				return nil, err
+			}
+
+			// Hand over what was collected once the wait elapses,
+			// the next receive surfaces any persistent subscription error
+			break
+		}
+		batch = append(batch, msg)
+	}
+
+	return batch, nil
+}
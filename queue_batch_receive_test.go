@@ -0,0 +1,50 @@
+package frame
+
+import (
+	"context"
+	"gocloud.dev/pubsub"
+	"testing"
+	"time"
+)
+
+func TestSubscriber_ReceiveBatchCanceledReleasesMessages(t *testing.T) {
+
+	ctx := context.Background()
+
+	topic, err := pubsub.OpenTopic(ctx, "mem://topicBatchCanceled")
+	if err != nil {
+		t.Fatalf("could not open topic : %s", err)
+	}
+	defer topic.Shutdown(ctx)
+
+	subscription, err := pubsub.OpenSubscription(ctx, "mem://topicBatchCanceled?ackdeadline=1h")
+	if err != nil {
+		t.Fatalf("could not open subscription : %s", err)
+	}
+	defer subscription.Shutdown(ctx)
+
+	err = topic.Send(ctx, &pubsub.Message{Body: []byte("collected")})
+	if err != nil {
+		t.Fatalf("could not send message : %s", err)
+	}
+
+	sub := &subscriber{subscription: subscription, batchSize: 5, batchWait: time.Hour}
+
+	receiveCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	_, err = sub.receiveBatch(receiveCtx)
+	if err == nil {
+		t.Fatalf("receiving a batch should fail once the context is canceled")
+	}
+
+	// Without the release the message stays leased until its ack deadline passes
+	redeliverCtx, cancelRedeliver := context.WithTimeout(ctx, 2*time.Second)
+	defer cancelRedeliver()
+
+	msg, err := subscription.Receive(redeliverCtx)
+	if err != nil {
+		t.Fatalf("the message collected before the cancel was not handed back : %s", err)
+	}
+	msg.Ack()
+}
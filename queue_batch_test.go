@@ -0,0 +1,112 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"sync"
+	"testing"
+	"time"
+)
+
+type batchHandler struct {
+	mu      sync.Mutex
+	sizes   []int
+	fail    bool
+	batches chan int
+}
+
+func (h *batchHandler) HandleBatch(_ context.Context, messages []frame.QueueMessage) error {
+	h.mu.Lock()
+	h.sizes = append(h.sizes, len(messages))
+	h.mu.Unlock()
+
+	select {
+	case h.batches <- len(messages):
+	default:
+	}
+
+	if h.fail {
+		return errors.New("bulk write failed")
+	}
+	return nil
+}
+
+func TestService_RegisterBatchSubscriber(t *testing.T) {
+
+	handler := &batchHandler{batches: make(chan int, 10)}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-batch", "mem://topicBatch"),
+		frame.RegisterBatchSubscriber("test-batch", "mem://topicBatch", 4, 500*time.Millisecond, handler),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	for i := range 10 {
+		err = srv.Publish(ctx, "test-batch", []byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Errorf("could not publish message %d : %s", i, err)
+			return
+		}
+	}
+
+	received := 0
+	for received < 10 {
+		select {
+		case size := <-handler.batches:
+			received += size
+		case <-time.After(3 * time.Second):
+			t.Errorf("batches were not delivered, received %d messages", received)
+			return
+		}
+	}
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	want := []int{4, 4, 2}
+	if fmt.Sprint(handler.sizes) != fmt.Sprint(want) {
+		t.Errorf("batch sizes = %v, want %v", handler.sizes, want)
+	}
+}
+
+func TestService_RegisterBatchSubscriberHandlerError(t *testing.T) {
+
+	handler := &batchHandler{batches: make(chan int, 10), fail: true}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-batch-error", "mem://topicBatchError"),
+		frame.RegisterBatchSubscriber("test-batch-error", "mem://topicBatchError", 4, 100*time.Millisecond, handler),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	err = srv.Publish(ctx, "test-batch-error", []byte("message"))
+	if err != nil {
+		t.Errorf("could not publish message : %s", err)
+		return
+	}
+
+	select {
+	case <-handler.batches:
+	case <-time.After(2 * time.Second):
+		t.Errorf("batch was not delivered")
+		return
+	}
+
+	if !srv.SubscriptionIsInitiated("test-batch-error") {
+		t.Errorf("a failing batch handler should not stop the subscription")
+	}
+}
@@ -0,0 +1,102 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SchemaVersionMetadataKey is the message metadata key carrying the schema version of the payload
+const SchemaVersionMetadataKey = "schema_version"
+
+// ErrUnknownSchemaVersion is returned when a message carries a schema version no decoder is registered for
+var ErrUnknownSchemaVersion = NewError(ErrorCodeInvalidArgument, "unknown message schema version")
+
+// PublishVersioned publishes payload to the queue reference tagged with the supplied schema version,
+// subscribers decode it with the matching decoder of a VersionedCodec.
+func (s *Service) PublishVersioned(ctx context.Context, reference string, version string, payload any) error {
+	return s.publish(ctx, reference, payload, map[string]string{SchemaVersionMetadataKey: version})
+}
+
+// VersionedDecoder decodes the payload of one schema version into the current shape of the event
+type VersionedDecoder[T any] func(message []byte) (T, error)
+
+// JSONDecoder is a VersionedDecoder for payloads that unmarshal directly into the event
+func JSONDecoder[T any](message []byte) (T, error) {
+	var event T
+	err := json.Unmarshal(message, &event)
+	return event, err
+}
+
+// VersionedCodec decodes queue messages into the event T, routing each one to the decoder registered for
+// the schema version in its metadata. Old messages remaining on a queue keep decoding after the event changes,
+// as long as a decoder upgrading them is registered.
+type VersionedCodec[T any] struct {
+	defaultVersion string
+	decoders       map[string]VersionedDecoder[T]
+	unknownVersion func(ctx context.Context, metadata map[string]string, message []byte) error
+}
+
+// NewVersionedCodec creates a codec, messages published without a schema version are decoded as defaultVersion
+func NewVersionedCodec[T any](defaultVersion string) *VersionedCodec[T] {
+	return &VersionedCodec[T]{
+		defaultVersion: defaultVersion,
+		decoders:       map[string]VersionedDecoder[T]{},
+	}
+}
+
+// Register adds the decoder for messages of the supplied schema version
+func (c *VersionedCodec[T]) Register(version string, decoder VersionedDecoder[T]) *VersionedCodec[T] {
+	c.decoders[version] = decoder
+	return c
+}
+
+// OnUnknownVersion sets the error path for messages whose schema version has no decoder, for example
+// publishing them to a dead letter queue. Its result is what the subscriber sees, returning nil acknowledges the message.
+// Without it such messages fail with ErrUnknownSchemaVersion and are negatively acknowledged.
+func (c *VersionedCodec[T]) OnUnknownVersion(handler func(ctx context.Context, metadata map[string]string, message []byte) error) *VersionedCodec[T] {
+	c.unknownVersion = handler
+	return c
+}
+
+// Decode decodes message with the decoder of the schema version in metadata
+func (c *VersionedCodec[T]) Decode(metadata map[string]string, message []byte) (T, error) {
+
+	version, ok := metadata[SchemaVersionMetadataKey]
+	if !ok || version == "" {
+		version = c.defaultVersion
+	}
+
+	decoder, ok := c.decoders[version]
+	if !ok {
+		var event T
+		return event, fmt.Errorf("no decoder for version %q : %w", version, ErrUnknownSchemaVersion)
+	}
+
+	return decoder(message)
+}
+
+// Handler creates a SubscribeWorker that decodes each message and hands the event to handle.
+// Messages of an unknown schema version go to the error path set with OnUnknownVersion.
+func (c *VersionedCodec[T]) Handler(handle func(ctx context.Context, event T) error) SubscribeWorker {
+	return &versionedHandler[T]{codec: c, handle: handle}
+}
+
+type versionedHandler[T any] struct {
+	codec  *VersionedCodec[T]
+	handle func(ctx context.Context, event T) error
+}
+
+func (vh *versionedHandler[T]) Handle(ctx context.Context, metadata map[string]string, message []byte) error {
+
+	event, err := vh.codec.Decode(metadata, message)
+	if err != nil {
+		if vh.codec.unknownVersion != nil && errors.Is(err, ErrUnknownSchemaVersion) {
+			return vh.codec.unknownVersion(ctx, metadata, message)
+		}
+		return err
+	}
+
+	return vh.handle(ctx, event)
+}
@@ -0,0 +1,127 @@
+package frame_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/pitabwire/frame"
+	"testing"
+	"time"
+)
+
+// orderPlaced is the current shape of the event, v1 payloads carried the amount in whole units
+type orderPlaced struct {
+	OrderID     string `json:"order_id"`
+	AmountCents int64  `json:"amount_cents"`
+}
+
+type orderPlacedV1 struct {
+	OrderID string `json:"order_id"`
+	Amount  int64  `json:"amount"`
+}
+
+func orderPlacedCodec() *frame.VersionedCodec[orderPlaced] {
+	return frame.NewVersionedCodec[orderPlaced]("v1").
+		Register("v1", func(message []byte) (orderPlaced, error) {
+			var old orderPlacedV1
+			err := json.Unmarshal(message, &old)
+			return orderPlaced{OrderID: old.OrderID, AmountCents: old.Amount * 100}, err
+		}).
+		Register("v2", frame.JSONDecoder[orderPlaced])
+}
+
+func TestVersionedCodec_Decode(t *testing.T) {
+
+	codec := orderPlacedCodec()
+
+	tests := []struct {
+		name     string
+		metadata map[string]string
+		message  string
+		want     orderPlaced
+	}{
+		{name: "v1", metadata: map[string]string{frame.SchemaVersionMetadataKey: "v1"},
+			message: `{"order_id":"o-1","amount":12}`, want: orderPlaced{OrderID: "o-1", AmountCents: 1200}},
+		{name: "v2", metadata: map[string]string{frame.SchemaVersionMetadataKey: "v2"},
+			message: `{"order_id":"o-2","amount_cents":1250}`, want: orderPlaced{OrderID: "o-2", AmountCents: 1250}},
+		{name: "unversioned", metadata: map[string]string{},
+			message: `{"order_id":"o-3","amount":3}`, want: orderPlaced{OrderID: "o-3", AmountCents: 300}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := codec.Decode(tt.metadata, []byte(tt.message))
+			if err != nil || got != tt.want {
+				t.Errorf("Decode() = %+v, %v want %+v", got, err, tt.want)
+			}
+		})
+	}
+
+	_, err := codec.Decode(map[string]string{frame.SchemaVersionMetadataKey: "v9"}, []byte(`{}`))
+	if !errors.Is(err, frame.ErrUnknownSchemaVersion) {
+		t.Errorf("unknown versions should fail with ErrUnknownSchemaVersion, got %v", err)
+	}
+}
+
+func TestVersionedCodec_Subscriber(t *testing.T) {
+
+	received := make(chan orderPlaced, 2)
+	unknown := make(chan map[string]string, 1)
+
+	codec := orderPlacedCodec().OnUnknownVersion(func(_ context.Context, metadata map[string]string, _ []byte) error {
+		unknown <- metadata
+		return nil
+	})
+
+	handler := codec.Handler(func(_ context.Context, event orderPlaced) error {
+		received <- event
+		return nil
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.RegisterPublisher("orders", "mem://topicVersionedOrders"),
+		frame.RegisterSubscriber("orders", "mem://topicVersionedOrders", 1, handler))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	err = srv.PublishVersioned(ctx, "orders", "v1", orderPlacedV1{OrderID: "o-1", Amount: 5})
+	if err != nil {
+		t.Fatalf("could not publish v1 event : %s", err)
+	}
+
+	err = srv.PublishVersioned(ctx, "orders", "v3", map[string]string{"order": "o-3"})
+	if err != nil {
+		t.Fatalf("could not publish v3 event : %s", err)
+	}
+
+	err = srv.PublishVersioned(ctx, "orders", "v2", orderPlaced{OrderID: "o-2", AmountCents: 250})
+	if err != nil {
+		t.Fatalf("could not publish v2 event : %s", err)
+	}
+
+	// Messages are handled concurrently so they may arrive in any order
+	want := map[string]orderPlaced{"o-1": {OrderID: "o-1", AmountCents: 500}, "o-2": {OrderID: "o-2", AmountCents: 250}}
+	for range 2 {
+		select {
+		case event := <-received:
+			if event != want[event.OrderID] {
+				t.Errorf("received %+v want %+v", event, want[event.OrderID])
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("event was not handled, the subscriber should keep running after an unknown version")
+		}
+	}
+
+	select {
+	case metadata := <-unknown:
+		if metadata[frame.SchemaVersionMetadataKey] != "v3" {
+			t.Errorf("unknown version handler received %v", metadata)
+		}
+	case <-time.After(3 * time.Second):
+		t.Errorf("unknown version was not sent to the error path")
+	}
+}
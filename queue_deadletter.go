@@ -0,0 +1,96 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"gocloud.dev/pubsub"
+	"strconv"
+)
+
+const (
+	// DeadLetterReasonMetadataKey is the metadata key carrying the handler error of a dead lettered message
+	DeadLetterReasonMetadataKey = "dead_letter_reason"
+	// DeadLetterSourceMetadataKey is the metadata key carrying the subscriber a dead lettered message failed on
+	DeadLetterSourceMetadataKey = "dead_letter_source"
+	// DeadLetterAttemptsMetadataKey is the metadata key carrying how many deliveries of a dead lettered message failed
+	DeadLetterAttemptsMetadataKey = "dead_letter_attempts"
+)
+
+// PermanentError marks a handler error that redelivering the message can not fix, such as a malformed payload
+type PermanentError struct {
+	Err error
+}
+
+// NewPermanentError wraps err so subscribers stop redelivering the message that caused it
+func NewPermanentError(err error) error {
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// Permanent reports that the error will not clear up on redelivery
+func (e *PermanentError) Permanent() bool {
+	return true
+}
+
+// IsPermanentError reports whether err, or an error it wraps, is a PermanentError
+// or implements Permanent() bool returning true
+func IsPermanentError(err error) bool {
+	var permanent interface{ Permanent() bool }
+	return errors.As(err, &permanent) && permanent.Permanent()
+}
+
+// WithDeadLetterQueue moves messages to the publisher registered as reference instead of redelivering them
+// once their handler fails with a PermanentError, or fails on the maxDeliveries delivery of the message.
+// A maxDeliveries of 0 redelivers transiently failing messages indefinitely.
+// Dead lettered messages keep their metadata, with the failure recorded under the DeadLetter metadata keys.
+// Without this option messages failing permanently are logged and dropped as redelivering them can not succeed.
+func WithDeadLetterQueue(reference string, maxDeliveries int) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.deadLetter = reference
+		opts.maxDeliveries = maxDeliveries
+	}
+}
+
+// deadLetter takes a message whose handler failed off the queue if it should not be redelivered,
+// it reports whether that happened in which case the message is to be acknowledged
+func (s *subscriber) deadLetter(ctx context.Context, options *subscriberOptions, msg *pubsub.Message,
+	metadata map[string]string, attempt int, handleErr error) bool {
+
+	permanent := IsPermanentError(handleErr)
+	exhausted := options.maxDeliveries > 0 && attempt >= options.maxDeliveries
+	if !permanent && !exhausted {
+		return false
+	}
+
+	service := FromContext(ctx)
+	logger := s.logger.WithError(handleErr).WithField("attempts", attempt)
+
+	if options.deadLetter == "" {
+		logger.Error(" dropping message failing permanently")
+		return true
+	}
+
+	deadMetadata := make(map[string]string, len(metadata)+3)
+	for k, v := range metadata {
+		deadMetadata[k] = v
+	}
+	deadMetadata[DeadLetterReasonMetadataKey] = handleErr.Error()
+	deadMetadata[DeadLetterSourceMetadataKey] = s.reference
+	deadMetadata[DeadLetterAttemptsMetadataKey] = strconv.Itoa(attempt)
+
+	err := service.publish(ctx, options.deadLetter, msg.Body, deadMetadata)
+	if err != nil {
+		logger.WithField("dead_letter", options.deadLetter).WithError(err).Error(" could not dead letter message")
+		return false
+	}
+
+	logger.WithField("dead_letter", options.deadLetter).Warn(" message moved to the dead letter queue")
+	return true
+}
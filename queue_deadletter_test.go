@@ -0,0 +1,113 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type failingHandler struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (h *failingHandler) Handle(_ context.Context, _ map[string]string, _ []byte) error {
+	h.calls.Add(1)
+	return h.err
+}
+
+type deadLetterHandler struct {
+	metadata chan map[string]string
+}
+
+func (h *deadLetterHandler) Handle(_ context.Context, metadata map[string]string, _ []byte) error {
+	h.metadata <- metadata
+	return nil
+}
+
+func TestIsPermanentError(t *testing.T) {
+
+	permanent := frame.NewPermanentError(errors.New("malformed payload"))
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "permanent", err: permanent, want: true},
+		{name: "wrapped permanent", err: fmt.Errorf("handling order : %w", permanent), want: true},
+		{name: "transient", err: errors.New("connection refused"), want: false},
+		{name: "nil", err: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frame.IsPermanentError(tt.err); got != tt.want {
+				t.Errorf("IsPermanentError() = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_DeadLetterQueue(t *testing.T) {
+
+	tests := []struct {
+		name         string
+		err          error
+		wantAttempts int32
+	}{
+		{name: "permanent error skips retries", err: frame.NewPermanentError(errors.New("malformed payload")), wantAttempts: 1},
+		{name: "transient error retries up to the limit", err: errors.New("database unavailable"), wantAttempts: 3},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			queueURL := fmt.Sprintf("mem://topic-dead-letter-source-%d", i)
+			deadLetterURL := fmt.Sprintf("mem://topic-dead-letter-%d", i)
+
+			handler := &failingHandler{err: tt.err}
+			deadLetters := &deadLetterHandler{metadata: make(chan map[string]string, 1)}
+
+			ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+				frame.RegisterPublisher("orders", queueURL),
+				frame.RegisterPublisher("orders-dead", deadLetterURL),
+				frame.RegisterSubscriber("orders", queueURL, 1, handler, frame.WithDeadLetterQueue("orders-dead", 3)),
+				frame.RegisterSubscriber("orders-dead", deadLetterURL, 1, deadLetters))
+			defer srv.Stop(ctx)
+
+			err := srv.Run(ctx, "")
+			if err != nil {
+				t.Fatalf("could not run service : %s", err)
+			}
+
+			err = srv.Publish(ctx, "orders", []byte(`{"id":`))
+			if err != nil {
+				t.Fatalf("could not publish message : %s", err)
+			}
+
+			select {
+			case metadata := <-deadLetters.metadata:
+				if metadata[frame.DeadLetterReasonMetadataKey] != tt.err.Error() {
+					t.Errorf("dead letter reason %q, expected %q", metadata[frame.DeadLetterReasonMetadataKey], tt.err)
+				}
+				if metadata[frame.DeadLetterSourceMetadataKey] != "orders" {
+					t.Errorf("dead letter source %q, expected orders", metadata[frame.DeadLetterSourceMetadataKey])
+				}
+				if metadata[frame.DeadLetterAttemptsMetadataKey] != fmt.Sprint(tt.wantAttempts) {
+					t.Errorf("dead letter attempts %q, expected %d", metadata[frame.DeadLetterAttemptsMetadataKey], tt.wantAttempts)
+				}
+			case <-time.After(10 * time.Second):
+				t.Fatalf("message was not dead lettered")
+			}
+
+			if calls := handler.calls.Load(); calls != tt.wantAttempts {
+				t.Errorf("handler was called %d times, expected %d", calls, tt.wantAttempts)
+			}
+		})
+	}
+}
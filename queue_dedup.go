@@ -0,0 +1,122 @@
+package frame
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+const ctxKeyPublishRequestID = contextKey("publishRequestIDKey")
+
+// WithPublishRequestID sets the request id of messages published with the context,
+// subscribers using WithSubscriberDedup handle each request id only once
+func WithPublishRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, ctxKeyPublishRequestID, requestID)
+}
+
+func publishRequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(ctxKeyPublishRequestID).(string)
+	return requestID
+}
+
+// IdempotencyStore records the messages subscribers have processed
+type IdempotencyStore interface {
+	// Seen reports whether key was recorded and has not expired yet
+	Seen(ctx context.Context, key string) (bool, error)
+	// Record marks key as processed until ttl elapses
+	Record(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// WithSubscriberDedup skips messages whose request id the subscriber already processed successfully within ttl,
+// guarding against duplicate deliveries. Messages are identified by their MessageMeta request id,
+// messages without one are always handled. Concurrent deliveries of the same message may still both be handled.
+func WithSubscriberDedup(store IdempotencyStore, ttl time.Duration) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.dedupStore = store
+		opts.dedupTTL = ttl
+	}
+}
+
+// dedupKey is the key a message is recorded under, it is empty when the message can not be deduplicated
+func (s *subscriber) dedupKey(options *subscriberOptions, meta *MessageMeta) string {
+	if options.dedupStore == nil || meta.RequestID() == "" {
+		return ""
+	}
+	return s.reference + ":" + meta.RequestID()
+}
+
+// ProcessedMessage is the record of a message processed by a subscriber, kept by the datastore IdempotencyStore.
+// Include it in the models migrated for services using NewDatastoreIdempotencyStore.
+type ProcessedMessage struct {
+	ID        string    `gorm:"type:varchar(255);primary_key"`
+	ExpiresAt time.Time `gorm:"index"`
+}
+
+type datastoreIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewDatastoreIdempotencyStore creates an IdempotencyStore keeping processed messages in the ProcessedMessage table
+// of db, shared by every instance of the service. Expired records are ignored and removed by PurgeExpiredMessages.
+func NewDatastoreIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return &datastoreIdempotencyStore{db: db}
+}
+
+func (ds *datastoreIdempotencyStore) Seen(ctx context.Context, key string) (bool, error) {
+	var count int64
+	err := ds.db.WithContext(ctx).Model(&ProcessedMessage{}).
+		Where("id = ? AND expires_at > ?", key, time.Now()).Count(&count).Error
+	return count > 0, err
+}
+
+func (ds *datastoreIdempotencyStore) Record(ctx context.Context, key string, ttl time.Duration) error {
+	return ds.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoUpdates: clause.AssignmentColumns([]string{"expires_at"})}).
+		Create(&ProcessedMessage{ID: key, ExpiresAt: time.Now().Add(ttl)}).Error
+}
+
+// PurgeExpiredMessages removes the processed message records that have expired, returning how many were removed
+func PurgeExpiredMessages(ctx context.Context, db *gorm.DB) (int64, error) {
+	result := db.WithContext(ctx).Where("expires_at <= ?", time.Now()).Delete(&ProcessedMessage{})
+	return result.RowsAffected, result.Error
+}
+
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	expires map[string]time.Time
+}
+
+// NewMemoryIdempotencyStore creates an IdempotencyStore local to the process,
+// suitable for single instance services and tests
+func NewMemoryIdempotencyStore() IdempotencyStore {
+	return &memoryIdempotencyStore{expires: map[string]time.Time{}}
+}
+
+func (ms *memoryIdempotencyStore) Seen(_ context.Context, key string) (bool, error) {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	expiresAt, ok := ms.expires[key]
+	if ok && !time.Now().Before(expiresAt) {
+		delete(ms.expires, key)
+		return false, nil
+	}
+	return ok, nil
+}
+
+func (ms *memoryIdempotencyStore) Record(_ context.Context, key string, ttl time.Duration) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+
+	now := time.Now()
+	for k, expiresAt := range ms.expires {
+		if !now.Before(expiresAt) {
+			delete(ms.expires, k)
+		}
+	}
+
+	ms.expires[key] = now.Add(ttl)
+	return nil
+}
@@ -0,0 +1,88 @@
+package frame
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"net/url"
+	"strings"
+)
+
+// ErrNotJetStreamQueue is returned by operations that require the queue to be backed by a jetstream stream
+var ErrNotJetStreamQueue = NewError(ErrorCodeInvalidArgument, "queue is not a jetstream queue")
+
+// jetStreamTarget is a direct jetstream connection to the stream backing a queue
+type jetStreamTarget struct {
+	conn    *nats.Conn
+	js      jetstream.JetStream
+	stream  string
+	subject string
+	durable string
+}
+
+func (t *jetStreamTarget) close() {
+	t.conn.Close()
+}
+
+// openJetStream connects to the jetstream stream backing the queue reference
+func (s *Service) openJetStream(reference string) (*jetStreamTarget, error) {
+
+	queueURL, err := s.queueURL(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "nats" || !u.Query().Has("jetstream") {
+		return nil, fmt.Errorf("%w : %s", ErrNotJetStreamQueue, reference)
+	}
+
+	streamName := u.Query().Get("stream_name")
+	if streamName == "" {
+		return nil, errors.New("accessing a jetstream queue requires the stream_name to be set in its url")
+	}
+
+	subject := u.Query().Get("subject")
+	if path := strings.Trim(u.Path, "/"); path != "" {
+		subject = strings.Trim(strings.Join([]string{subject, path}, "."), ".")
+	}
+
+	connURL := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	conn, err := nats.Connect(connURL.String())
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &jetStreamTarget{
+		conn:    conn,
+		js:      js,
+		stream:  streamName,
+		subject: subject,
+		durable: u.Query().Get("consumer_durable"),
+	}, nil
+}
+
+// queueURL obtains the url a subscriber or publisher was registered with,
+// subscriber urls include the driver parameters derived from their options
+func (s *Service) queueURL(reference string) (string, error) {
+	if sub, ok := s.queue.subscriptionQueueMap.Load(reference); ok {
+		return subscriberURL(sub.(*subscriber).url, sub.(*subscriber).options...)
+	}
+
+	pub, err := s.queue.getPublisherByReference(reference)
+	if err != nil {
+		return "", err
+	}
+	return pub.url, nil
+}
@@ -0,0 +1,104 @@
+package frame
+
+import (
+	"context"
+	"github.com/nats-io/nats.go/jetstream"
+	"gocloud.dev/pubsub"
+	"time"
+)
+
+// RequestIDMetadataKey is the message metadata key carrying the id publishers assign to a request
+const RequestIDMetadataKey = "request_id"
+
+// MessageMeta describes a received message, sparing handlers from knowing the metadata keys and driver details
+type MessageMeta struct {
+	subject       string
+	requestID     string
+	deliveryCount int
+	timestamp     time.Time
+	raw           map[string]string
+}
+
+// Subject is the subject the message was delivered on, it is empty where the driver does not expose one
+func (m *MessageMeta) Subject() string {
+	return m.subject
+}
+
+// RequestID is the id the publisher assigned to the message, taken from the request_id metadata
+// or for jetstream the message id used for deduplication
+func (m *MessageMeta) RequestID() string {
+	return m.requestID
+}
+
+// DeliveryCount is how many times the message has been delivered, it is above 1 for redeliveries
+func (m *MessageMeta) DeliveryCount() int {
+	return m.deliveryCount
+}
+
+// Timestamp is when the message was stored by jetstream, for other drivers it is when the message was received
+func (m *MessageMeta) Timestamp() time.Time {
+	return m.timestamp
+}
+
+// Raw is the metadata map handed to SubscribeWorker handlers
+func (m *MessageMeta) Raw() map[string]string {
+	return m.raw
+}
+
+// MessageMetaSubscribeWorker is implemented by subscriber handlers that prefer the typed MessageMeta
+// to the metadata map, subscribers call HandleMessage instead of Handle on handlers implementing it.
+type MessageMetaSubscribeWorker interface {
+	SubscribeWorker
+	HandleMessage(ctx context.Context, meta *MessageMeta, message []byte) error
+}
+
+// MessageMetaHandler adapts a function receiving the typed MessageMeta into a handler for RegisterSubscriber
+func MessageMetaHandler(handle func(ctx context.Context, meta *MessageMeta, message []byte) error) MessageMetaSubscribeWorker {
+	return messageMetaHandler(handle)
+}
+
+type messageMetaHandler func(ctx context.Context, meta *MessageMeta, message []byte) error
+
+func (h messageMetaHandler) Handle(ctx context.Context, metadata map[string]string, message []byte) error {
+	return h(ctx, newMessageMeta(metadata, 1, time.Now()), message)
+}
+
+func (h messageMetaHandler) HandleMessage(ctx context.Context, meta *MessageMeta, message []byte) error {
+	return h(ctx, meta, message)
+}
+
+// newMessageMeta creates the meta of a message from its metadata
+func newMessageMeta(metadata map[string]string, deliveryCount int, receivedAt time.Time) *MessageMeta {
+	return &MessageMeta{
+		subject:       metadata[SubjectMetadataKey],
+		requestID:     metadata[RequestIDMetadataKey],
+		deliveryCount: deliveryCount,
+		timestamp:     receivedAt,
+		raw:           metadata,
+	}
+}
+
+// receivedMessageMeta creates the meta of a received message, preferring what jetstream records about it
+func receivedMessageMeta(msg *pubsub.Message, metadata map[string]string, deliveryCount int, receivedAt time.Time) *MessageMeta {
+	meta := newMessageMeta(metadata, deliveryCount, receivedAt)
+
+	var jsMsg jetstream.Msg
+	if msg.As(&jsMsg) && jsMsg != nil {
+		meta.fromJetstream(jsMsg)
+	}
+	return meta
+}
+
+func (m *MessageMeta) fromJetstream(jsMsg jetstream.Msg) {
+	m.subject = jsMsg.Subject()
+
+	if m.requestID == "" {
+		m.requestID = jsMsg.Headers().Get(jetstream.MsgIDHeader)
+	}
+
+	metadata, err := jsMsg.Metadata()
+	if err == nil {
+		m.deliveryCount = int(metadata.NumDelivered)
+		m.timestamp = metadata.Timestamp
+	}
+}
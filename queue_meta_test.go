@@ -0,0 +1,123 @@
+package frame
+
+import (
+	"context"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"testing"
+	"time"
+)
+
+// fakeJetstreamMsg is a delivered jetstream message, only the methods read for the message meta are implemented
+type fakeJetstreamMsg struct {
+	jetstream.Msg
+	subject  string
+	headers  nats.Header
+	metadata *jetstream.MsgMetadata
+}
+
+func (m *fakeJetstreamMsg) Subject() string {
+	return m.subject
+}
+
+func (m *fakeJetstreamMsg) Headers() nats.Header {
+	return m.headers
+}
+
+func (m *fakeJetstreamMsg) Metadata() (*jetstream.MsgMetadata, error) {
+	return m.metadata, nil
+}
+
+func TestMessageMeta_Jetstream(t *testing.T) {
+
+	storedAt := time.Date(2024, 3, 1, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		metadata      map[string]string
+		headers       nats.Header
+		wantRequestID string
+	}{
+		{
+			name:          "request id from metadata",
+			metadata:      map[string]string{RequestIDMetadataKey: "req-1", "tenant_id": "t1"},
+			headers:       nats.Header{jetstream.MsgIDHeader: []string{"msg-1"}},
+			wantRequestID: "req-1",
+		},
+		{
+			name:          "request id from jetstream message id",
+			metadata:      map[string]string{"tenant_id": "t1"},
+			headers:       nats.Header{jetstream.MsgIDHeader: []string{"msg-1"}},
+			wantRequestID: "msg-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jsMsg := &fakeJetstreamMsg{
+				subject:  "orders.created.eu",
+				headers:  tt.headers,
+				metadata: &jetstream.MsgMetadata{NumDelivered: 3, Timestamp: storedAt},
+			}
+
+			meta := newMessageMeta(tt.metadata, 1, time.Now())
+			meta.fromJetstream(jsMsg)
+
+			if meta.Subject() != "orders.created.eu" {
+				t.Errorf("subject %q does not match the delivery subject", meta.Subject())
+			}
+			if meta.RequestID() != tt.wantRequestID {
+				t.Errorf("request id %q, expected %q", meta.RequestID(), tt.wantRequestID)
+			}
+			if meta.DeliveryCount() != 3 {
+				t.Errorf("delivery count %d, expected 3", meta.DeliveryCount())
+			}
+			if !meta.Timestamp().Equal(storedAt) {
+				t.Errorf("timestamp %s, expected %s", meta.Timestamp(), storedAt)
+			}
+			if meta.Raw()["tenant_id"] != "t1" {
+				t.Errorf("raw metadata %v is missing the tenant", meta.Raw())
+			}
+		})
+	}
+}
+
+func TestService_MessageMetaHandler(t *testing.T) {
+
+	received := make(chan *MessageMeta, 1)
+	handler := MessageMetaHandler(func(_ context.Context, meta *MessageMeta, _ []byte) error {
+		received <- meta
+		return nil
+	})
+
+	queueURL := "mem://topic-message-meta"
+	ctx, srv := NewService("Test Srv", NoopDriver(),
+		RegisterPublisher("meta", queueURL),
+		RegisterSubscriber("meta", queueURL, 1, handler))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	err = srv.publish(ctx, "meta", []byte("hello"), map[string]string{RequestIDMetadataKey: "req-42"})
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	select {
+	case meta := <-received:
+		if meta.RequestID() != "req-42" {
+			t.Errorf("request id %q, expected req-42", meta.RequestID())
+		}
+		if meta.DeliveryCount() != 1 {
+			t.Errorf("delivery count %d, expected 1", meta.DeliveryCount())
+		}
+		if meta.Timestamp().IsZero() {
+			t.Errorf("timestamp should be set to when the message was received")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("message was not handled")
+	}
+}
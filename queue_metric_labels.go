@@ -0,0 +1,104 @@
+package frame
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"slices"
+	"sync"
+)
+
+const (
+	defaultQueueMetricLabelValues = 20
+	// QueueMetricLabelOther is recorded in place of metadata values beyond the bounds of a label
+	QueueMetricLabelOther = "other"
+)
+
+// QueueMetricLabels adds labels to the metrics recorded for a queue reference
+type QueueMetricLabels struct {
+	// Static labels recorded on every measurement
+	Static map[string]string
+	// Metadata maps label names to the message metadata keys their values are read from, for example
+	// {"tenant": "tenant_id"}. Messages without the key are recorded with an empty value.
+	Metadata map[string]string
+	// AllowedValues bounds the values of metadata labels, values outside the list are recorded as QueueMetricLabelOther.
+	// Labels without an allow list keep the first MaxValues distinct values they see.
+	AllowedValues map[string][]string
+	// MaxValues bounds the distinct values of metadata labels without an allow list, by default 20
+	MaxValues int
+}
+
+// WithQueueMetricLabels Option to attach labels to the metrics of the publisher and subscriber registered as reference.
+// Labels read from message metadata are bounded so that metric cardinality stays under control,
+// batch subscribers only record the static labels as a batch spans many messages.
+func WithQueueMetricLabels(reference string, labels QueueMetricLabels) Option {
+	return func(s *Service) {
+		if labels.MaxValues <= 0 {
+			labels.MaxValues = defaultQueueMetricLabelValues
+		}
+		if s.queueMetricLabels == nil {
+			s.queueMetricLabels = map[string]*queueMetricLabeler{}
+		}
+		s.queueMetricLabels[reference] = &queueMetricLabeler{labels: labels, seen: map[string]map[string]struct{}{}}
+	}
+}
+
+type queueMetricLabeler struct {
+	labels QueueMetricLabels
+
+	mu   sync.Mutex
+	seen map[string]map[string]struct{}
+}
+
+// queueMetricAttributes are the attributes recorded for a message of reference with the supplied metadata
+func (s *Service) queueMetricAttributes(reference string, metadata map[string]string) metric.MeasurementOption {
+
+	labeler, ok := s.queueMetricLabels[reference]
+	if !ok {
+		return queueReferenceAttribute(reference)
+	}
+
+	attributes := make([]attribute.KeyValue, 0, 1+len(labeler.labels.Static)+len(labeler.labels.Metadata))
+	attributes = append(attributes, attribute.String("reference", reference))
+	for name, value := range labeler.labels.Static {
+		attributes = append(attributes, attribute.String(name, value))
+	}
+	for name, key := range labeler.labels.Metadata {
+		attributes = append(attributes, attribute.String(name, labeler.boundedValue(name, metadata[key])))
+	}
+
+	return metric.WithAttributes(attributes...)
+}
+
+// boundedValue returns value if the label may record it, otherwise QueueMetricLabelOther
+func (l *queueMetricLabeler) boundedValue(name string, value string) string {
+
+	if value == "" {
+		return value
+	}
+
+	if allowed, ok := l.labels.AllowedValues[name]; ok {
+		if slices.Contains(allowed, value) {
+			return value
+		}
+		return QueueMetricLabelOther
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	values := l.seen[name]
+	if values == nil {
+		values = map[string]struct{}{}
+		l.seen[name] = values
+	}
+
+	if _, ok := values[value]; ok {
+		return value
+	}
+	if len(values) >= l.labels.MaxValues {
+		return QueueMetricLabelOther
+	}
+
+	values[value] = struct{}{}
+	return value
+}
@@ -0,0 +1,155 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"sync"
+	"time"
+)
+
+const instrumentationName = "github.com/pitabwire/frame"
+
+// MeterProvider Option that specifies the meter provider used to record service metrics.
+// By default the globally registered otel meter provider is used.
+func MeterProvider(provider metric.MeterProvider) Option {
+	return func(s *Service) {
+		s.meterProvider = provider
+	}
+}
+
+type queueMetrics struct {
+	published       metric.Int64Counter
+	consumed        metric.Int64Counter
+	handlerErrors   metric.Int64Counter
+	handlerDuration metric.Float64Histogram
+
+	// jetStreamTargets holds the connections used to observe the pending messages of jetstream subscribers
+	jetStreamTargets sync.Map
+}
+
+// queueMetrics lazily creates the instruments used to record queue throughput,
+// the consumer pending gauge is observed from the jetstream server on every collection.
+func (s *Service) queueMetrics() *queueMetrics {
+
+	s.queueMetricsOnce.Do(func() {
+
+		provider := s.meterProvider
+		if provider == nil {
+			provider = otel.GetMeterProvider()
+		}
+
+		meter := provider.Meter(instrumentationName)
+
+		qm := &queueMetrics{}
+		qm.published, _ = meter.Int64Counter("frame.queue.published",
+			metric.WithDescription("Number of messages published per queue reference"))
+		qm.consumed, _ = meter.Int64Counter("frame.queue.consumed",
+			metric.WithDescription("Number of messages received by subscribers per queue reference"))
+		qm.handlerErrors, _ = meter.Int64Counter("frame.queue.handler.errors",
+			metric.WithDescription("Number of messages subscribers failed to handle per queue reference"))
+		qm.handlerDuration, _ = meter.Float64Histogram("frame.queue.handler.duration",
+			metric.WithDescription("Time taken by subscribers to handle a message"),
+			metric.WithUnit("s"))
+
+		_, _ = meter.Int64ObservableGauge("frame.queue.consumer.pending",
+			metric.WithDescription("Number of messages waiting to be delivered to jetstream subscribers"),
+			metric.WithInt64Callback(func(ctx context.Context, observer metric.Int64Observer) error {
+				s.observeConsumerPending(ctx, qm, observer)
+				return nil
+			}))
+
+		s.queueMetricsInstance = qm
+	})
+
+	return s.queueMetricsInstance
+}
+
+func queueReferenceAttribute(reference string) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String("reference", reference))
+}
+
+func (qm *queueMetrics) recordPublish(ctx context.Context, attributes metric.MeasurementOption) {
+	qm.published.Add(ctx, 1, attributes)
+}
+
+func (qm *queueMetrics) recordHandled(ctx context.Context, attributes metric.MeasurementOption, startedAt time.Time, err error) {
+	qm.consumed.Add(ctx, 1, attributes)
+	qm.handlerDuration.Record(ctx, time.Since(startedAt).Seconds(), attributes)
+	if err != nil {
+		qm.handlerErrors.Add(ctx, 1, attributes)
+	}
+}
+
+// observeConsumerPending records how many messages the durable consumer of each jetstream subscriber has yet to deliver
+func (s *Service) observeConsumerPending(ctx context.Context, qm *queueMetrics, observer metric.Int64Observer) {
+
+	if s.queue == nil {
+		return
+	}
+
+	s.queue.subscriptionQueueMap.Range(func(key, _ any) bool {
+		reference := key.(string)
+
+		target, err := qm.jetStreamTarget(s, reference)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("subscriber", reference).Debug("could not observe pending messages")
+			return true
+		}
+		if target == nil {
+			return true
+		}
+
+		consumer, err := target.js.Consumer(ctx, target.stream, target.durable)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("subscriber", reference).Debug("could not observe pending messages")
+			return true
+		}
+
+		info, err := consumer.Info(ctx)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("subscriber", reference).Debug("could not observe pending messages")
+			return true
+		}
+
+		observer.Observe(int64(info.NumPending), s.queueMetricAttributes(reference, nil))
+		return true
+	})
+}
+
+// jetStreamTarget obtains the connection to the durable consumer of the subscriber reference,
+// it is nil for subscribers that do not read from a durable jetstream consumer.
+func (qm *queueMetrics) jetStreamTarget(s *Service, reference string) (*jetStreamTarget, error) {
+
+	if target, ok := qm.jetStreamTargets.Load(reference); ok {
+		return target.(*jetStreamTarget), nil
+	}
+
+	target, err := s.openJetStream(reference)
+	if err != nil {
+		if errors.Is(err, ErrNotJetStreamQueue) {
+			qm.jetStreamTargets.Store(reference, (*jetStreamTarget)(nil))
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	if target.durable == "" {
+		target.close()
+		qm.jetStreamTargets.Store(reference, (*jetStreamTarget)(nil))
+		return nil, nil
+	}
+
+	existing, loaded := qm.jetStreamTargets.LoadOrStore(reference, target)
+	if loaded {
+		target.close()
+		return existing.(*jetStreamTarget), nil
+	}
+
+	s.AddCleanupMethod(func(_ context.Context) {
+		target.close()
+	})
+	return target, nil
+}
@@ -0,0 +1,276 @@
+package frame_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/embedded"
+	"go.opentelemetry.io/otel/metric/noop"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingMeterProvider keeps the totals of counters per queue reference or http route for assertions in tests.
+type recordingMeterProvider struct {
+	noop.MeterProvider
+	mu         sync.Mutex
+	counters   map[string]map[string]int64
+	attributes map[string][]attribute.Set
+	gauges     map[string][]metric.Int64Callback
+}
+
+func newRecordingMeterProvider() *recordingMeterProvider {
+	return &recordingMeterProvider{counters: map[string]map[string]int64{}, attributes: map[string][]attribute.Set{},
+		gauges: map[string][]metric.Int64Callback{}}
+}
+
+func (rp *recordingMeterProvider) Meter(_ string, _ ...metric.MeterOption) metric.Meter {
+	return &recordingMeter{provider: rp}
+}
+
+func (rp *recordingMeterProvider) counter(name, reference string) int64 {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return rp.counters[name][reference]
+}
+
+// attributeSets returns the attributes of every measurement added to the counter
+func (rp *recordingMeterProvider) attributeSets(name string) []attribute.Set {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return slices.Clone(rp.attributes[name])
+}
+
+// observe runs the callbacks of the observable gauge and returns the values observed per queue reference
+func (rp *recordingMeterProvider) observe(ctx context.Context, name string) map[string]int64 {
+	rp.mu.Lock()
+	callbacks := slices.Clone(rp.gauges[name])
+	rp.mu.Unlock()
+
+	observer := &recordingObserver{values: map[string]int64{}}
+	for _, callback := range callbacks {
+		_ = callback(ctx, observer)
+	}
+	return observer.values
+}
+
+type recordingObserver struct {
+	embedded.Int64Observer
+	values map[string]int64
+}
+
+func (ro *recordingObserver) Observe(value int64, opts ...metric.ObserveOption) {
+	attributes := metric.NewObserveConfig(opts).Attributes()
+	reference, _ := attributes.Value("reference")
+	ro.values[reference.AsString()] = value
+}
+
+type recordingMeter struct {
+	noop.Meter
+	provider *recordingMeterProvider
+}
+
+func (rm *recordingMeter) Int64Counter(name string, _ ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	return &recordingCounter{name: name, provider: rm.provider}, nil
+}
+
+func (rm *recordingMeter) Int64ObservableGauge(name string, opts ...metric.Int64ObservableGaugeOption) (metric.Int64ObservableGauge, error) {
+	config := metric.NewInt64ObservableGaugeConfig(opts...)
+
+	rm.provider.mu.Lock()
+	defer rm.provider.mu.Unlock()
+	rm.provider.gauges[name] = append(rm.provider.gauges[name], config.Callbacks()...)
+	return noop.Int64ObservableGauge{}, nil
+}
+
+type recordingCounter struct {
+	noop.Int64Counter
+	name     string
+	provider *recordingMeterProvider
+}
+
+func (rc *recordingCounter) Add(_ context.Context, incr int64, opts ...metric.AddOption) {
+	config := metric.NewAddConfig(opts)
+	attributes := config.Attributes()
+	reference, ok := attributes.Value("reference")
+	if !ok {
+		reference, _ = attributes.Value("route")
+	}
+
+	rc.provider.mu.Lock()
+	defer rc.provider.mu.Unlock()
+
+	if rc.provider.counters[rc.name] == nil {
+		rc.provider.counters[rc.name] = map[string]int64{}
+	}
+	rc.provider.counters[rc.name][reference.AsString()] += incr
+	rc.provider.attributes[rc.name] = append(rc.provider.attributes[rc.name], attributes)
+}
+
+func TestService_QueueMetrics(t *testing.T) {
+
+	reference := "test-queue-metrics"
+	meterProvider := newRecordingMeterProvider()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.MeterProvider(meterProvider),
+		frame.RegisterPublisher(reference, "mem://topicMetrics"),
+		frame.RegisterSubscriber(reference, "mem://topicMetrics", 1, &messageHandler{}),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("we couldn't instantiate queue  %s", err)
+		return
+	}
+
+	for i := range 3 {
+		err = srv.Publish(ctx, reference, []byte(fmt.Sprintf("metrics message %d", i)))
+		if err != nil {
+			t.Errorf("We could not publish to topic that was registered %s", err)
+			return
+		}
+	}
+
+	if published := meterProvider.counter("frame.queue.published", reference); published != 3 {
+		t.Errorf("published counter is %d not 3", published)
+	}
+
+	consumed := int64(0)
+	for range 50 {
+		consumed = meterProvider.counter("frame.queue.consumed", reference)
+		if consumed == 3 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if consumed != 3 {
+		t.Errorf("consumed counter is %d not 3", consumed)
+	}
+
+	if handlerErrors := meterProvider.counter("frame.queue.handler.errors", reference); handlerErrors != 0 {
+		t.Errorf("handler errors counter is %d not 0", handlerErrors)
+	}
+}
+
+func TestService_QueueMetricLabels(t *testing.T) {
+
+	reference := "test-queue-metric-labels"
+	queueURL := "mem://topicMetricLabels"
+	meterProvider := newRecordingMeterProvider()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.MeterProvider(meterProvider),
+		frame.RegisterPublisher(reference, queueURL),
+		frame.RegisterSubscriber(reference, queueURL, 1, &messageHandler{}),
+		frame.WithQueueMetricLabels(reference, frame.QueueMetricLabels{
+			Static:        map[string]string{"team": "payments"},
+			Metadata:      map[string]string{"tenant": "tenant_id"},
+			AllowedValues: map[string][]string{"tenant": {"tenantA"}},
+		}),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	for _, tenantID := range []string{"tenantA", "tenantB"} {
+		claims := frame.AuthenticationClaims{TenantID: tenantID}
+		err = srv.Publish(claims.ClaimsToContext(ctx), reference, []byte("labelled message"))
+		if err != nil {
+			t.Fatalf("could not publish message : %s", err)
+		}
+	}
+
+	for range 50 {
+		if meterProvider.counter("frame.queue.consumed", reference) == 2 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	for _, name := range []string{"frame.queue.published", "frame.queue.consumed"} {
+		tenants := map[string]int{}
+		for _, attributes := range meterProvider.attributeSets(name) {
+			if team, _ := attributes.Value("team"); team.AsString() != "payments" {
+				t.Errorf("%s is missing the static team label : %v", name, attributes.ToSlice())
+			}
+			tenant, _ := attributes.Value("tenant")
+			tenants[tenant.AsString()]++
+		}
+
+		want := map[string]int{"tenantA": 1, frame.QueueMetricLabelOther: 1}
+		for tenant, count := range want {
+			if tenants[tenant] != count {
+				t.Errorf("%s recorded tenants %v, expected %v", name, tenants, want)
+				break
+			}
+		}
+	}
+}
+
+func TestService_QueueConsumerPendingMetric(t *testing.T) {
+
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+	suffix := time.Now().UnixNano()
+	queueURL := fmt.Sprintf("%s?jetstream=true&stream_name=frame_pending_%d&subject=frame.pending.%d", natsURL, suffix, suffix)
+	meterProvider := newRecordingMeterProvider()
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.MeterProvider(meterProvider),
+		frame.RegisterPublisher("pending", queueURL),
+		frame.RegisterSubscriber("pending-sub", queueURL, 1, &messageHandler{}, frame.WithDurableConsumer("pending_sub")),
+		frame.RegisterSubscriber("pending-mem", "mem://topicPending", 1, &messageHandler{}),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	err = srv.PauseSubscriber(ctx, "pending-sub")
+	if err != nil {
+		t.Fatalf("could not pause subscriber : %s", err)
+	}
+
+	for i := range 3 {
+		err = srv.Publish(ctx, "pending", []byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Fatalf("could not publish message : %s", err)
+		}
+	}
+
+	pending := meterProvider.observe(ctx, "frame.queue.consumer.pending")
+	if pending["pending-sub"] != 3 {
+		t.Errorf("pending gauge is %d not 3 while the subscriber is paused", pending["pending-sub"])
+	}
+	if _, ok := pending["pending-mem"]; ok {
+		t.Errorf("pending gauge should only be observed for jetstream subscribers")
+	}
+
+	err = srv.ResumeSubscriber(ctx, "pending-sub")
+	if err != nil {
+		t.Fatalf("could not resume subscriber : %s", err)
+	}
+
+	for range 50 {
+		pending = meterProvider.observe(ctx, "frame.queue.consumer.pending")
+		if pending["pending-sub"] == 0 {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	if pending["pending-sub"] != 0 {
+		t.Errorf("pending gauge is %d once the subscriber resumed, expected the messages to be delivered", pending["pending-sub"])
+	}
+}
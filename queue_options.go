@@ -0,0 +1,109 @@
+package frame
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// SubscriberOption configures how a subscriber consumes messages from the underlying queue
+type SubscriberOption func(opts *subscriberOptions)
+
+type subscriberOptions struct {
+	ackExplicit   bool
+	ackWait       time.Duration
+	maxAckPending int
+	durable       string
+	skipEmpty     bool
+	deadLetter    string
+	maxDeliveries int
+	dedupStore    IdempotencyStore
+	dedupTTL      time.Duration
+
+	ackBatchSize     int
+	ackBatchInterval time.Duration
+}
+
+func newSubscriberOptions(opts ...SubscriberOption) *subscriberOptions {
+	options := &subscriberOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// WithAckExplicit requires every message to be acknowledged explicitly by the subscriber.
+// For nats this switches the subscription to a JetStream consumer, which always uses the explicit ack policy.
+func WithAckExplicit() SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.ackExplicit = true
+	}
+}
+
+// WithAckWait sets how long the server waits for an acknowledgement before redelivering a message
+func WithAckWait(d time.Duration) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.ackWait = d
+	}
+}
+
+// WithMaxAckPending limits the number of delivered messages that may be awaiting acknowledgement
+func WithMaxAckPending(n int) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.maxAckPending = n
+	}
+}
+
+// WithDurableConsumer names the consumer so that its delivery state survives restarts
+func WithDurableConsumer(name string) SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.durable = name
+	}
+}
+
+// WithSkipEmptyMessages acknowledges and drops messages with an empty body instead of handing them to the handler
+func WithSkipEmptyMessages() SubscriberOption {
+	return func(opts *subscriberOptions) {
+		opts.skipEmpty = true
+	}
+}
+
+// subscriberURL translates the structured subscriber options into the driver url.
+// Options only apply to nats urls, other drivers receive the url unchanged.
+// The nats driver takes no max deliver or deliver policy settings, it creates its consumers with the server defaults
+// for those, so durable consumers needing other values have to be configured on the stream directly.
+func subscriberURL(queueURL string, opts ...SubscriberOption) (string, error) {
+
+	if len(opts) == 0 {
+		return queueURL, nil
+	}
+
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid subscriber url %s : %w", queueURL, err)
+	}
+
+	if u.Scheme != "nats" {
+		return queueURL, nil
+	}
+
+	options := newSubscriberOptions(opts...)
+
+	query := u.Query()
+	if options.ackExplicit {
+		query.Set("jetstream", "true")
+	}
+	if options.ackWait > 0 {
+		query.Set("consumer_ack_wait_timeout_ms", strconv.FormatInt(options.ackWait.Milliseconds(), 10))
+	}
+	if options.maxAckPending > 0 {
+		query.Set("consumer_max_ack_pending", strconv.Itoa(options.maxAckPending))
+	}
+	if options.durable != "" {
+		query.Set("consumer_durable", options.durable)
+	}
+
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
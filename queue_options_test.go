@@ -0,0 +1,74 @@
+package frame
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestSubscriberURL(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		queueURL string
+		opts     []SubscriberOption
+		want     map[string]string
+	}{
+		{
+			name:     "No options",
+			queueURL: "nats://localhost:4222?subject=orders.>",
+			want:     map[string]string{"subject": "orders.>"},
+		},
+		{
+			name:     "JetStream consumer configuration",
+			queueURL: "nats://localhost:4222?subject=orders.>&stream_name=orders",
+			opts: []SubscriberOption{
+				WithAckExplicit(),
+				WithAckWait(30 * time.Second),
+				WithMaxAckPending(25),
+				WithDurableConsumer("orders-consumer"),
+			},
+			want: map[string]string{
+				"subject":                      "orders.>",
+				"stream_name":                  "orders",
+				"jetstream":                    "true",
+				"consumer_ack_wait_timeout_ms": "30000",
+				"consumer_max_ack_pending":     "25",
+				"consumer_durable":             "orders-consumer",
+			},
+		},
+		{
+			name:     "Non nats urls are untouched",
+			queueURL: "mem://topicA",
+			opts:     []SubscriberOption{WithAckExplicit(), WithAckWait(time.Second)},
+			want:     map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := subscriberURL(tt.queueURL, tt.opts...)
+			if err != nil {
+				t.Errorf("subscriberURL() error = %v", err)
+				return
+			}
+
+			u, err := url.Parse(got)
+			if err != nil {
+				t.Errorf("subscriberURL() produced invalid url %s : %v", got, err)
+				return
+			}
+
+			query := u.Query()
+			if len(query) != len(tt.want) {
+				t.Errorf("subscriberURL() = %s, want parameters %v", got, tt.want)
+			}
+
+			for k, v := range tt.want {
+				if query.Get(k) != v {
+					t.Errorf("subscriberURL() parameter %s = %s, want %s", k, query.Get(k), v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"github.com/rs/xid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sync"
+	"time"
+)
+
+const (
+	ctxKeyOutbox = contextKey("outboxKey")
+
+	outboxRelayBatchSize = 100
+)
+
+// OutboxMessage is a message published inside InTransaction, kept until the relay has sent it to its queue.
+// Include it in the models migrated for services using InTransaction.
+type OutboxMessage struct {
+	ID        string `gorm:"type:varchar(50);primary_key"`
+	Reference string `gorm:"type:varchar(255)"`
+	Payload   []byte
+	Metadata  datatypes.JSONMap
+	CreatedAt time.Time `gorm:"index"`
+}
+
+// transactionOutbox collects the messages published through the context of an InTransaction call
+type transactionOutbox struct {
+	tx  *gorm.DB
+	mu  sync.Mutex
+	ids []string
+}
+
+func outboxFromContext(ctx context.Context) *transactionOutbox {
+	outbox, _ := ctx.Value(ctxKeyOutbox).(*transactionOutbox)
+	return outbox
+}
+
+func (o *transactionOutbox) enqueue(ctx context.Context, reference string, payload []byte, metadata map[string]string) error {
+
+	props := datatypes.JSONMap{}
+	for k, v := range metadata {
+		props[k] = v
+	}
+
+	// The outbox is not tenant scoped, a new statement drops the tenancy conditions of the transaction
+	message := OutboxMessage{ID: xid.New().String(), Reference: reference, Payload: payload, Metadata: props}
+	err := o.tx.Session(&gorm.Session{NewDB: true, Context: ctx}).Create(&message).Error
+	if err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	o.ids = append(o.ids, message.ID)
+	o.mu.Unlock()
+	return nil
+}
+
+// InTransaction runs fn inside a transaction on the write connection, messages published with the context handed to fn
+// are written to the OutboxMessage table in the same transaction instead of being sent. They are relayed to their
+// queues once the transaction commits, and discarded with it when fn fails, so the entity writes and events of a
+// handler are committed together. Messages whose relay fails are retried by WithOutboxRelay.
+func (s *Service) InTransaction(ctx context.Context, fn func(ctx context.Context, tx *gorm.DB) error) error {
+	db := s.DB(ctx, false)
+	if db == nil {
+		return errors.New("no datastore is setup to run a transaction")
+	}
+
+	if outer := outboxFromContext(ctx); outer != nil {
+		// Nested calls join the enclosing transaction, whose commit relays the messages
+		return outer.tx.Transaction(func(tx *gorm.DB) error {
+			return fn(ctx, tx)
+		})
+	}
+
+	outbox := &transactionOutbox{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		outbox.tx = tx
+		return fn(context.WithValue(ctx, ctxKeyOutbox, outbox), tx)
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(outbox.ids) == 0 {
+		return nil
+	}
+
+	_, err = s.relayOutbox(ctx, outbox.ids)
+	if err != nil {
+		// The messages are committed, the outbox relay sends them later
+		s.L(ctx).WithError(err).Warn("could not relay outbox messages after commit")
+	}
+	return nil
+}
+
+// WithOutboxRelay Option to send the outbox messages left behind by InTransaction, for instance by a failed relay
+// or a crash after commit, every interval while the service runs
+func WithOutboxRelay(interval time.Duration) Option {
+	return func(s *Service) {
+		s.outboxRelayInterval = interval
+	}
+}
+
+// RelayOutbox sends pending outbox messages to their queues oldest first, returning how many were sent
+func (s *Service) RelayOutbox(ctx context.Context) (int, error) {
+	return s.relayOutbox(ctx, nil)
+}
+
+func (s *Service) runOutboxRelay(ctx context.Context) {
+	ticker := time.NewTicker(s.outboxRelayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := s.RelayOutbox(ctx)
+			if err != nil {
+				s.L(ctx).WithError(err).Warn("could not relay outbox messages")
+			}
+		}
+	}
+}
+
+// relayOutbox sends the pending outbox messages, limited to ids when supplied. Messages are locked while they are sent
+// so concurrent relays of other instances skip them, and removed once sent.
+func (s *Service) relayOutbox(ctx context.Context, ids []string) (int, error) {
+	// Messages of every tenant are relayed, the outbox has no tenancy columns to scope by
+	db := s.DB(SkipTenancyChecksOnClaims(ctx), false)
+	if db == nil {
+		return 0, errors.New("no datastore is setup to relay the outbox")
+	}
+
+	// Relayed messages are sent, never written back to an outbox
+	ctx = context.WithValue(ctx, ctxKeyOutbox, (*transactionOutbox)(nil))
+
+	sent := 0
+	var publishErr error
+	err := db.Transaction(func(tx *gorm.DB) error {
+
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Order("created_at").Limit(outboxRelayBatchSize)
+		if ids != nil {
+			query = query.Where("id IN ?", ids)
+		}
+
+		var messages []OutboxMessage
+		err := query.Find(&messages).Error
+		if err != nil {
+			return err
+		}
+
+		var sentIDs []string
+		for _, message := range messages {
+			metadata := make(map[string]string, len(message.Metadata))
+			for k, v := range message.Metadata {
+				if value, ok := v.(string); ok {
+					metadata[k] = value
+				}
+			}
+
+			publishErr = s.publish(ctx, message.Reference, message.Payload, metadata)
+			if publishErr != nil {
+				break
+			}
+			sentIDs = append(sentIDs, message.ID)
+		}
+
+		if len(sentIDs) > 0 {
+			err = tx.Where("id IN ?", sentIDs).Delete(&OutboxMessage{}).Error
+			if err != nil {
+				return err
+			}
+			sent = len(sentIDs)
+		}
+
+		// Sent messages are removed even when a later one fails, the failed ones stay for the next relay
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return sent, publishErr
+}
@@ -0,0 +1,158 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// ErrSubscriberNotFound is returned when no subscriber is registered for a queue reference
+var ErrSubscriberNotFound = NewError(ErrorCodeNotFound, "subscriber not found")
+
+// subscriberPause holds a subscriber back from receiving messages while it is paused
+type subscriberPause struct {
+	mu            sync.Mutex
+	paused        bool
+	resumed       chan struct{}
+	cancelReceive context.CancelFunc
+}
+
+func (s *Service) subscriberByReference(reference string) (*subscriber, error) {
+	if s.queue == nil {
+		return nil, fmt.Errorf("%s : %w", reference, ErrSubscriberNotFound)
+	}
+
+	sub, ok := s.queue.subscriptionQueueMap.Load(reference)
+	if !ok {
+		return nil, fmt.Errorf("%s : %w", reference, ErrSubscriberNotFound)
+	}
+	return sub.(*subscriber), nil
+}
+
+// PauseSubscriber stops the subscriber registered as reference from receiving messages until ResumeSubscriber is called,
+// without shutting down the service. Messages being handled complete while new ones stay on the queue,
+// jetstream keeps them on the server and in memory queues hold them in the subscription.
+func (s *Service) PauseSubscriber(ctx context.Context, reference string) error {
+
+	sub, err := s.subscriberByReference(reference)
+	if err != nil {
+		return err
+	}
+
+	sub.pause.mu.Lock()
+	defer sub.pause.mu.Unlock()
+
+	if sub.pause.paused {
+		return nil
+	}
+
+	sub.pause.paused = true
+	sub.pause.resumed = make(chan struct{})
+	if sub.pause.cancelReceive != nil {
+		sub.pause.cancelReceive()
+	}
+
+	s.L(ctx).WithField("subscriber", reference).Info("subscriber paused")
+	return nil
+}
+
+// ResumeSubscriber lets a subscriber paused with PauseSubscriber receive messages again
+func (s *Service) ResumeSubscriber(ctx context.Context, reference string) error {
+
+	sub, err := s.subscriberByReference(reference)
+	if err != nil {
+		return err
+	}
+
+	sub.pause.mu.Lock()
+	defer sub.pause.mu.Unlock()
+
+	if !sub.pause.paused {
+		return nil
+	}
+
+	sub.pause.paused = false
+	close(sub.pause.resumed)
+
+	s.L(ctx).WithField("subscriber", reference).Info("subscriber resumed")
+	return nil
+}
+
+// SubscriberIsPaused reports whether the subscriber registered as reference is paused
+func (s *Service) SubscriberIsPaused(reference string) bool {
+
+	sub, err := s.subscriberByReference(reference)
+	if err != nil {
+		return false
+	}
+
+	sub.pause.mu.Lock()
+	defer sub.pause.mu.Unlock()
+	return sub.pause.paused
+}
+
+// receiveContext waits while the subscriber is paused, then returns the context to receive the next message with.
+// Pausing cancels that context so a receive that is waiting for messages returns right away.
+func (s *subscriber) receiveContext(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	for {
+		s.pause.mu.Lock()
+		if !s.pause.paused {
+			receiveCtx, cancel := context.WithCancel(ctx)
+			s.pause.cancelReceive = cancel
+			s.pause.mu.Unlock()
+			return receiveCtx, cancel, nil
+		}
+		resumed := s.pause.resumed
+		s.pause.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-resumed:
+		}
+	}
+}
+
+// SubscriberAdminHandler creates a handler to pause and resume subscribers at runtime, serving
+//
+//	GET  /subscribers/{reference}         the subscriber state
+//	POST /subscribers/{reference}/pause   pauses the subscriber
+//	POST /subscribers/{reference}/resume  resumes the subscriber
+//
+// It performs no authorization of its own and should be mounted behind the authentication used for admin endpoints.
+func (s *Service) SubscriberAdminHandler() http.Handler {
+
+	writeState := func(w http.ResponseWriter, r *http.Request, reference string) {
+		_ = WriteJSON(r.Context(), w, http.StatusOK, map[string]any{
+			"reference": reference,
+			"paused":    s.SubscriberIsPaused(reference),
+			"initiated": s.SubscriptionIsInitiated(reference),
+		})
+	}
+
+	change := func(apply func(ctx context.Context, reference string) error) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			reference := r.PathValue("reference")
+			err := apply(r.Context(), reference)
+			if err != nil {
+				_ = WriteError(r.Context(), w, err)
+				return
+			}
+			writeState(w, r, reference)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /subscribers/{reference}", func(w http.ResponseWriter, r *http.Request) {
+		reference := r.PathValue("reference")
+		if _, err := s.subscriberByReference(reference); err != nil {
+			_ = WriteError(r.Context(), w, err)
+			return
+		}
+		writeState(w, r, reference)
+	})
+	mux.HandleFunc("POST /subscribers/{reference}/pause", change(s.PauseSubscriber))
+	mux.HandleFunc("POST /subscribers/{reference}/resume", change(s.ResumeSubscriber))
+	return mux
+}
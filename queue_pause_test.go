@@ -0,0 +1,159 @@
+package frame_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestService_PauseSubscriber(t *testing.T) {
+
+	tests := []struct {
+		name     string
+		register func(queueURL string, handler *channelHandler) frame.Option
+	}{
+		{
+			name: "Subscriber",
+			register: func(queueURL string, handler *channelHandler) frame.Option {
+				return frame.RegisterSubscriber("pausable", queueURL, 1, handler)
+			},
+		},
+		{
+			name: "Batch subscriber",
+			register: func(queueURL string, handler *channelHandler) frame.Option {
+				return frame.RegisterBatchSubscriber("pausable", queueURL, 1, 10*time.Millisecond, handler)
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			queueURL := fmt.Sprintf("mem://topic-pausable-%d", i)
+			handler := &channelHandler{messages: make(chan []byte, 2)}
+
+			ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+				frame.RegisterPublisher("pausable", queueURL),
+				tt.register(queueURL, handler))
+			defer srv.Stop(ctx)
+
+			err := srv.Run(ctx, "")
+			if err != nil {
+				t.Fatalf("could not run service : %s", err)
+			}
+
+			err = srv.PauseSubscriber(ctx, "pausable")
+			if err != nil {
+				t.Fatalf("could not pause subscriber : %s", err)
+			}
+
+			if !srv.SubscriberIsPaused("pausable") {
+				t.Errorf("subscriber should report being paused")
+			}
+
+			err = srv.Publish(ctx, "pausable", []byte("held"))
+			if err != nil {
+				t.Fatalf("could not publish message : %s", err)
+			}
+
+			select {
+			case message := <-handler.messages:
+				t.Fatalf("paused subscriber handled %q", message)
+			case <-time.After(300 * time.Millisecond):
+			}
+
+			err = srv.ResumeSubscriber(ctx, "pausable")
+			if err != nil {
+				t.Fatalf("could not resume subscriber : %s", err)
+			}
+
+			select {
+			case message := <-handler.messages:
+				if string(message) != "held" {
+					t.Errorf("resumed subscriber handled %q instead of the held message", message)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("held message was not delivered after resuming")
+			}
+		})
+	}
+}
+
+func (h *channelHandler) HandleBatch(_ context.Context, messages []frame.QueueMessage) error {
+	for _, message := range messages {
+		h.messages <- message.Body
+	}
+	return nil
+}
+
+func TestService_SubscriberAdminHandler(t *testing.T) {
+
+	queueURL := "mem://topic-pausable-admin"
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.RegisterPublisher("pausable", queueURL),
+		frame.RegisterSubscriber("pausable", queueURL, 1, &channelHandler{messages: make(chan []byte, 1)}))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	if !errors.Is(srv.PauseSubscriber(ctx, "unknown"), frame.ErrSubscriberNotFound) {
+		t.Errorf("pausing an unknown subscriber should fail with ErrSubscriberNotFound")
+	}
+
+	ts := httptest.NewServer(srv.SubscriberAdminHandler())
+	defer ts.Close()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		wantStatus int
+		wantPaused bool
+	}{
+		{name: "Pause", method: http.MethodPost, path: "/subscribers/pausable/pause", wantStatus: http.StatusOK, wantPaused: true},
+		{name: "State", method: http.MethodGet, path: "/subscribers/pausable", wantStatus: http.StatusOK, wantPaused: true},
+		{name: "Resume", method: http.MethodPost, path: "/subscribers/pausable/resume", wantStatus: http.StatusOK, wantPaused: false},
+		{name: "Unknown", method: http.MethodPost, path: "/subscribers/unknown/pause", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			resp, err0 := http.DefaultClient.Do(req)
+			if err0 != nil {
+				t.Fatalf("could not call %s : %s", tt.path, err0)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status %d, expected %d", resp.StatusCode, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var state struct {
+				Paused bool `json:"paused"`
+			}
+			err0 = json.NewDecoder(resp.Body).Decode(&state)
+			if err0 != nil {
+				t.Fatalf("could not decode subscriber state : %s", err0)
+			}
+			if state.Paused != tt.wantPaused {
+				t.Errorf("paused %t, expected %t", state.Paused, tt.wantPaused)
+			}
+			if srv.SubscriberIsPaused("pausable") != tt.wantPaused {
+				t.Errorf("service state does not match the response")
+			}
+		})
+	}
+}
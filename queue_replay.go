@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"context"
+	"github.com/nats-io/nats.go/jetstream"
+	"net/url"
+	"time"
+)
+
+const replayFetchBatch = 100
+
+// ReplayQueue feeds the messages stored in the jetstream stream of the queue reference since the supplied time
+// through handler, returning the number of messages replayed. Messages are read with an ephemeral ordered consumer
+// that does not acknowledge anything, so the delivery state of the registered subscribers is left untouched.
+func (s *Service) ReplayQueue(ctx context.Context, reference string, from time.Time, handler SubscribeWorker) (int, error) {
+
+	target, err := s.openJetStream(reference)
+	if err != nil {
+		return 0, err
+	}
+	defer target.close()
+
+	consumerConfig := jetstream.OrderedConsumerConfig{
+		DeliverPolicy: jetstream.DeliverByStartTimePolicy,
+		OptStartTime:  &from,
+	}
+	if target.subject != "" {
+		consumerConfig.FilterSubjects = []string{target.subject}
+	}
+
+	consumer, err := target.js.OrderedConsumer(ctx, target.stream, consumerConfig)
+	if err != nil {
+		return 0, err
+	}
+
+	info, err := consumer.Info(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	remaining := info.NumPending
+	for remaining > 0 {
+
+		batch, err := consumer.Fetch(int(min(remaining, replayFetchBatch)), jetstream.FetchMaxWait(5*time.Second))
+		if err != nil {
+			return replayed, err
+		}
+
+		fetched := 0
+		for msg := range batch.Messages() {
+			fetched++
+
+			metadata, err := replayMetadata(msg)
+			if err != nil {
+				return replayed, err
+			}
+
+			err = handler.Handle(ctx, metadata, msg.Data())
+			if err != nil {
+				return replayed, err
+			}
+			replayed++
+		}
+
+		if batch.Error() != nil {
+			return replayed, batch.Error()
+		}
+
+		if fetched == 0 {
+			break
+		}
+		remaining -= uint64(fetched)
+	}
+
+	return replayed, nil
+}
+
+// replayMetadata decodes the message headers the same way the nats driver does for subscribers
+func replayMetadata(msg jetstream.Msg) (map[string]string, error) {
+	metadata := map[string]string{SubjectMetadataKey: msg.Subject()}
+	for k, v := range msg.Headers() {
+		var value string
+		if len(v) > 0 {
+			value = v[0]
+		}
+
+		key, err := url.QueryUnescape(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
@@ -0,0 +1,83 @@
+package frame_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"sync"
+	"testing"
+	"time"
+)
+
+type replayHandler struct {
+	mu       sync.Mutex
+	messages []string
+}
+
+func (h *replayHandler) Handle(_ context.Context, _ map[string]string, message []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.messages = append(h.messages, string(message))
+	return nil
+}
+
+func TestService_ReplayQueue(t *testing.T) {
+
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+	suffix := time.Now().UnixNano()
+	queueURL := fmt.Sprintf("%s?jetstream=true&stream_name=frame_replay_%d&subject=frame.replay.%d", natsURL, suffix, suffix)
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("replay", queueURL),
+		frame.RegisterSubscriber("replay-sub", queueURL, 1, &replayHandler{}),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	err = srv.Publish(ctx, "replay", []byte("before"))
+	if err != nil {
+		t.Errorf("could not publish message : %s", err)
+		return
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	from := time.Now()
+
+	for _, message := range []string{"first", "second"} {
+		err = srv.Publish(ctx, "replay", []byte(message))
+		if err != nil {
+			t.Errorf("could not publish message : %s", err)
+			return
+		}
+	}
+
+	handler := &replayHandler{}
+	replayed, err := srv.ReplayQueue(ctx, "replay", from, handler)
+	if err != nil {
+		t.Errorf("could not replay queue : %s", err)
+		return
+	}
+
+	if replayed != 2 || len(handler.messages) != 2 ||
+		handler.messages[0] != "first" || handler.messages[1] != "second" {
+		t.Errorf("replay should deliver only the messages published after the start time, got %v", handler.messages)
+	}
+}
+
+func TestService_ReplayQueueRequiresJetStream(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("replay-mem", "mem://topicReplay"),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	_, err := srv.ReplayQueue(ctx, "replay-mem", time.Now(), &replayHandler{})
+	if err == nil {
+		t.Errorf("replaying a non jetstream queue should fail")
+	}
+}
@@ -0,0 +1,133 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/rs/xid"
+	"gocloud.dev/pubsub"
+	"net/url"
+	"time"
+)
+
+const (
+	// ReplyToMetadataKey is the metadata key carrying the subject a request expects its reply on
+	ReplyToMetadataKey    = "reply_to"
+	replyErrorMetadataKey = "reply_error"
+	replySubjectPrefix    = "frame.reply."
+)
+
+// ReplySubscribeWorker handles request messages, the returned payload is sent back to the requester
+type ReplySubscribeWorker interface {
+	HandleRequest(ctx context.Context, metadata map[string]string, message []byte) ([]byte, error)
+}
+
+// RegisterReplySubscriber Option to register a subscription whose handler replies to requests made with Request
+func RegisterReplySubscriber(reference string, queueURL string, concurrency int,
+	handler ReplySubscribeWorker, opts ...SubscriberOption) Option {
+	return RegisterSubscriber(reference, queueURL, concurrency, &replyWorker{handler: handler, queueURL: queueURL}, opts...)
+}
+
+type replyWorker struct {
+	handler  ReplySubscribeWorker
+	queueURL string
+}
+
+func (rw *replyWorker) Handle(ctx context.Context, metadata map[string]string, message []byte) error {
+
+	reply, err := rw.handler.HandleRequest(ctx, metadata, message)
+
+	replyTo := metadata[ReplyToMetadataKey]
+	if replyTo == "" {
+		return err
+	}
+
+	replyMetadata := map[string]string{}
+	if err != nil {
+		replyMetadata[replyErrorMetadataKey] = err.Error()
+	}
+
+	replyURL, err0 := replyQueueURL(rw.queueURL, replyTo)
+	if err0 != nil {
+		return err0
+	}
+
+	topic, err0 := pubsub.OpenTopic(ctx, replyURL)
+	if err0 != nil {
+		return err0
+	}
+	defer func() { _ = topic.Shutdown(ctx) }()
+
+	return topic.Send(ctx, &pubsub.Message{Body: reply, Metadata: replyMetadata})
+}
+
+// Request publishes payload to the queue reference and waits up to timeout for a subscriber
+// registered with RegisterReplySubscriber to reply, returning the reply payload.
+func (s *Service) Request(ctx context.Context, reference string, payload any, timeout time.Duration) ([]byte, error) {
+
+	pub, err := s.queue.getPublisherByReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	replyTo := replySubjectPrefix + xid.New().String()
+	replyURL, err := replyQueueURL(pub.url, replyTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// The reply topic is opened before subscribing as in memory subscriptions require an existing topic
+	replyTopic, err := pubsub.OpenTopic(ctx, replyURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = replyTopic.Shutdown(context.Background()) }()
+
+	replySubscription, err := pubsub.OpenSubscription(ctx, replyURL)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = replySubscription.Shutdown(context.Background()) }()
+
+	err = s.publish(ctx, reference, payload, map[string]string{ReplyToMetadataKey: replyTo})
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := replySubscription.Receive(ctx)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, WrapError(ErrorCodeDeadlineExceeded, err, fmt.Sprintf("no reply was received within %s", timeout))
+		}
+		return nil, err
+	}
+	reply.Ack()
+
+	if replyErr, ok := reply.Metadata[replyErrorMetadataKey]; ok {
+		return reply.Body, fmt.Errorf("request handler failed : %s", replyErr)
+	}
+
+	return reply.Body, nil
+}
+
+// replyQueueURL derives the url replies are exchanged on from the url of the request queue
+func replyQueueURL(queueURL string, replyTo string) (string, error) {
+
+	u, err := url.Parse(queueURL)
+	if err != nil {
+		return "", err
+	}
+
+	switch u.Scheme {
+	case "mem":
+		return fmt.Sprintf("mem://%s", replyTo), nil
+	case "nats":
+		replyURL := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host, RawQuery: url.Values{"subject": {replyTo}}.Encode()}
+		return replyURL.String(), nil
+	default:
+		return "", fmt.Errorf("request reply is not supported over %s queues", u.Scheme)
+	}
+}
@@ -0,0 +1,83 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"strings"
+	"testing"
+	"time"
+)
+
+type echoReplyHandler struct{}
+
+func (h *echoReplyHandler) HandleRequest(_ context.Context, _ map[string]string, message []byte) ([]byte, error) {
+	if string(message) == "fail" {
+		return nil, errors.New("request rejected")
+	}
+	return []byte(strings.ToUpper(string(message))), nil
+}
+
+func testRequestReply(t *testing.T, requestURL string) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-request", requestURL),
+		frame.RegisterReplySubscriber("test-request", requestURL, 1, &echoReplyHandler{}),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	reply, err := srv.Request(ctx, "test-request", []byte("ping"), 3*time.Second)
+	if err != nil {
+		t.Errorf("request failed : %s", err)
+		return
+	}
+
+	if string(reply) != "PING" {
+		t.Errorf("reply = %q, want %q", reply, "PING")
+	}
+
+	_, err = srv.Request(ctx, "test-request", []byte("fail"), 3*time.Second)
+	if err == nil || !strings.Contains(err.Error(), "request rejected") {
+		t.Errorf("expected the handler error to be returned, got %v", err)
+	}
+}
+
+func TestService_RequestReplyMem(t *testing.T) {
+	testRequestReply(t, "mem://topicRequest")
+}
+
+func TestService_RequestReplyNats(t *testing.T) {
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+	testRequestReply(t, fmt.Sprintf("%s?subject=frame.test.request", natsURL))
+}
+
+func TestService_RequestTimeout(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-request-timeout", "mem://topicRequestTimeout"),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	startedAt := time.Now()
+	_, err = srv.Request(ctx, "test-request-timeout", []byte("ping"), 200*time.Millisecond)
+	if frame.ErrorCodeOf(err) != frame.ErrorCodeDeadlineExceeded {
+		t.Errorf("expected a deadline exceeded error, got %v", err)
+	}
+
+	if time.Since(startedAt) > 2*time.Second {
+		t.Errorf("request did not give up after its timeout")
+	}
+}
@@ -0,0 +1,87 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"github.com/nats-io/nats.go"
+	"gocloud.dev/gcerrors"
+	"time"
+)
+
+// PublishRetryPolicy controls how failed publishes are retried
+type PublishRetryPolicy struct {
+	// MaxAttempts is the total number of sends tried, including the first one
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, it doubles on every following retry
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between retries when set
+	MaxBackoff time.Duration
+	// Retryable decides which errors are worth retrying, IsTransientPublishError is used when it is not set
+	Retryable func(err error) bool
+}
+
+// WithPublishRetry Option to retry publishes failing with transient broker errors according to policy.
+// Publishes are not retried unless this option is used.
+func WithPublishRetry(policy PublishRetryPolicy) Option {
+	return func(s *Service) {
+		s.publishRetry = &policy
+	}
+}
+
+// IsTransientPublishError reports whether a publish failed for a reason likely to clear up on its own,
+// such as nats having no responders or timing out, as opposed to permanent failures like an invalid subject.
+func IsTransientPublishError(err error) bool {
+
+	switch {
+	case err == nil, errors.Is(err, context.Canceled):
+		return false
+	case errors.Is(err, nats.ErrBadSubject), errors.Is(err, nats.ErrMaxPayload), errors.Is(err, nats.ErrConnectionClosed):
+		return false
+	case errors.Is(err, nats.ErrNoResponders), errors.Is(err, nats.ErrTimeout), errors.Is(err, nats.ErrConnectionReconnecting):
+		return true
+	}
+
+	switch gcerrors.Code(err) {
+	case gcerrors.ResourceExhausted, gcerrors.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// sendWithRetry calls send until it succeeds, fails permanently or the attempts of the retry policy run out
+func (s *Service) sendWithRetry(ctx context.Context, reference string, send func() error) error {
+
+	policy := s.publishRetry
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return send()
+	}
+
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsTransientPublishError
+	}
+
+	backoff := policy.InitialBackoff
+	for attempt := 1; ; attempt++ {
+
+		err := send()
+		if err == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		s.L(ctx).WithError(err).WithField("publisher", reference).WithField("attempt", attempt).
+			Debug("publish failed with a transient error, retrying")
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+	}
+}
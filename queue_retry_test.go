@@ -0,0 +1,145 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nats-io/nats.go"
+	"github.com/pitabwire/frame"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/driver"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyTopic is a pubsub driver failing the first sends with a configured error before succeeding,
+// it simulates a broker with transient or permanent failures.
+type flakyTopic struct {
+	failures int32
+	err      error
+	sends    atomic.Int32
+}
+
+func (ft *flakyTopic) SendBatch(_ context.Context, _ []*driver.Message) error {
+	if ft.sends.Add(1) <= ft.failures {
+		return ft.err
+	}
+	return nil
+}
+func (ft *flakyTopic) IsRetryable(_ error) bool             { return false }
+func (ft *flakyTopic) As(_ any) bool                        { return false }
+func (ft *flakyTopic) ErrorAs(_ error, _ any) bool          { return false }
+func (ft *flakyTopic) ErrorCode(_ error) gcerrors.ErrorCode { return gcerrors.Unknown }
+func (ft *flakyTopic) Close() error                         { return nil }
+
+// flakyTopics opens flaky://<name>?fail=<count>&error=<noresponders|badsubject> topics, keeping them by name
+type flakyTopics struct {
+	mu     sync.Mutex
+	topics map[string]*flakyTopic
+}
+
+func (fts *flakyTopics) OpenTopicURL(_ context.Context, u *url.URL) (*pubsub.Topic, error) {
+
+	failures, err := strconv.Atoi(u.Query().Get("fail"))
+	if err != nil {
+		return nil, err
+	}
+
+	topic := &flakyTopic{failures: int32(failures), err: nats.ErrNoResponders}
+	if u.Query().Get("error") == "badsubject" {
+		topic.err = nats.ErrBadSubject
+	}
+
+	fts.mu.Lock()
+	fts.topics[u.Host] = topic
+	fts.mu.Unlock()
+
+	return pubsub.NewTopic(topic, nil), nil
+}
+
+func (fts *flakyTopics) get(name string) *flakyTopic {
+	fts.mu.Lock()
+	defer fts.mu.Unlock()
+	return fts.topics[name]
+}
+
+var flakyTopicRegistry = &flakyTopics{topics: map[string]*flakyTopic{}}
+
+func init() {
+	pubsub.DefaultURLMux().RegisterTopic("flaky", flakyTopicRegistry)
+}
+
+func TestService_PublishRetry(t *testing.T) {
+
+	policy := frame.PublishRetryPolicy{MaxAttempts: 4, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+
+	tests := []struct {
+		name      string
+		url       string
+		policy    *frame.PublishRetryPolicy
+		wantErr   error
+		wantSends int32
+	}{
+		{name: "Transient errors are retried", url: "flaky://retry-transient?fail=2", policy: &policy, wantSends: 3},
+		{name: "Attempts run out", url: "flaky://retry-exhausted?fail=10", policy: &policy, wantErr: nats.ErrNoResponders, wantSends: 4},
+		{name: "Permanent errors are not retried", url: "flaky://retry-permanent?fail=1&error=badsubject", policy: &policy, wantErr: nats.ErrBadSubject, wantSends: 1},
+		{name: "No retry by default", url: "flaky://retry-disabled?fail=1", wantErr: nats.ErrNoResponders, wantSends: 1},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			reference := fmt.Sprintf("test-publish-retry-%d", i)
+			opts := []frame.Option{frame.RegisterPublisher(reference, tt.url), frame.NoopDriver()}
+			if tt.policy != nil {
+				opts = append(opts, frame.WithPublishRetry(*tt.policy))
+			}
+
+			ctx, srv := frame.NewService("Test Srv", opts...)
+			defer srv.Stop(ctx)
+
+			err := srv.Run(ctx, "")
+			if err != nil {
+				t.Fatalf("we couldn't instantiate queue  %s", err)
+			}
+
+			err = srv.Publish(ctx, reference, []byte("Testament"))
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("publish should succeed after retrying, got %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("publish error = %v, want %v", err, tt.wantErr)
+			}
+
+			u, _ := url.Parse(tt.url)
+			if sends := flakyTopicRegistry.get(u.Host).sends.Load(); sends != tt.wantSends {
+				t.Errorf("sends = %d, want %d", sends, tt.wantSends)
+			}
+		})
+	}
+}
+
+func TestIsTransientPublishError(t *testing.T) {
+
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nats.ErrNoResponders, want: true},
+		{err: fmt.Errorf("send failed : %w", nats.ErrTimeout), want: true},
+		{err: nats.ErrBadSubject, want: false},
+		{err: context.Canceled, want: false},
+		{err: errors.New("unknown failure"), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := frame.IsTransientPublishError(tt.err); got != tt.want {
+			t.Errorf("IsTransientPublishError(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
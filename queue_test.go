@@ -5,8 +5,14 @@ import (
 	"errors"
 	"fmt"
 	"github.com/pitabwire/frame"
+	"gocloud.dev/gcerrors"
+	"gocloud.dev/pubsub"
+	"gocloud.dev/pubsub/driver"
 	"log"
+	"net/url"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestService_RegisterPublisherNotSet(t *testing.T) {
@@ -89,6 +95,75 @@ func TestService_RegisterPublisherMultiple(t *testing.T) {
 	srv.Stop(ctx)
 }
 
+// blockingTopic is a pubsub driver that never completes a send unless the context is done,
+// it simulates a slow or unreachable broker.
+type blockingTopic struct {
+}
+
+func (bt *blockingTopic) SendBatch(_ context.Context, _ []*driver.Message) error {
+	select {}
+}
+func (bt *blockingTopic) IsRetryable(_ error) bool             { return false }
+func (bt *blockingTopic) As(_ any) bool                        { return false }
+func (bt *blockingTopic) ErrorAs(_ error, _ any) bool          { return false }
+func (bt *blockingTopic) ErrorCode(_ error) gcerrors.ErrorCode { return gcerrors.Unknown }
+func (bt *blockingTopic) Close() error                         { return nil }
+func (bt *blockingTopic) OpenTopicURL(_ context.Context, _ *url.URL) (*pubsub.Topic, error) {
+	return pubsub.NewTopic(bt, nil), nil
+}
+
+func init() {
+	pubsub.DefaultURLMux().RegisterTopic("blocking", &blockingTopic{})
+}
+
+func TestService_PublishCanceledContext(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-publish-cancel", "mem://topicCancel"), frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("we couldn't instantiate queue  %s", err)
+		return
+	}
+
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	err = srv.Publish(canceledCtx, "test-publish-cancel", []byte("Testament"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("publishing with a canceled context should fail with context canceled not : %v", err)
+	}
+}
+
+func TestService_PublishAbortsBlockedSend(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-publish-blocked", "blocking://topic"), frame.NoopDriver(),
+		frame.WithQueueShutdownTimeout(500*time.Millisecond))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("we couldn't instantiate queue  %s", err)
+		return
+	}
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = srv.Publish(timeoutCtx, "test-publish-blocked", []byte("Testament"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("a blocked publish should be aborted once the context expires not : %v", err)
+	}
+
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("a blocked publish did not return promptly after the context expired")
+	}
+}
+
 type messageHandler struct {
 }
 
@@ -198,3 +273,146 @@ func TestService_RegisterSubscriberContextCancelWorks(t *testing.T) {
 	srv.Stop(ctx)
 
 }
+
+type subjectHandler struct {
+	mu       sync.Mutex
+	subjects map[string]string
+}
+
+func (h *subjectHandler) Handle(_ context.Context, metadata map[string]string, message []byte) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subjects[metadata[frame.SubjectMetadataKey]] = string(message)
+	return nil
+}
+
+func (h *subjectHandler) received() map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	received := make(map[string]string, len(h.subjects))
+	for k, v := range h.subjects {
+		received[k] = v
+	}
+	return received
+}
+
+func TestService_RegisterSubscriberWildcardSubject(t *testing.T) {
+
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+
+	handler := &subjectHandler{subjects: map[string]string{}}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("orders-created", fmt.Sprintf("%s?subject=orders.created", natsURL)),
+		frame.RegisterPublisher("orders-updated", fmt.Sprintf("%s?subject=orders.updated", natsURL)),
+		frame.RegisterSubscriber("orders", fmt.Sprintf("%s?subject=orders.>", natsURL), 1, handler),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("We couldn't instantiate queue  %s", err)
+		return
+	}
+
+	err = srv.Publish(ctx, "orders-created", []byte("created"))
+	if err != nil {
+		t.Errorf("We could not publish to orders.created %s", err)
+		return
+	}
+
+	err = srv.Publish(ctx, "orders-updated", []byte("updated"))
+	if err != nil {
+		t.Errorf("We could not publish to orders.updated %s", err)
+		return
+	}
+
+	want := map[string]string{"orders.created": "created", "orders.updated": "updated"}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if len(handler.received()) == len(want) {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	received := handler.received()
+	for subject, body := range want {
+		if received[subject] != body {
+			t.Errorf("expected message %q on subject %s, got %q", body, subject, received[subject])
+		}
+	}
+}
+
+type recordingHandler struct {
+	messages chan []byte
+}
+
+func (h *recordingHandler) Handle(_ context.Context, _ map[string]string, message []byte) error {
+	h.messages <- message
+	return nil
+}
+
+func TestService_EmptyMessages(t *testing.T) {
+
+	tests := []struct {
+		name      string
+		opts      []frame.SubscriberOption
+		wantEmpty bool
+	}{
+		{name: "Empty message is delivered by default", wantEmpty: true},
+		{name: "Empty message is skipped", opts: []frame.SubscriberOption{frame.WithSkipEmptyMessages()}},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := &recordingHandler{messages: make(chan []byte, 2)}
+			queueURL := fmt.Sprintf("mem://topicEmpty%d", i)
+
+			ctx, srv := frame.NewService("Test Srv",
+				frame.RegisterPublisher("test-empty", queueURL),
+				frame.RegisterSubscriber("test-empty", queueURL, 1, handler, tt.opts...),
+				frame.NoopDriver())
+			defer srv.Stop(ctx)
+
+			err := srv.Run(ctx, "")
+			if err != nil {
+				t.Fatalf("We couldn't instantiate queue  %s", err)
+			}
+
+			for _, message := range []string{"", "not empty"} {
+				err = srv.Publish(ctx, "test-empty", []byte(message))
+				if err != nil {
+					t.Fatalf("could not publish message : %s", err)
+				}
+			}
+
+			receivedEmpty := false
+			receivedOther := false
+			timeout := time.After(time.Second)
+			for !receivedOther || (tt.wantEmpty && !receivedEmpty) {
+				select {
+				case message := <-handler.messages:
+					if len(message) == 0 {
+						receivedEmpty = true
+					} else {
+						receivedOther = true
+					}
+				case <-timeout:
+					t.Fatalf("messages did not reach the handler, empty %v other %v", receivedEmpty, receivedOther)
+				}
+			}
+
+			select {
+			case message := <-handler.messages:
+				receivedEmpty = receivedEmpty || len(message) == 0
+			case <-time.After(200 * time.Millisecond):
+			}
+
+			if receivedEmpty != tt.wantEmpty {
+				t.Errorf("empty message handled = %v, want %v", receivedEmpty, tt.wantEmpty)
+			}
+		})
+	}
+}
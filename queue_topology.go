@@ -0,0 +1,95 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// QueuePublisherDeclaration declares a publisher to be registered from configuration
+type QueuePublisherDeclaration struct {
+	Reference string `json:"reference"`
+	URL       string `json:"url"`
+}
+
+// QueueSubscriberDeclaration declares a subscriber to be registered from configuration,
+// the handler is resolved by name from the handlers registered with RegisterQueueHandler
+type QueueSubscriberDeclaration struct {
+	Reference   string `json:"reference"`
+	URL         string `json:"url"`
+	Handler     string `json:"handler"`
+	Concurrency int    `json:"concurrency"`
+}
+
+// QueueTopology declares the publishers and subscribers a service wires at startup.
+// It is read from configuration as a json document for example :
+//
+//	{"publishers": [{"reference": "orders", "url": "nats://localhost:4222?subject=orders"}],
+//	 "subscribers": [{"reference": "orders", "url": "nats://localhost:4222?subject=orders", "handler": "orders", "concurrency": 5}]}
+type QueueTopology struct {
+	Publishers  []QueuePublisherDeclaration  `json:"publishers"`
+	Subscribers []QueueSubscriberDeclaration `json:"subscribers"`
+}
+
+// Decode allows the topology to be loaded from environment variables
+func (qt *QueueTopology) Decode(value string) error {
+	if value == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(value), qt)
+}
+
+// RegisterQueueHandler Option to make a subscription handler available by name
+// to subscribers declared in the configured queue topology
+func RegisterQueueHandler(name string, handler SubscribeWorker) Option {
+	return func(s *Service) {
+		s.queue.handlers.Store(name, handler)
+	}
+}
+
+// initQueueTopology registers the publishers and subscribers declared in configuration.
+// References that were already registered in code take precedence over the configuration.
+func (s *Service) initQueueTopology(_ context.Context) error {
+
+	config, ok := s.Config().(ConfigurationQueueTopology)
+	if !ok {
+		return nil
+	}
+
+	topology := config.GetQueueTopology()
+
+	for _, pub := range topology.Publishers {
+		if pub.Reference == "" || pub.URL == "" {
+			return fmt.Errorf("queue topology publisher requires a reference and url : %+v", pub)
+		}
+
+		s.queue.publishQueueMap.LoadOrStore(pub.Reference, &publisher{
+			reference: pub.Reference,
+			url:       pub.URL,
+		})
+	}
+
+	for _, sub := range topology.Subscribers {
+		if sub.Reference == "" || sub.URL == "" {
+			return fmt.Errorf("queue topology subscriber requires a reference and url : %+v", sub)
+		}
+
+		if _, ok = s.queue.subscriptionQueueMap.Load(sub.Reference); ok {
+			continue
+		}
+
+		handler, ok := s.queue.handlers.Load(sub.Handler)
+		if !ok {
+			return fmt.Errorf("queue topology subscriber %s uses handler %s which is not registered", sub.Reference, sub.Handler)
+		}
+
+		concurrency := sub.Concurrency
+		if concurrency <= 0 {
+			concurrency = 1
+		}
+
+		RegisterSubscriber(sub.Reference, sub.URL, concurrency, handler.(SubscribeWorker))(s)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package frame_test
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"os"
+	"testing"
+	"time"
+)
+
+type namedHandler struct {
+	received chan string
+}
+
+func (h *namedHandler) Handle(_ context.Context, _ map[string]string, message []byte) error {
+	h.received <- string(message)
+	return nil
+}
+
+func TestService_QueueTopologyFromConfig(t *testing.T) {
+
+	err := os.Setenv("QUEUE_TOPOLOGY", `{
+		"publishers": [{"reference": "orders", "url": "mem://topicTopology"}],
+		"subscribers": [{"reference": "orders-sub", "url": "mem://topicTopology", "handler": "orders-handler", "concurrency": 2}]
+	}`)
+	if err != nil {
+		t.Errorf("could not set topology environment : %s", err)
+		return
+	}
+	defer func() { _ = os.Unsetenv("QUEUE_TOPOLOGY") }()
+
+	var defConf frame.ConfigurationDefault
+	err = frame.ConfigProcess("", &defConf)
+	if err != nil {
+		t.Errorf("could not process configuration : %s", err)
+		return
+	}
+
+	handler := &namedHandler{received: make(chan string, 1)}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.Config(&defConf),
+		frame.RegisterQueueHandler("orders-handler", handler),
+		frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	err = srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	if !srv.SubscriptionIsInitiated("orders-sub") {
+		t.Errorf("config declared subscriber was not wired")
+	}
+
+	err = srv.Publish(ctx, "orders", []byte("order created"))
+	if err != nil {
+		t.Errorf("could not publish to config declared publisher : %s", err)
+		return
+	}
+
+	select {
+	case msg := <-handler.received:
+		if msg != "order created" {
+			t.Errorf("registered handler received %q", msg)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("registered handler did not receive the message")
+	}
+}
+
+func TestService_QueueTopologyUnknownHandler(t *testing.T) {
+
+	defConf := frame.ConfigurationDefault{
+		QueueTopology: frame.QueueTopology{
+			Subscribers: []frame.QueueSubscriberDeclaration{
+				{Reference: "orders-sub", URL: "mem://topicTopologyUnknown", Handler: "missing"},
+			},
+		},
+	}
+
+	ctx, srv := frame.NewService("Test Srv", frame.Config(&defConf), frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	if err := srv.Run(ctx, ""); err == nil {
+		t.Errorf("subscriber with an unregistered handler should fail startup")
+	}
+}
@@ -0,0 +1,79 @@
+package frame
+
+import (
+	"context"
+	"github.com/nats-io/nats.go/jetstream"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gocloud.dev/pubsub"
+	"sync/atomic"
+)
+
+const queueTracerName = "github.com/pitabwire/frame/queue"
+
+const (
+	// messagingDestinationKey is the queue reference a message was published to or received from
+	messagingDestinationKey = attribute.Key("messaging.destination.name")
+	// messagingDeliveryAttemptKey counts the deliveries of a message, it is above 1 for redeliveries
+	messagingDeliveryAttemptKey = attribute.Key("messaging.message.delivery_attempt")
+)
+
+// startPublishSpan starts the producer span of a message, its context is what the message carries in its metadata
+func startPublishSpan(ctx context.Context, reference string) (context.Context, trace.Span) {
+	return otel.Tracer(queueTracerName).Start(ctx, "publish "+reference,
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(messagingDestinationKey.String(reference)))
+}
+
+// startProcessSpan starts the consumer span of a delivery attempt. The publish span restored from the message
+// metadata is both its parent and linked to it, so every redelivery stays correlated to the original trace.
+func startProcessSpan(ctx context.Context, reference string, attempt int) (context.Context, trace.Span) {
+
+	opts := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(messagingDestinationKey.String(reference), messagingDeliveryAttemptKey.Int(attempt)),
+	}
+
+	if publishSpan := trace.SpanContextFromContext(ctx); publishSpan.IsValid() {
+		opts = append(opts, trace.WithLinks(trace.Link{SpanContext: publishSpan}))
+	}
+
+	return otel.Tracer(queueTracerName).Start(ctx, "process "+reference, opts...)
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// deliveryAttempt obtains how many times a message has been delivered. Jetstream tracks this itself,
+// for other drivers deliveries are counted by message id until the message is acknowledged.
+func (s *subscriber) deliveryAttempt(msg *pubsub.Message) int {
+
+	var jsMsg jetstream.Msg
+	if msg.As(&jsMsg) && jsMsg != nil {
+		metadata, err := jsMsg.Metadata()
+		if err == nil {
+			return int(metadata.NumDelivered)
+		}
+	}
+
+	if msg.LoggableID == "" {
+		return 1
+	}
+
+	attempts, _ := s.deliveries.LoadOrStore(msg.LoggableID, new(atomic.Int64))
+	return int(attempts.(*atomic.Int64).Add(1))
+}
+
+// forgetDeliveries drops the delivery count of a message once it no longer needs to be tracked
+func (s *subscriber) forgetDeliveries(msg *pubsub.Message) {
+	if msg.LoggableID != "" {
+		s.deliveries.Delete(msg.LoggableID)
+	}
+}
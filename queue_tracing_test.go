@@ -0,0 +1,117 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// failOnceHandler fails the first delivery so the message is redelivered
+type failOnceHandler struct {
+	calls atomic.Int32
+	spans chan trace.SpanContext
+}
+
+func (h *failOnceHandler) Handle(ctx context.Context, _ map[string]string, _ []byte) error {
+	h.spans <- trace.SpanContextFromContext(ctx)
+	if h.calls.Add(1) == 1 {
+		return errors.New("transient failure")
+	}
+	return nil
+}
+
+func TestService_QueueTraceSurvivesRedelivery(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	defer otel.SetTracerProvider(previous)
+
+	handler := &failOnceHandler{spans: make(chan trace.SpanContext, 2)}
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.RegisterPublisher("traced", "mem://topicTracedRedelivery"),
+		frame.RegisterSubscriber("traced", "mem://topicTracedRedelivery", 1, handler))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	err = srv.Publish(ctx, "traced", []byte("hello"))
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	var handled []trace.SpanContext
+	for range 2 {
+		select {
+		case sc := <-handler.spans:
+			handled = append(handled, sc)
+		case <-time.After(3 * time.Second):
+			t.Fatalf("message was not redelivered after failing")
+		}
+	}
+
+	// Let the second process span end
+	time.Sleep(100 * time.Millisecond)
+
+	var publishSpan sdktrace.ReadOnlySpan
+	processSpans := map[trace.SpanID]sdktrace.ReadOnlySpan{}
+	for _, span := range recorder.Ended() {
+		switch span.SpanKind() {
+		case trace.SpanKindProducer:
+			publishSpan = span
+		case trace.SpanKindConsumer:
+			processSpans[span.SpanContext().SpanID()] = span
+		}
+	}
+
+	if publishSpan == nil {
+		t.Fatalf("no publish span was recorded")
+	}
+
+	for i, sc := range handled {
+		span, ok := processSpans[sc.SpanID()]
+		if !ok {
+			t.Fatalf("delivery %d was not handled within a process span", i+1)
+		}
+
+		if span.SpanContext().TraceID() != publishSpan.SpanContext().TraceID() ||
+			span.Parent().SpanID() != publishSpan.SpanContext().SpanID() {
+			t.Errorf("delivery %d should be a child of the publish span", i+1)
+		}
+
+		linked := false
+		for _, link := range span.Links() {
+			if link.SpanContext.SpanID() == publishSpan.SpanContext().SpanID() {
+				linked = true
+			}
+		}
+		if !linked {
+			t.Errorf("delivery %d should link to the publish span", i+1)
+		}
+
+		attempt := int64(0)
+		for _, attr := range span.Attributes() {
+			if attr.Key == attribute.Key("messaging.message.delivery_attempt") {
+				attempt = attr.Value.AsInt64()
+			}
+		}
+		if attempt != int64(i+1) {
+			t.Errorf("delivery %d recorded attempt %d", i+1, attempt)
+		}
+	}
+}
@@ -1,11 +1,49 @@
 package frame
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
 )
 
+// immutableColumns are managed by the framework and can not be changed through partial updates
+var immutableColumns = map[string]struct{}{
+	"id":           {},
+	"created_at":   {},
+	"modified_at":  {},
+	"version":      {},
+	"tenant_id":    {},
+	"partition_id": {},
+	"access_id":    {},
+	"deleted_at":   {},
+	"created_by":   {},
+	"updated_by":   {},
+}
+
+// bulkUpdateChunkSize bounds the ids updated by a single statement, postgres accepts at most 65535 bind parameters
+const bulkUpdateChunkSize = 10000
+
+// ErrInvalidUpdate is returned when a partial update references unknown or framework managed columns
+var ErrInvalidUpdate = NewError(ErrorCodeInvalidArgument, "invalid update")
+
+// ErrInvalidColumn is returned when a column selection references fields the model does not have
+var ErrInvalidColumn = NewError(ErrorCodeInvalidArgument, "invalid column")
+
+// ErrRecordConflict is returned by CreateIdempotent when the id belongs to a record that can not be read back,
+// because it was deleted or belongs to another tenant
+var ErrRecordConflict = NewError(ErrorCodeConflict, "a record with the id already exists")
+
+// repositorySchemaCache holds the parsed model schemas used to validate column names
+var repositorySchemaCache sync.Map
+
 type BaseRepositoryI interface {
 	GetByID(id string, result BaseModelI) error
 	Delete(id string) error
@@ -16,14 +54,57 @@ type BaseRepository struct {
 	readDb          *gorm.DB
 	writeDb         *gorm.DB
 	instanceCreator func() BaseModelI
+	changePublisher *Service
+	changeReference string
 }
 
-func NewBaseRepository(readDb *gorm.DB, writeDB *gorm.DB, instanceCreator func() BaseModelI) *BaseRepository {
-	return &BaseRepository{
+func NewBaseRepository(readDb *gorm.DB, writeDB *gorm.DB, instanceCreator func() BaseModelI, opts ...RepositoryOption) *BaseRepository {
+	repo := &BaseRepository{
 		readDb:          readDb,
 		writeDb:         writeDB,
 		instanceCreator: instanceCreator,
 	}
+
+	for _, opt := range opts {
+		opt(repo)
+	}
+	return repo
+}
+
+// withContext returns a copy of the repository whose connections are bound to ctx,
+// so the writes it makes are audited and published for the subject of ctx
+func (repo *BaseRepository) withContext(ctx context.Context) *BaseRepository {
+	bound := *repo
+	if repo.readDb != nil {
+		bound.readDb = repo.readDb.WithContext(ctx)
+	}
+	if repo.writeDb != nil {
+		bound.writeDb = repo.writeDb.WithContext(ctx)
+	}
+	return &bound
+}
+
+// resetManagedFields clears the framework managed fields of instance, like its id, version and tenancy info,
+// so values supplied by a client can not override them
+func (repo *BaseRepository) resetManagedFields(ctx context.Context, instance BaseModelI) error {
+
+	modelSchema, err := schema.Parse(instance, &repositorySchemaCache, repo.namingStrategy())
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(instance)
+	for _, field := range modelSchema.Fields {
+		if _, ok := immutableColumns[field.DBName]; !ok {
+			continue
+		}
+
+		err = field.Set(ctx, value, reflect.Zero(field.FieldType).Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (repo *BaseRepository) getReadDb() *gorm.DB {
@@ -41,12 +122,20 @@ func (repo *BaseRepository) Delete(id string) error {
 		return err
 	}
 
-	return repo.getWriteDb().Delete(deleteInstance).Error
+	err = repo.getWriteDb().Delete(deleteInstance).Error
+	if err != nil {
+		return err
+	}
 
+	return repo.publishChange(repo.writeContext(), ChangeOpDeleted, id, deleteInstance)
 }
 
 func (repo *BaseRepository) GetByID(id string, result BaseModelI) error {
-	return repo.getReadDb().Preload(clause.Associations).First(result, "id = ?", id).Error
+	key, err := repo.keyValue(id)
+	if err != nil {
+		return err
+	}
+	return repo.getReadDb().Preload(clause.Associations).First(result, "id = ?", key).Error
 }
 
 func (repo *BaseRepository) GetLastestBy(properties map[string]any, result BaseModelI) error {
@@ -90,12 +179,268 @@ func (repo *BaseRepository) Save(instance BaseModelI) error {
 
 	if instance.GetVersion() <= 0 {
 
+		auditCreated(repo.writeContext(), instance)
 		err := repo.getWriteDb().Create(instance).Error
 		if err != nil {
 			return err
 		}
-	} else {
-		return repo.getWriteDb().Save(instance).Error
+		return repo.publishChange(repo.writeContext(), ChangeOpCreated, instance.GetID(), instance)
 	}
-	return nil
+
+	auditUpdated(repo.writeContext(), instance)
+	err := repo.getWriteDb().Save(instance).Error
+	if err != nil {
+		return err
+	}
+	return repo.publishChange(repo.writeContext(), ChangeOpUpdated, instance.GetID(), instance)
+}
+
+// CreateIdempotent creates the supplied instance treating an existing record with the same id as success.
+// In that case the existing record is loaded into the instance, making repeated processing of the same data safe.
+// When the existing record is deleted or belongs to another tenant ErrRecordConflict is returned.
+func (repo *BaseRepository) CreateIdempotent(ctx context.Context, instance BaseModelI) error {
+
+	auditCreated(ctx, instance)
+	result := repo.getWriteDb().WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "id"}}, DoNothing: true}).
+		Create(instance)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if result.RowsAffected > 0 {
+		return repo.publishChange(ctx, ChangeOpCreated, instance.GetID(), instance)
+	}
+
+	key, err := repo.keyValue(instance.GetID())
+	if err != nil {
+		return err
+	}
+	err = repo.getWriteDb().WithContext(ctx).First(instance, "id = ?", key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%s : %w", instance.GetID(), ErrRecordConflict)
+	}
+	return err
+}
+
+// UpdateFields updates only the supplied columns of the record with the given id, returning the number of rows affected.
+// Column names are validated against the model and framework managed columns like the id or tenancy info can not be changed.
+func (repo *BaseRepository) UpdateFields(ctx context.Context, id string, fields map[string]any) (int64, error) {
+
+	if len(fields) == 0 {
+		return 0, WrapError(ErrorCodeInvalidArgument, ErrInvalidUpdate, "no fields were supplied for update")
+	}
+
+	key, err := repo.keyValue(id)
+	if err != nil {
+		return 0, err
+	}
+
+	db := repo.getWriteDb().WithContext(ctx)
+	instance := repo.instanceCreator()
+
+	updates, err := repo.columnUpdates(db, instance, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	query := db.Model(instance).Where("id = ?", key)
+	if repo.changePublisher != nil {
+		// The written record is returned so its change event carries the entity, as Save and Delete do
+		query = query.Clauses(clause.Returning{})
+	}
+
+	result := query.UpdateColumns(updates)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return result.RowsAffected, result.Error
+	}
+
+	return result.RowsAffected, repo.publishChange(ctx, ChangeOpUpdated, id, instance)
+}
+
+// BulkUpdate applies the same column updates as UpdateFields to all the records with the supplied ids,
+// returning the total number of rows affected. Ids are updated in chunks of bulkUpdateChunkSize
+// within a single transaction, keeping each statement well within the postgres bind parameter limit.
+func (repo *BaseRepository) BulkUpdate(ctx context.Context, ids []string, fields map[string]any) (int64, error) {
+
+	if len(fields) == 0 {
+		return 0, WrapError(ErrorCodeInvalidArgument, ErrInvalidUpdate, "no fields were supplied for update")
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	keys := make([]any, 0, len(ids))
+	for _, id := range ids {
+		key, err := repo.keyValue(id)
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, key)
+	}
+
+	db := repo.getWriteDb().WithContext(ctx)
+	instance := repo.instanceCreator()
+
+	updates, err := repo.columnUpdates(db, instance, fields)
+	if err != nil {
+		return 0, err
+	}
+
+	// Change events are published for the records actually updated, carrying them as returned by the update
+	var updatedRecords []BaseModelI
+	var affected int64
+	err = db.Transaction(func(tx *gorm.DB) error {
+		for chunk := range slices.Chunk(keys, bulkUpdateChunkSize) {
+
+			if repo.changePublisher == nil {
+				result := tx.Model(repo.instanceCreator()).Where("id IN ?", chunk).UpdateColumns(updates)
+				if result.Error != nil {
+					return result.Error
+				}
+				affected += result.RowsAffected
+				continue
+			}
+
+			updated := reflect.New(reflect.SliceOf(reflect.TypeOf(instance).Elem()))
+			result := tx.Model(updated.Interface()).Clauses(clause.Returning{}).
+				Where("id IN ?", chunk).UpdateColumns(updates)
+			if result.Error != nil {
+				return result.Error
+			}
+			affected += result.RowsAffected
+
+			records := updated.Elem()
+			for i := range records.Len() {
+				if record, ok := records.Index(i).Addr().Interface().(BaseModelI); ok {
+					updatedRecords = append(updatedRecords, record)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, record := range updatedRecords {
+		err = repo.publishChange(ctx, ChangeOpUpdated, record.GetID(), record)
+		if err != nil {
+			return affected, err
+		}
+	}
+	return affected, nil
+}
+
+// columnUpdates validates the supplied fields against the model, returning them keyed by column
+// along with the modification time, version bump and for auditable models the updating subject every update has to carry
+func (repo *BaseRepository) columnUpdates(db *gorm.DB, instance BaseModelI, fields map[string]any) (map[string]any, error) {
+
+	stmt := &gorm.Statement{DB: db}
+	err := stmt.Parse(instance)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]any, len(fields)+2)
+	for column, value := range fields {
+		field := stmt.Schema.LookUpField(column)
+		if field == nil || field.DBName == "" {
+			return nil, WrapError(ErrorCodeInvalidArgument, ErrInvalidUpdate, fmt.Sprintf("%s is not a valid column", column))
+		}
+
+		if _, ok := immutableColumns[field.DBName]; ok {
+			return nil, WrapError(ErrorCodeInvalidArgument, ErrInvalidUpdate, fmt.Sprintf("%s can not be updated", column))
+		}
+
+		updates[field.DBName] = value
+	}
+
+	updates["modified_at"] = time.Now()
+	updates["version"] = gorm.Expr("version + ?", 1)
+
+	if _, ok := instance.(Auditable); ok {
+		if subject := auditSubject(db.Statement.Context); subject != "" {
+			updates["updated_by"] = subject
+		}
+	}
+	return updates, nil
+}
+
+// Columns resolves the supplied model field or column names to their database column names,
+// failing with ErrInvalidColumn when any of them does not belong to the model.
+func (repo *BaseRepository) Columns(fields ...string) ([]string, error) {
+
+	schemaFields, err := repo.lookUpFields(fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(schemaFields))
+	for _, field := range schemaFields {
+		columns = append(columns, field.DBName)
+	}
+	return columns, nil
+}
+
+func (repo *BaseRepository) lookUpFields(names ...string) ([]*schema.Field, error) {
+
+	modelSchema, err := schema.Parse(repo.instanceCreator(), &repositorySchemaCache, repo.namingStrategy())
+	if err != nil {
+		return nil, err
+	}
+
+	fields := make([]*schema.Field, 0, len(names))
+	for _, name := range names {
+		field := modelSchema.LookUpField(name)
+		if field == nil || field.DBName == "" {
+			return nil, WrapError(ErrorCodeInvalidArgument, ErrInvalidColumn, fmt.Sprintf("%s is not a valid field", name))
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// List loads a page of records into result, reading only the supplied columns when any are given
+func (repo *BaseRepository) List(ctx context.Context, result any, offset, limit int, columns ...string) error {
+
+	db := repo.getReadDb().WithContext(ctx)
+	if len(columns) > 0 {
+		dbColumns, err := repo.Columns(columns...)
+		if err != nil {
+			return err
+		}
+		db = db.Select(dbColumns)
+	}
+
+	return db.Offset(offset).Limit(limit).Find(result).Error
+}
+
+// keyValue converts an id to the type of the model primary key, integer keys have to be valid numbers
+// and any other id fails with gorm.ErrRecordNotFound as no record can have it.
+func (repo *BaseRepository) keyValue(id string) (any, error) {
+
+	modelSchema, err := schema.Parse(repo.instanceCreator(), &repositorySchemaCache, repo.namingStrategy())
+	if err != nil {
+		return nil, err
+	}
+
+	primaryField := modelSchema.PrioritizedPrimaryField
+	if primaryField == nil || (primaryField.DataType != schema.Int && primaryField.DataType != schema.Uint) {
+		return id, nil
+	}
+
+	key, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid id : %w", id, gorm.ErrRecordNotFound)
+	}
+	return key, nil
+}
+
+func (repo *BaseRepository) namingStrategy() schema.Namer {
+	if repo.readDb != nil && repo.readDb.Config != nil && repo.readDb.NamingStrategy != nil {
+		return repo.readDb.NamingStrategy
+	}
+	return schema.NamingStrategy{}
 }
@@ -0,0 +1,68 @@
+package frame
+
+import "context"
+
+// Auditable is implemented by models that record the subject who created and last updated them.
+// BaseRepository fills these in from the authentication claims in the context on create and update.
+type Auditable interface {
+	SetCreatedBy(subject string)
+	SetUpdatedBy(subject string)
+}
+
+// AuditFields can be embedded in a model next to BaseModel to make it Auditable
+type AuditFields struct {
+	CreatedBy string `gorm:"type:varchar(50);"`
+	UpdatedBy string `gorm:"type:varchar(50);"`
+}
+
+func (a *AuditFields) SetCreatedBy(subject string) {
+	a.CreatedBy = subject
+}
+
+func (a *AuditFields) SetUpdatedBy(subject string) {
+	a.UpdatedBy = subject
+}
+
+// auditSubject returns the authenticated subject in the context, empty when there is none
+func auditSubject(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	claims := ClaimsFromContext(ctx)
+	if claims == nil {
+		return ""
+	}
+	return claims.Subject
+}
+
+// auditCreated records the authenticated subject as the creator of auditable instances
+func auditCreated(ctx context.Context, instance BaseModelI) {
+	auditable, ok := instance.(Auditable)
+	if !ok {
+		return
+	}
+
+	subject := auditSubject(ctx)
+	if subject == "" {
+		return
+	}
+
+	auditable.SetCreatedBy(subject)
+	auditable.SetUpdatedBy(subject)
+}
+
+// auditUpdated records the authenticated subject as the last one to update auditable instances
+func auditUpdated(ctx context.Context, instance BaseModelI) {
+	auditable, ok := instance.(Auditable)
+	if !ok {
+		return
+	}
+
+	subject := auditSubject(ctx)
+	if subject == "" {
+		return
+	}
+
+	auditable.SetUpdatedBy(subject)
+}
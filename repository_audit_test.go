@@ -0,0 +1,115 @@
+package frame_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testAuditedModel struct {
+	frame.BaseModel
+	frame.AuditFields
+	Name string
+}
+
+func TestBaseRepository_AuditColumns(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	var updateVars []any
+	err := db.Callback().Update().After("gorm:update").Register("test:capture", func(db *gorm.DB) {
+		updateVars = db.Statement.Vars
+	})
+	if err != nil {
+		t.Fatalf("could not register capture callback : %s", err)
+	}
+
+	repo := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testAuditedModel{}
+	})
+
+	claims := &frame.AuthenticationClaims{}
+	claims.Subject = "profile-1"
+	ctx := claims.ClaimsToContext(context.Background())
+
+	entity := &testAuditedModel{Name: "audited"}
+	err = repo.CreateIdempotent(ctx, entity)
+	if err != nil {
+		t.Fatalf("Could not create entity : %s", err)
+	}
+
+	if entity.CreatedBy != "profile-1" || entity.UpdatedBy != "profile-1" {
+		t.Errorf("Audit columns should be set from the context subject, got %+v", entity.AuditFields)
+	}
+
+	_, err = repo.UpdateFields(ctx, entity.GetID(), map[string]any{"name": "patched"})
+	if err != nil {
+		t.Fatalf("Could not patch entity : %s", err)
+	}
+
+	found := false
+	for _, v := range updateVars {
+		if v == "profile-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Partial updates should set the updating subject, got %v", updateVars)
+	}
+
+	_, err = repo.UpdateFields(ctx, entity.GetID(), map[string]any{"created_by": "other"})
+	if err == nil {
+		t.Errorf("Patching an audit column should fail")
+	}
+
+	anonymous := &testAuditedModel{Name: "anonymous"}
+	err = repo.CreateIdempotent(context.Background(), anonymous)
+	if err != nil {
+		t.Fatalf("Could not create entity : %s", err)
+	}
+
+	if anonymous.CreatedBy != "" || anonymous.UpdatedBy != "" {
+		t.Errorf("Audit columns should be empty without a subject, got %+v", anonymous.AuditFields)
+	}
+}
+
+func TestRESTResource_AuditColumns(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	ctx, srv := frame.NewService("Test REST Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	repo := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testAuditedModel{}
+	})
+	resource := frame.NewRESTResource[testAuditedModel](srv, repo, "/audited")
+
+	claims := &frame.AuthenticationClaims{}
+	claims.Subject = "profile-1"
+
+	body := `{"Name": "audited", "CreatedBy": "someone-else", "UpdatedBy": "someone-else"}`
+	req := httptest.NewRequest(http.MethodPost, "/audited", strings.NewReader(body))
+	req = req.WithContext(claims.ClaimsToContext(req.Context()))
+	rr := httptest.NewRecorder()
+	resource.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("create returned %d : %s", rr.Code, rr.Body.String())
+	}
+
+	var created testAuditedModel
+	err := json.Unmarshal(rr.Body.Bytes(), &created)
+	if err != nil {
+		t.Fatalf("could not decode created record : %s", err)
+	}
+
+	if created.CreatedBy != "profile-1" || created.UpdatedBy != "profile-1" {
+		t.Errorf("created by %q updated by %q, expected the authenticated subject", created.CreatedBy, created.UpdatedBy)
+	}
+}
@@ -0,0 +1,281 @@
+package frame
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RepositoryCache stores encoded models for a CachedRepository, it can be backed by a shared cache like redis
+type RepositoryCache interface {
+	// Get returns the value stored under key and whether there was one
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+// CachedRepository decorates a BaseRepository so reads by id are served from a cache, while its writes
+// invalidate the cached records they change. Every other method is that of the underlying repository.
+type CachedRepository[T any, PT interface {
+	*T
+	BaseModelI
+}] struct {
+	*BaseRepository
+	cache RepositoryCache
+	keyFn func(ctx context.Context, id string) string
+	ttl   time.Duration
+}
+
+// NewCachedRepository wraps repo, keeping the records it reads by id in cache for ttl under the key produced by keyFn.
+// Without a keyFn records are keyed by the model type, the tenant and partition of the claims in the context and the id,
+// so a record read for one tenant is never served to another. Writes invalidate the key of their context along with
+// the key used by reads without tenancy claims, custom keys have to separate tenants the same way.
+func NewCachedRepository[T any, PT interface {
+	*T
+	BaseModelI
+}](repo *BaseRepository, cache RepositoryCache, keyFn func(ctx context.Context, id string) string, ttl time.Duration) *CachedRepository[T, PT] {
+	if keyFn == nil {
+		typeName := reflect.TypeFor[T]().String()
+		keyFn = func(ctx context.Context, id string) string {
+			tenantID, partitionID := cacheTenancy(ctx)
+			return typeName + ":" + tenantID + ":" + partitionID + ":" + id
+		}
+	}
+	return &CachedRepository[T, PT]{BaseRepository: repo, cache: cache, keyFn: keyFn, ttl: ttl}
+}
+
+// cacheTenancy obtains the tenant and partition reads with the context are scoped to, empty when they are not scoped
+func cacheTenancy(ctx context.Context) (string, string) {
+	claims := ClaimsFromContext(ctx)
+	if claims == nil || IsTenancyChecksOnClaimSkipped(ctx) {
+		return "", ""
+	}
+	return claims.GetTenantId(), claims.GetPartitionId()
+}
+
+// dbContext obtains the context db was bound to
+func dbContext(db *gorm.DB) context.Context {
+	if db == nil || db.Statement == nil || db.Statement.Context == nil {
+		return context.Background()
+	}
+	return db.Statement.Context
+}
+
+// cached loads the record with id from the cache into result, reporting whether it was there
+func (cr *CachedRepository[T, PT]) cached(ctx context.Context, id string, result any) bool {
+	value, ok, err := cr.cache.Get(ctx, cr.keyFn(ctx, id))
+	if err != nil || !ok {
+		return false
+	}
+	return json.Unmarshal(value, result) == nil
+}
+
+func (cr *CachedRepository[T, PT]) store(ctx context.Context, id string, value []byte) {
+	_ = cr.cache.Set(ctx, cr.keyFn(ctx, id), value, cr.ttl)
+}
+
+// invalidate removes the cached records with ids for the tenancy of ctx and for unscoped reads
+func (cr *CachedRepository[T, PT]) invalidate(ctx context.Context, ids ...string) error {
+	unscopedCtx := SkipTenancyChecksOnClaims(ctx)
+
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, cr.keyFn(ctx, id))
+		if unscopedKey := cr.keyFn(unscopedCtx, id); unscopedKey != keys[len(keys)-1] {
+			keys = append(keys, unscopedKey)
+		}
+	}
+	return cr.cache.Delete(ctx, keys...)
+}
+
+// GetByID loads the record with id into result, from the cache when it is there.
+// Concurrent misses for the same record share a single datastore read.
+func (cr *CachedRepository[T, PT]) GetByID(id string, result BaseModelI) error {
+	ctx := dbContext(cr.readDb)
+	if cr.cached(ctx, id, result) {
+		return nil
+	}
+
+	value, err := SingleFlight(ctx, cr.keyFn(ctx, id), func(ctx context.Context) ([]byte, error) {
+		instance := PT(new(T))
+		err := cr.BaseRepository.GetByID(id, instance)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(instance)
+		if err != nil {
+			return nil, err
+		}
+		cr.store(ctx, id, value)
+		return value, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(value, result)
+}
+
+// GetByIDs loads the records with the supplied ids in that order, reading only those missing from the cache
+// from the datastore. Ids without a record are left out of the result.
+func (cr *CachedRepository[T, PT]) GetByIDs(ctx context.Context, ids []string) ([]PT, error) {
+
+	found := make(map[string]PT, len(ids))
+	var missingIDs []string
+	var missingKeys []any
+	for _, id := range ids {
+		instance := PT(new(T))
+		if cr.cached(ctx, id, instance) {
+			found[id] = instance
+			continue
+		}
+
+		key, err := cr.keyValue(id)
+		if err != nil {
+			continue
+		}
+		missingIDs = append(missingIDs, cr.keyFn(ctx, id))
+		missingKeys = append(missingKeys, key)
+	}
+
+	if len(missingKeys) > 0 {
+		slices.Sort(missingIDs)
+		loaded, err := SingleFlight(ctx, strings.Join(missingIDs, ","), func(ctx context.Context) ([][]byte, error) {
+			var records []T
+			err := cr.getReadDb().WithContext(ctx).Preload(clause.Associations).Where("id IN ?", missingKeys).Find(&records).Error
+			if err != nil {
+				return nil, err
+			}
+
+			values := make([][]byte, 0, len(records))
+			for i := range records {
+				value, err0 := json.Marshal(PT(&records[i]))
+				if err0 != nil {
+					return nil, err0
+				}
+				cr.store(ctx, PT(&records[i]).GetID(), value)
+				values = append(values, value)
+			}
+			return values, nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// Callers sharing the read each decode their own copy, so none of them share slices, maps or associations
+		for _, value := range loaded {
+			instance := PT(new(T))
+			err = json.Unmarshal(value, instance)
+			if err != nil {
+				return nil, err
+			}
+			found[instance.GetID()] = instance
+		}
+	}
+
+	results := make([]PT, 0, len(found))
+	for _, id := range ids {
+		if instance, ok := found[id]; ok {
+			results = append(results, instance)
+		}
+	}
+	return results, nil
+}
+
+// Save saves instance and invalidates its cached record
+func (cr *CachedRepository[T, PT]) Save(instance BaseModelI) error {
+	err := cr.BaseRepository.Save(instance)
+	return errors.Join(err, cr.invalidate(dbContext(cr.writeDb), instance.GetID()))
+}
+
+// Delete removes the record with id and invalidates its cached record
+func (cr *CachedRepository[T, PT]) Delete(id string) error {
+	err := cr.BaseRepository.Delete(id)
+	return errors.Join(err, cr.invalidate(dbContext(cr.writeDb), id))
+}
+
+// UpdateFields updates the record with id like BaseRepository.UpdateFields and invalidates its cached record
+func (cr *CachedRepository[T, PT]) UpdateFields(ctx context.Context, id string, fields map[string]any) (int64, error) {
+	affected, err := cr.BaseRepository.UpdateFields(ctx, id, fields)
+	return affected, errors.Join(err, cr.invalidate(ctx, id))
+}
+
+// BulkUpdate updates the records with ids like BaseRepository.BulkUpdate and invalidates their cached records
+func (cr *CachedRepository[T, PT]) BulkUpdate(ctx context.Context, ids []string, fields map[string]any) (int64, error) {
+	affected, err := cr.BaseRepository.BulkUpdate(ctx, ids, fields)
+	return affected, errors.Join(err, cr.invalidate(ctx, ids...))
+}
+
+// memoryRepositoryCacheSweepInterval is how often expired entries that are not read again are removed
+const memoryRepositoryCacheSweepInterval = time.Minute
+
+type memoryRepositoryCacheEntry struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+type memoryRepositoryCache struct {
+	mu        sync.Mutex
+	entries   map[string]memoryRepositoryCacheEntry
+	lastSweep time.Time
+}
+
+// NewMemoryRepositoryCache creates a RepositoryCache local to the process
+func NewMemoryRepositoryCache() RepositoryCache {
+	return &memoryRepositoryCache{entries: map[string]memoryRepositoryCacheEntry{}, lastSweep: time.Now()}
+}
+
+func (mc *memoryRepositoryCache) Get(_ context.Context, key string) ([]byte, bool, error) {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	entry, ok := mc.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(mc.entries, key)
+		return nil, false, nil
+	}
+	return entry.value, true, nil
+}
+
+func (mc *memoryRepositoryCache) Set(_ context.Context, key string, value []byte, ttl time.Duration) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(mc.lastSweep) >= memoryRepositoryCacheSweepInterval {
+		mc.sweep(now)
+	}
+
+	mc.entries[key] = memoryRepositoryCacheEntry{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// sweep removes the expired entries, it is called with the lock held
+func (mc *memoryRepositoryCache) sweep(now time.Time) {
+	for key, entry := range mc.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(mc.entries, key)
+		}
+	}
+	mc.lastSweep = now
+}
+
+func (mc *memoryRepositoryCache) Delete(_ context.Context, keys ...string) error {
+	mc.mu.Lock()
+	defer mc.mu.Unlock()
+	for _, key := range keys {
+		delete(mc.entries, key)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package frame_test
+
+import (
+	"context"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func countQueries(t *testing.T, db *gorm.DB) func() int {
+	t.Helper()
+
+	count := 0
+	err := db.Callback().Query().After("gorm:query").Register("test:count", func(_ *gorm.DB) {
+		count++
+	})
+	if err != nil {
+		t.Fatalf("could not register counting callback : %s", err)
+	}
+
+	return func() int { return count }
+}
+
+func TestCachedRepository_GetByID(t *testing.T) {
+
+	db := dryRunDB(t)
+	queries := countQueries(t, db)
+
+	base := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testRepositoryModel{}
+	})
+	repo := frame.NewCachedRepository[testRepositoryModel](base, frame.NewMemoryRepositoryCache(), nil, time.Minute)
+
+	var _ frame.BaseRepositoryI = repo
+
+	read := func() {
+		err := repo.GetByID("cached-1", &testRepositoryModel{})
+		if err != nil {
+			t.Fatalf("could not get record : %s", err)
+		}
+	}
+
+	read()
+	read()
+	if queries() != 1 {
+		t.Fatalf("repeated GetByID ran %d queries, expected the second to be served from the cache", queries())
+	}
+
+	_, err := repo.UpdateFields(context.Background(), "cached-1", map[string]any{"name": "renamed"})
+	if err != nil {
+		t.Fatalf("could not update record : %s", err)
+	}
+
+	read()
+	if queries() != 2 {
+		t.Fatalf("GetByID after an update ran %d queries in total, expected the update to invalidate the entry", queries())
+	}
+
+	model := &testRepositoryModel{}
+	model.ID = "cached-1"
+	err = repo.Save(model)
+	if err != nil {
+		t.Fatalf("could not save record : %s", err)
+	}
+
+	read()
+	if queries() != 3 {
+		t.Fatalf("GetByID after a save ran %d queries in total, expected the save to invalidate the entry", queries())
+	}
+
+	_, err = repo.GetByIDs(context.Background(), []string{"cached-1", "cached-2"})
+	if err != nil {
+		t.Fatalf("could not get records : %s", err)
+	}
+	if queries() != 4 {
+		t.Fatalf("GetByIDs ran %d queries in total, expected a single query for the uncached ids", queries())
+	}
+}
+
+func TestCachedRepository_TenantIsolation(t *testing.T) {
+
+	db := dryRunDB(t)
+	queries := countQueries(t, db)
+
+	cache := frame.NewMemoryRepositoryCache()
+	tenantRepo := func(tenantID string) *frame.CachedRepository[testRepositoryModel, *testRepositoryModel] {
+		claims := frame.AuthenticationClaims{TenantID: tenantID, PartitionID: "partition", AccessID: "access"}
+		claims.Subject = "profile"
+		ctx := claims.ClaimsToContext(context.Background())
+
+		base := frame.NewBaseRepository(db.WithContext(ctx), db.WithContext(ctx), func() frame.BaseModelI {
+			return &testRepositoryModel{}
+		})
+		return frame.NewCachedRepository[testRepositoryModel](base, cache, nil, time.Minute)
+	}
+
+	tenantA := tenantRepo("tenant-a")
+	tenantB := tenantRepo("tenant-b")
+
+	for _, repo := range []*frame.CachedRepository[testRepositoryModel, *testRepositoryModel]{tenantA, tenantA, tenantB} {
+		err := repo.GetByID("shared-1", &testRepositoryModel{})
+		if err != nil {
+			t.Fatalf("could not get record : %s", err)
+		}
+	}
+
+	if queries() != 2 {
+		t.Fatalf("reads from two tenants ran %d queries, expected a record cached for one tenant not to be served to the other", queries())
+	}
+}
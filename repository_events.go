@@ -0,0 +1,63 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChangeOp is the kind of change a repository applied to an entity
+type ChangeOp string
+
+const (
+	ChangeOpCreated ChangeOp = "created"
+	ChangeOpUpdated ChangeOp = "updated"
+	ChangeOpDeleted ChangeOp = "deleted"
+)
+
+// EntityChanged is the event published by repositories configured with WithChangeEventPublisher
+type EntityChanged struct {
+	Op     ChangeOp `json:"op"`
+	ID     string   `json:"id"`
+	Entity any      `json:"entity,omitempty"`
+}
+
+// RepositoryOption configures optional behaviour of a BaseRepository
+type RepositoryOption func(repo *BaseRepository)
+
+// WithChangeEventPublisher RepositoryOption that publishes an EntityChanged event to the queue reference
+// registered on service after every successful create, update or delete made through the repository.
+// Events are published once the change is written, a failure to publish is returned to the caller.
+// To commit changes and their events together, construct the repository inside Service.InTransaction
+// on the supplied transaction bound to the supplied context, the events are then written to its outbox.
+func WithChangeEventPublisher(service *Service, reference string) RepositoryOption {
+	return func(repo *BaseRepository) {
+		repo.changePublisher = service
+		repo.changeReference = reference
+	}
+}
+
+// publishChange emits the change event when the repository has a change publisher
+func (repo *BaseRepository) publishChange(ctx context.Context, op ChangeOp, id string, entity any) error {
+
+	if repo.changePublisher == nil {
+		return nil
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := repo.changePublisher.Publish(ctx, repo.changeReference, EntityChanged{Op: op, ID: id, Entity: entity})
+	if err != nil {
+		return fmt.Errorf("%s change of %s was saved but its event could not be published : %w", op, id, err)
+	}
+	return nil
+}
+
+// writeContext obtains the context the write connection was bound to
+func (repo *BaseRepository) writeContext() context.Context {
+	if repo.writeDb == nil || repo.writeDb.Statement == nil {
+		return nil
+	}
+	return repo.writeDb.Statement.Context
+}
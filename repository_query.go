@@ -0,0 +1,264 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"maps"
+	"slices"
+)
+
+type conditionOperator string
+
+const (
+	operatorEq      conditionOperator = "="
+	operatorNeq     conditionOperator = "<>"
+	operatorGt      conditionOperator = ">"
+	operatorLt      conditionOperator = "<"
+	operatorIn      conditionOperator = "IN"
+	operatorLike    conditionOperator = "LIKE"
+	operatorBetween conditionOperator = "BETWEEN"
+	operatorAnd     conditionOperator = "AND"
+	operatorOr      conditionOperator = "OR"
+)
+
+// Condition is a filter of a SearchQuery, created with Eq, Neq, Gt, Lt, In, Like, Between and grouped with And or Or
+type Condition struct {
+	field    string
+	operator conditionOperator
+	values   []any
+	group    []Condition
+}
+
+// Eq matches records whose field equals value
+func Eq(field string, value any) Condition {
+	return Condition{field: field, operator: operatorEq, values: []any{value}}
+}
+
+// Neq matches records whose field differs from value
+func Neq(field string, value any) Condition {
+	return Condition{field: field, operator: operatorNeq, values: []any{value}}
+}
+
+// Gt matches records whose field is greater than value
+func Gt(field string, value any) Condition {
+	return Condition{field: field, operator: operatorGt, values: []any{value}}
+}
+
+// Lt matches records whose field is less than value
+func Lt(field string, value any) Condition {
+	return Condition{field: field, operator: operatorLt, values: []any{value}}
+}
+
+// In matches records whose field equals any of the values, no record matches an empty list
+func In(field string, values ...any) Condition {
+	return Condition{field: field, operator: operatorIn, values: values}
+}
+
+// Like matches records whose field matches the sql pattern, for example "ord-%"
+func Like(field string, pattern string) Condition {
+	return Condition{field: field, operator: operatorLike, values: []any{pattern}}
+}
+
+// Between matches records whose field lies between low and high, both inclusive
+func Between(field string, low, high any) Condition {
+	return Condition{field: field, operator: operatorBetween, values: []any{low, high}}
+}
+
+// And matches records satisfying all the conditions
+func And(conditions ...Condition) Condition {
+	return Condition{operator: operatorAnd, group: conditions}
+}
+
+// Or matches records satisfying any of the conditions
+func Or(conditions ...Condition) Condition {
+	return Condition{operator: operatorOr, group: conditions}
+}
+
+// SortField orders search results by a model field, descending when Desc is set
+type SortField struct {
+	Column string
+	Desc   bool
+}
+
+// defaultSearchSort keeps results in a stable order for pagination when a query sets none
+var defaultSearchSort = []SortField{{Column: "created_at"}, {Column: "id"}}
+
+// SearchQuery describes the records a repository search loads.
+// Fields holds plain equality matches while Where adds conditions using any operator, all of them have to hold.
+// Results are ordered by Sort, or by creation time and id when it is empty so pages never overlap.
+type SearchQuery struct {
+	Fields map[string]any
+	Sort   []SortField
+	Offset int
+	Limit  int
+
+	conditions []Condition
+	paginator  *Paginator
+}
+
+// NewSearchQuery creates a query matching the supplied conditions
+func NewSearchQuery(conditions ...Condition) *SearchQuery {
+	return &SearchQuery{conditions: conditions}
+}
+
+// Where adds conditions that matching records have to satisfy
+func (q *SearchQuery) Where(conditions ...Condition) *SearchQuery {
+	q.conditions = append(q.conditions, conditions...)
+	return q
+}
+
+// OrderBy adds a field to sort results by
+func (q *SearchQuery) OrderBy(column string, desc bool) *SearchQuery {
+	q.Sort = append(q.Sort, SortField{Column: column, Desc: desc})
+	return q
+}
+
+// Paginate limits the query to a page of limit records starting at offset
+func (q *SearchQuery) Paginate(offset, limit int) *SearchQuery {
+	q.Offset = offset
+	q.Limit = limit
+	return q
+}
+
+// PaginateWith loads the records in the batches of the paginator instead of a fixed page, each Find loads the next
+// batch and the paginator total is set to the count of matching records when the first batch is loaded e.g.
+//
+//	paginator := NewPaginator(1000, 100)
+//	query := NewSearchQuery(Eq("status", "active")).PaginateWith(paginator)
+//	for paginator.CanLoad() {
+//		var batch []Order
+//		err := repo.Find(ctx, query, &batch)
+//		...
+//		if paginator.Stop(len(batch)) {
+//			break
+//		}
+//	}
+func (q *SearchQuery) PaginateWith(paginator *Paginator) *SearchQuery {
+	q.paginator = paginator
+	return q
+}
+
+// Find loads the records matching query into result, fields used by the query and its sort are validated against the model
+// and values are always passed as bind parameters. Failures to validate are reported as ErrInvalidColumn.
+func (repo *BaseRepository) Find(ctx context.Context, query *SearchQuery, result any) error {
+
+	db := repo.getReadDb().WithContext(ctx)
+
+	expression, err := repo.compileSearchQuery(query)
+	if err != nil {
+		return err
+	}
+
+	if expression != nil {
+		db = db.Where(expression)
+	}
+
+	orderBy, err := repo.compileSearchSort(query.Sort)
+	if err != nil {
+		return err
+	}
+
+	offset, limit := query.Offset, query.Limit
+	if query.paginator != nil {
+		offset, limit = query.paginator.Offset(), query.paginator.BatchSize()
+
+		if offset == 0 {
+			var total int64
+			err = db.Session(&gorm.Session{}).Model(repo.instanceCreator()).Count(&total).Error
+			if err != nil {
+				return err
+			}
+			query.paginator.SetTotal(total)
+		}
+	}
+
+	db = db.Order(orderBy)
+
+	if offset > 0 {
+		db = db.Offset(offset)
+	}
+
+	if limit > 0 {
+		db = db.Limit(limit)
+	}
+
+	return db.Find(result).Error
+}
+
+// compileSearchQuery turns the query filters into a single parameterized expression, nil when nothing is filtered
+func (repo *BaseRepository) compileSearchQuery(query *SearchQuery) (clause.Expression, error) {
+
+	conditions := make([]Condition, 0, len(query.Fields)+len(query.conditions))
+	for _, field := range slices.Sorted(maps.Keys(query.Fields)) {
+		conditions = append(conditions, Eq(field, query.Fields[field]))
+	}
+	conditions = append(conditions, query.conditions...)
+
+	if len(conditions) == 0 {
+		return nil, nil
+	}
+
+	return repo.compileCondition(And(conditions...))
+}
+
+// compileSearchSort validates the sort fields against the model, falling back to the default order
+func (repo *BaseRepository) compileSearchSort(sort []SortField) (clause.OrderBy, error) {
+
+	if len(sort) == 0 {
+		sort = defaultSearchSort
+	}
+
+	orderBy := clause.OrderBy{Columns: make([]clause.OrderByColumn, 0, len(sort))}
+	for _, field := range sort {
+		columns, err := repo.Columns(field.Column)
+		if err != nil {
+			return orderBy, err
+		}
+		orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{Column: clause.Column{Name: columns[0]}, Desc: field.Desc})
+	}
+	return orderBy, nil
+}
+
+func (repo *BaseRepository) compileCondition(condition Condition) (clause.Expression, error) {
+
+	switch condition.operator {
+	case operatorAnd, operatorOr:
+		if len(condition.group) == 0 {
+			return nil, WrapError(ErrorCodeInvalidArgument, ErrInvalidColumn, fmt.Sprintf("%s requires at least one condition", condition.operator))
+		}
+
+		expressions := make([]clause.Expression, 0, len(condition.group))
+		for _, member := range condition.group {
+			expression, err := repo.compileCondition(member)
+			if err != nil {
+				return nil, err
+			}
+			expressions = append(expressions, expression)
+		}
+
+		if condition.operator == operatorOr {
+			return clause.Or(expressions...), nil
+		}
+		return clause.And(expressions...), nil
+	}
+
+	columns, err := repo.Columns(condition.field)
+	if err != nil {
+		return nil, err
+	}
+	column := clause.Column{Name: columns[0]}
+
+	switch condition.operator {
+	case operatorIn:
+		if len(condition.values) == 0 {
+			return clause.Expr{SQL: "1 = 0"}, nil
+		}
+		return clause.Expr{SQL: "? IN ?", Vars: []any{column, condition.values}}, nil
+	case operatorBetween:
+		return clause.Expr{SQL: "? BETWEEN ? AND ?", Vars: []any{column, condition.values[0], condition.values[1]}}, nil
+	default:
+		return clause.Expr{SQL: "? " + string(condition.operator) + " ?", Vars: []any{column, condition.values[0]}}, nil
+	}
+}
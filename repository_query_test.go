@@ -0,0 +1,209 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// captureQueries records the sql and bind parameters of the queries issued on a dry run database
+func captureQueries(t *testing.T, db *gorm.DB) func() (string, []any) {
+	t.Helper()
+
+	var sql string
+	var vars []any
+	err := db.Callback().Query().After("gorm:query").Register("test:capture", func(db *gorm.DB) {
+		sql = db.Statement.SQL.String()
+		vars = db.Statement.Vars
+	})
+	if err != nil {
+		t.Fatalf("could not register capture callback : %s", err)
+	}
+
+	return func() (string, []any) { return sql, vars }
+}
+
+func TestBaseRepository_FindOperators(t *testing.T) {
+
+	db := dryRunDB(t)
+	captured := captureQueries(t, db)
+
+	repo := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testRepositoryModel{}
+	})
+
+	const selectPrefix = `SELECT * FROM "test_repository_models" WHERE `
+	const softDelete = ` AND "test_repository_models"."deleted_at" IS NULL ORDER BY "created_at","id"`
+
+	tests := []struct {
+		name     string
+		query    *frame.SearchQuery
+		wantSQL  string
+		wantVars string
+	}{
+		{name: "Eq", query: frame.NewSearchQuery(frame.Eq("name", "first")),
+			wantSQL: `"name" = $1`, wantVars: "[first]"},
+		{name: "Neq", query: frame.NewSearchQuery(frame.Neq("Name", "first")),
+			wantSQL: `"name" <> $1`, wantVars: "[first]"},
+		{name: "Gt", query: frame.NewSearchQuery(frame.Gt("amount", 5)),
+			wantSQL: `"amount" > $1`, wantVars: "[5]"},
+		{name: "Lt", query: frame.NewSearchQuery(frame.Lt("amount", 5)),
+			wantSQL: `"amount" < $1`, wantVars: "[5]"},
+		{name: "In", query: frame.NewSearchQuery(frame.In("name", "a", "b")),
+			wantSQL: `"name" IN ($1,$2)`, wantVars: "[a b]"},
+		{name: "Empty in", query: frame.NewSearchQuery(frame.In("name")),
+			wantSQL: `1 = 0`, wantVars: "[]"},
+		{name: "Like", query: frame.NewSearchQuery(frame.Like("name", "fir%")),
+			wantSQL: `"name" LIKE $1`, wantVars: "[fir%]"},
+		{name: "Between", query: frame.NewSearchQuery(frame.Between("amount", 1, 9)),
+			wantSQL: `("amount" BETWEEN $1 AND $2)`, wantVars: "[1 9]"},
+		{name: "Fields map", query: &frame.SearchQuery{Fields: map[string]any{"name": "first", "amount": 3}},
+			wantSQL: `("amount" = $1 AND "name" = $2)`, wantVars: "[3 first]"},
+		{name: "Compound", query: frame.NewSearchQuery(frame.Gt("amount", 1),
+			frame.Or(frame.Eq("name", "a"), frame.And(frame.Like("name", "b%"), frame.Lt("amount", 9)))),
+			wantSQL: `("amount" > $1 AND ("name" = $2 OR ("name" LIKE $3 AND "amount" < $4)))`, wantVars: "[1 a b% 9]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []testRepositoryModel
+			err := repo.Find(context.Background(), tt.query, &items)
+			if err != nil {
+				t.Fatalf("could not compile query : %s", err)
+			}
+
+			sql, vars := captured()
+			if want := selectPrefix + tt.wantSQL + softDelete; sql != want {
+				t.Errorf("sql = %s, want %s", sql, want)
+			}
+			if fmt.Sprint(vars) != tt.wantVars {
+				t.Errorf("vars = %v, want %s", vars, tt.wantVars)
+			}
+		})
+	}
+
+	var items []testRepositoryModel
+	err := repo.Find(context.Background(), frame.NewSearchQuery(frame.Or(frame.Eq("name", "a"), frame.Eq("password", "x"))), &items)
+	if !errors.Is(err, frame.ErrInvalidColumn) {
+		t.Errorf("unknown fields should be rejected, got %v", err)
+	}
+}
+
+func TestBaseRepository_FindSort(t *testing.T) {
+
+	db := dryRunDB(t)
+	captured := captureQueries(t, db)
+
+	repo := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testRepositoryModel{}
+	})
+
+	var items []testRepositoryModel
+	query := frame.NewSearchQuery(frame.Gt("amount", 1)).OrderBy("Amount", true).OrderBy("name", false).Paginate(20, 10)
+	err := repo.Find(context.Background(), query, &items)
+	if err != nil {
+		t.Fatalf("could not compile query : %s", err)
+	}
+
+	sql, _ := captured()
+	want := `SELECT * FROM "test_repository_models" WHERE "amount" > $1 AND "test_repository_models"."deleted_at" IS NULL ORDER BY "amount" DESC,"name" LIMIT $2 OFFSET $3`
+	if sql != want {
+		t.Errorf("sql = %s, want %s", sql, want)
+	}
+
+	query = &frame.SearchQuery{Sort: []frame.SortField{{Column: "amount; DROP TABLE x"}}}
+	err = repo.Find(context.Background(), query, &items)
+	if !errors.Is(err, frame.ErrInvalidColumn) {
+		t.Errorf("unknown sort columns should be rejected, got %v", err)
+	}
+}
+
+func TestBaseRepository_FindCompound(t *testing.T) {
+	repo, cleanup := getTestRepository(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	name := fmt.Sprintf("find-%s", t.Name())
+
+	for _, amount := range []int{1, 5, 10, 20} {
+		err := repo.Save(&testRepositoryModel{Name: fmt.Sprintf("%s-%d", name, amount), Amount: amount})
+		if err != nil {
+			t.Errorf("Could not create entity : %s", err)
+			return
+		}
+	}
+
+	query := frame.NewSearchQuery(
+		frame.Like("name", name+"-%"),
+		frame.Or(frame.Between("amount", 4, 11), frame.Eq("amount", 20)),
+		frame.Neq("amount", 10),
+	)
+
+	var items []testRepositoryModel
+	err := repo.Find(ctx, query, &items)
+	if err != nil {
+		t.Errorf("Could not search entities : %s", err)
+		return
+	}
+
+	found := map[int]bool{}
+	for _, item := range items {
+		found[item.Amount] = true
+	}
+
+	if len(items) != 2 || !found[5] || !found[20] {
+		t.Errorf("Compound query should match amounts 5 and 20, got %+v", items)
+	}
+
+	query.OrderBy("amount", true)
+	err = repo.Find(ctx, query, &items)
+	if err != nil || len(items) != 2 || items[0].Amount != 20 || items[1].Amount != 5 {
+		t.Errorf("Results should be sorted by descending amount, got %+v : %v", items, err)
+	}
+}
+
+func TestBaseRepository_FindPaginateWith(t *testing.T) {
+
+	db := dryRunDB(t)
+
+	var queries []string
+	err := db.Callback().Query().After("gorm:query").Register("test:collect", func(db *gorm.DB) {
+		queries = append(queries, db.Statement.SQL.String())
+	})
+	if err != nil {
+		t.Fatalf("could not register collecting callback : %s", err)
+	}
+
+	repo := frame.NewBaseRepository(db, db, func() frame.BaseModelI {
+		return &testRepositoryModel{}
+	})
+
+	paginator := frame.NewPaginator(25, 10)
+	query := frame.NewSearchQuery(frame.Eq("name", "paged")).PaginateWith(paginator)
+
+	err = repo.Find(context.Background(), query, &[]testRepositoryModel{})
+	if err != nil {
+		t.Fatalf("could not find first batch : %s", err)
+	}
+	paginator.Stop(10)
+
+	err = repo.Find(context.Background(), query, &[]testRepositoryModel{})
+	if err != nil {
+		t.Fatalf("could not find second batch : %s", err)
+	}
+
+	want := []string{
+		`SELECT count(*) FROM "test_repository_models" WHERE "name" = $1 AND "test_repository_models"."deleted_at" IS NULL`,
+		`SELECT * FROM "test_repository_models" WHERE "name" = $1 AND "test_repository_models"."deleted_at" IS NULL ORDER BY "created_at","id" LIMIT $2`,
+		`SELECT * FROM "test_repository_models" WHERE "name" = $1 AND "test_repository_models"."deleted_at" IS NULL ORDER BY "created_at","id" LIMIT $2 OFFSET $3`,
+	}
+	if !slices.Equal(queries, want) {
+		t.Errorf("paginated finds ran\n%s\nexpected the total counted once then batches\n%s",
+			strings.Join(queries, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,151 @@
+package frame
+
+import (
+	"context"
+	"net/http"
+)
+
+// WriteJSON writes v as a json response with the supplied status code.
+// The payload is encoded using the codec of the service in the context, encoding errors are logged and returned.
+func WriteJSON(ctx context.Context, w http.ResponseWriter, status int, v any) error {
+
+	data, err := jsonCodecFromContext(ctx).Marshal(v)
+	if err != nil {
+		logResponseError(ctx, err, "WriteJSON -- could not encode response")
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err = w.Write(data)
+	if err != nil {
+		logResponseError(ctx, err, "WriteJSON -- could not write response")
+		return err
+	}
+
+	return nil
+}
+
+// WriteJSONStream writes the items received on the supplied channel as a json array with the supplied status code.
+// Each item is encoded and flushed to the client as it arrives so large payloads are never held in memory,
+// writing stops once the channel is closed or the context is done.
+func WriteJSONStream(ctx context.Context, w http.ResponseWriter, status int, items <-chan any) error {
+
+	codec := jsonCodecFromContext(ctx)
+	controller := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	_, err := w.Write([]byte("["))
+	if err != nil {
+		logResponseError(ctx, err, "WriteJSONStream -- could not write response")
+		return err
+	}
+
+	isFirst := true
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case item, ok := <-items:
+			if !ok {
+				_, err = w.Write([]byte("]"))
+				if err != nil {
+					logResponseError(ctx, err, "WriteJSONStream -- could not write response")
+				}
+				return err
+			}
+
+			data, err0 := codec.Marshal(item)
+			if err0 != nil {
+				logResponseError(ctx, err0, "WriteJSONStream -- could not encode response item")
+				return err0
+			}
+
+			if !isFirst {
+				data = append([]byte(","), data...)
+			}
+			isFirst = false
+
+			_, err = w.Write(data)
+			if err != nil {
+				logResponseError(ctx, err, "WriteJSONStream -- could not write response")
+				return err
+			}
+
+			// Writers that can not flush still receive the whole stream once the handler returns
+			_ = controller.Flush()
+		}
+	}
+}
+
+// ChunkedWriter writes json values as newline delimited chunks, flushing each one to the client as it is written.
+// It suits long running handlers reporting their progress, flushing goes through http.ResponseController
+// so it keeps working behind middleware whose response writers implement Unwrap.
+type ChunkedWriter struct {
+	w          http.ResponseWriter
+	controller *http.ResponseController
+	started    bool
+}
+
+// NewChunkedWriter creates a ChunkedWriter for the supplied response, the status is sent with the first chunk
+func NewChunkedWriter(w http.ResponseWriter) *ChunkedWriter {
+	return &ChunkedWriter{w: w, controller: http.NewResponseController(w)}
+}
+
+// WriteChunk encodes v using the codec of the service in the context and flushes it to the client
+func (cw *ChunkedWriter) WriteChunk(ctx context.Context, v any) error {
+
+	data, err := jsonCodecFromContext(ctx).Marshal(v)
+	if err != nil {
+		logResponseError(ctx, err, "WriteChunk -- could not encode chunk")
+		return err
+	}
+
+	if !cw.started {
+		cw.started = true
+		cw.w.Header().Set("Content-Type", "application/x-ndjson")
+		cw.w.Header().Set("X-Content-Type-Options", "nosniff")
+		cw.w.WriteHeader(http.StatusOK)
+	}
+
+	_, err = cw.w.Write(append(data, '\n'))
+	if err != nil {
+		logResponseError(ctx, err, "WriteChunk -- could not write chunk")
+		return err
+	}
+
+	err = cw.controller.Flush()
+	if err != nil {
+		logResponseError(ctx, err, "WriteChunk -- could not flush chunk")
+		return err
+	}
+	return nil
+}
+
+func logResponseError(ctx context.Context, err error, message string) {
+	service := FromContext(ctx)
+	if service == nil {
+		return
+	}
+	service.L(ctx).WithError(err).Error(message)
+}
+
+// WriteError writes err as a json error response, the status is derived from the error code
+// and only the client safe message of the error is included in the payload.
+func WriteError(ctx context.Context, w http.ResponseWriter, err error) error {
+
+	code := ErrorCodeOf(err)
+	if code == ErrorCodeInternal {
+		logResponseError(ctx, err, "WriteError -- request failed")
+	}
+
+	return WriteJSON(ctx, w, code.HTTPStatus(), map[string]string{
+		"code":  string(code),
+		"error": errorMessage(err),
+	})
+}
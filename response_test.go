@@ -0,0 +1,160 @@
+package frame_test
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type responsePayload struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestWriteJSON(t *testing.T) {
+
+	ctx, _ := frame.NewService("Test Srv")
+
+	recorder := httptest.NewRecorder()
+
+	err := frame.WriteJSON(ctx, recorder, http.StatusCreated, responsePayload{Name: "frame", Count: 2})
+	if err != nil {
+		t.Errorf("could not write json response : %s", err)
+		return
+	}
+
+	if recorder.Code != http.StatusCreated {
+		t.Errorf("response status %d is not %d", recorder.Code, http.StatusCreated)
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("response content type %s is not application/json", contentType)
+	}
+
+	if body := recorder.Body.String(); body != `{"name":"frame","count":2}` {
+		t.Errorf("unexpected response body %s", body)
+	}
+}
+
+func TestWriteJSON_EncodeError(t *testing.T) {
+
+	recorder := httptest.NewRecorder()
+
+	err := frame.WriteJSON(context.Background(), recorder, http.StatusOK, map[string]any{"invalid": make(chan int)})
+	if err == nil {
+		t.Errorf("encoding an unsupported value should fail")
+	}
+
+	if recorder.Code != http.StatusInternalServerError {
+		t.Errorf("response status %d is not %d", recorder.Code, http.StatusInternalServerError)
+	}
+}
+
+func TestWriteJSONStream(t *testing.T) {
+
+	ctx, _ := frame.NewService("Test Srv")
+
+	items := make(chan any, 3)
+	items <- responsePayload{Name: "a", Count: 1}
+	items <- responsePayload{Name: "b", Count: 2}
+	close(items)
+
+	recorder := httptest.NewRecorder()
+
+	err := frame.WriteJSONStream(ctx, recorder, http.StatusOK, items)
+	if err != nil {
+		t.Errorf("could not stream json response : %s", err)
+		return
+	}
+
+	if contentType := recorder.Header().Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("response content type %s is not application/json", contentType)
+	}
+
+	if body := recorder.Body.String(); body != `[{"name":"a","count":1},{"name":"b","count":2}]` {
+		t.Errorf("unexpected response body %s", body)
+	}
+
+	if !recorder.Flushed {
+		t.Errorf("streamed response items should be flushed")
+	}
+}
+
+// unwrappingWriter hides the flusher of the underlying writer, like middleware that only implements Unwrap
+type unwrappingWriter struct {
+	rw http.ResponseWriter
+}
+
+func (w *unwrappingWriter) Header() http.Header         { return w.rw.Header() }
+func (w *unwrappingWriter) Write(p []byte) (int, error) { return w.rw.Write(p) }
+func (w *unwrappingWriter) WriteHeader(status int)      { w.rw.WriteHeader(status) }
+func (w *unwrappingWriter) Unwrap() http.ResponseWriter { return w.rw }
+
+func TestChunkedWriter(t *testing.T) {
+
+	proceed := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		chunked := frame.NewChunkedWriter(w)
+		for i := range 3 {
+			err := chunked.WriteChunk(r.Context(), responsePayload{Name: "progress", Count: i})
+			if err != nil {
+				t.Errorf("could not write chunk : %s", err)
+				return
+			}
+
+			select {
+			case <-proceed:
+			case <-time.After(2 * time.Second):
+				return
+			}
+		}
+	})
+
+	middleware := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(&unwrappingWriter{rw: w}, r)
+		})
+	}
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.HttpHandler(handler), frame.WithHTTPMiddleware(middleware))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/progress")
+	if err != nil {
+		t.Fatalf("could not call service : %s", err)
+	}
+	defer resp.Body.Close()
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/x-ndjson" {
+		t.Errorf("response content type %s is not application/x-ndjson", contentType)
+	}
+
+	reader := bufio.NewReader(resp.Body)
+	for i := range 3 {
+		// The handler only writes the next chunk once this one is read, so every chunk has to be flushed
+		line, err0 := reader.ReadString('\n')
+		if err0 != nil {
+			t.Fatalf("chunk %d was not received : %s", i, err0)
+		}
+
+		expected := fmt.Sprintf(`{"name":"progress","count":%d}`+"\n", i)
+		if line != expected {
+			t.Errorf("chunk %d is %q, want %q", i, line, expected)
+		}
+		proceed <- struct{}{}
+	}
+}
@@ -0,0 +1,369 @@
+package frame
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"gorm.io/gorm"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	restDefaultPageLimit = 50
+	restMaxPageLimit     = 1000
+)
+
+// Validator is implemented by models that check their own consistency before being persisted
+type Validator interface {
+	Validate() error
+}
+
+// RESTResourceOption configures a RESTResource
+type RESTResourceOption func(opts *restResourceOptions)
+
+type restResourceOptions struct {
+	disabledVerbs map[string]struct{}
+}
+
+// WithDisabledVerbs prevents the resource from serving the supplied http methods
+func WithDisabledVerbs(methods ...string) RESTResourceOption {
+	return func(opts *restResourceOptions) {
+		for _, method := range methods {
+			opts.disabledVerbs[strings.ToUpper(method)] = struct{}{}
+		}
+	}
+}
+
+// RESTResource serves json CRUD endpoints for a model backed by a BaseRepository
+type RESTResource[T any, PT interface {
+	*T
+	BaseModelI
+}] struct {
+	service  *Service
+	repo     *BaseRepository
+	basePath string
+	mux      *http.ServeMux
+	options  restResourceOptions
+}
+
+// NewRESTResource creates a handler serving the following endpoints for the model T
+//
+//	GET    basePath       lists records, paged with the limit and offset query parameters
+//	GET    basePath/{id}  loads a single record
+//	POST   basePath       creates a record from the json body
+//	PATCH  basePath/{id}  updates the fields supplied in the json body
+//	DELETE basePath/{id}  removes a record
+//
+// The read endpoints accept a fields query parameter, for example ?fields=id,name,
+// limiting the response to the listed model fields.
+func NewRESTResource[T any, PT interface {
+	*T
+	BaseModelI
+}](service *Service, repo *BaseRepository, basePath string, opts ...RESTResourceOption) *RESTResource[T, PT] {
+
+	res := &RESTResource[T, PT]{
+		service:  service,
+		repo:     repo,
+		basePath: "/" + strings.Trim(basePath, "/"),
+		mux:      http.NewServeMux(),
+		options:  restResourceOptions{disabledVerbs: map[string]struct{}{}},
+	}
+
+	for _, opt := range opts {
+		opt(&res.options)
+	}
+
+	if service != nil {
+		service.addRESTResource(res)
+	}
+
+	itemPath := strings.TrimSuffix(res.basePath, "/") + "/{id}"
+
+	res.handle(http.MethodGet, res.basePath, res.list)
+	res.handle(http.MethodPost, res.basePath, res.create)
+	res.handle(http.MethodGet, itemPath, res.get)
+	res.handle(http.MethodPatch, itemPath, res.update)
+	res.handle(http.MethodDelete, itemPath, res.delete)
+
+	return res
+}
+
+func (res *RESTResource[T, PT]) handle(method, path string, handler http.HandlerFunc) {
+	if _, disabled := res.options.disabledVerbs[method]; disabled {
+		return
+	}
+	res.mux.HandleFunc(method+" "+path, handler)
+}
+
+// Register mounts the resource endpoints on the supplied mux
+func (res *RESTResource[T, PT]) Register(mux *http.ServeMux) {
+	mux.Handle(res.basePath, res)
+	mux.Handle(strings.TrimSuffix(res.basePath, "/")+"/", res)
+}
+
+func (res *RESTResource[T, PT]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if FromContext(r.Context()) == nil {
+		r = r.WithContext(ToContext(r.Context(), res.service))
+	}
+	res.mux.ServeHTTP(w, r)
+}
+
+func (res *RESTResource[T, PT]) list(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	limit, err := queryInt(r, "limit", restDefaultPageLimit)
+	if err != nil || limit <= 0 || limit > restMaxPageLimit {
+		_ = WriteError(ctx, w, NewError(ErrorCodeInvalidArgument, "limit should be between 1 and 1000"))
+		return
+	}
+
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		_ = WriteError(ctx, w, NewError(ErrorCodeInvalidArgument, "offset should not be negative"))
+		return
+	}
+
+	fields, keys, err := res.sparseFields(r)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	var items []T
+	err = res.repo.List(ctx, &items, offset, limit, fields...)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	if items == nil {
+		items = []T{}
+	}
+	res.writeProjected(ctx, w, items, keys)
+}
+
+func (res *RESTResource[T, PT]) get(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	fields, keys, err := res.sparseFields(r)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	key, err := res.repo.keyValue(r.PathValue("id"))
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	db := res.repo.getReadDb().WithContext(ctx)
+	if len(fields) > 0 {
+		columns, _ := res.repo.Columns(fields...)
+		db = db.Select(columns)
+	}
+
+	instance := PT(new(T))
+	err = db.First(instance, "id = ?", key).Error
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	res.writeProjected(ctx, w, instance, keys)
+}
+
+func (res *RESTResource[T, PT]) create(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	instance := PT(new(T))
+	err := BindJSON(r, instance)
+	if err != nil {
+		_ = WriteError(ctx, w, WrapError(ErrorCodeInvalidArgument, err, "request body is not valid json"))
+		return
+	}
+
+	// The id, version, tenancy and audit info are managed by the framework, never taken from the client
+	err = res.repo.resetManagedFields(ctx, instance)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	if validator, ok := any(instance).(Validator); ok {
+		err = validator.Validate()
+		if err != nil {
+			_ = WriteError(ctx, w, WrapError(ErrorCodeInvalidArgument, err, err.Error()))
+			return
+		}
+	}
+
+	err = res.repo.withContext(ctx).Save(instance)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	_ = WriteJSON(ctx, w, http.StatusCreated, instance)
+}
+
+func (res *RESTResource[T, PT]) update(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id := r.PathValue("id")
+
+	body := map[string]any{}
+	err := BindJSON(r, &body)
+	if err != nil {
+		_ = WriteError(ctx, w, WrapError(ErrorCodeInvalidArgument, err, "request body is not valid json"))
+		return
+	}
+
+	affected, err := res.repo.UpdateFields(ctx, id, res.bodyFields(ctx, body))
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	if affected == 0 {
+		_ = WriteError(ctx, w, gorm.ErrRecordNotFound)
+		return
+	}
+
+	key, err := res.repo.keyValue(id)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	instance := PT(new(T))
+	err = res.repo.getReadDb().WithContext(ctx).First(instance, "id = ?", key).Error
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	_ = WriteJSON(ctx, w, http.StatusOK, instance)
+}
+
+func (res *RESTResource[T, PT]) delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := res.repo.withContext(ctx).Delete(r.PathValue("id"))
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// sparseFields reads the fields query parameter validating it against the model,
+// it returns the requested fields along with the json keys they are encoded as
+func (res *RESTResource[T, PT]) sparseFields(r *http.Request) ([]string, map[string]struct{}, error) {
+
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil, nil
+	}
+
+	var fields []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			fields = append(fields, name)
+		}
+	}
+
+	schemaFields, err := res.repo.lookUpFields(fields...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := make(map[string]struct{}, len(schemaFields))
+	for _, field := range schemaFields {
+		key := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			key = tag
+		}
+		keys[jsonKeyFromContext(r.Context(), key)] = struct{}{}
+	}
+	return fields, keys, nil
+}
+
+// bodyFields maps the keys of a request body named by the json naming policy to the model fields they name,
+// keys naming no field are kept for the repository to match against the columns
+func (res *RESTResource[T, PT]) bodyFields(ctx context.Context, body map[string]any) map[string]any {
+
+	names := map[string]string{}
+	for _, field := range jsonFieldsOf(reflect.TypeFor[T]()) {
+		names[jsonKeyFromContext(ctx, field.key)] = field.name
+	}
+
+	fields := make(map[string]any, len(body))
+	for key, value := range body {
+		if name, ok := names[key]; ok {
+			key = name
+		}
+		fields[key] = value
+	}
+	return fields
+}
+
+// writeProjected writes v as json keeping only the supplied keys of each object, everything is kept when keys is empty
+func (res *RESTResource[T, PT]) writeProjected(ctx context.Context, w http.ResponseWriter, v any, keys map[string]struct{}) {
+
+	if len(keys) == 0 {
+		_ = WriteJSON(ctx, w, http.StatusOK, v)
+		return
+	}
+
+	data, err := jsonCodecFromContext(ctx).Marshal(v)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	var decoded any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err = decoder.Decode(&decoded)
+	if err != nil {
+		_ = WriteError(ctx, w, err)
+		return
+	}
+
+	project := func(item any) any {
+		object, ok := item.(map[string]any)
+		if !ok {
+			return item
+		}
+		for key := range object {
+			if _, keep := keys[key]; !keep {
+				delete(object, key)
+			}
+		}
+		return object
+	}
+
+	if list, ok := decoded.([]any); ok {
+		for i, item := range list {
+			list[i] = project(item)
+		}
+		decoded = list
+	} else {
+		decoded = project(decoded)
+	}
+
+	_ = WriteJSON(ctx, w, http.StatusOK, decoded)
+}
+
+func queryInt(r *http.Request, name string, defaultValue int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
@@ -0,0 +1,168 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SecretReferencePrefix marks configuration values that are resolved through the secret provider e.g. secret://db/password
+const SecretReferencePrefix = "secret://"
+
+const defaultSecretCacheTTL = 5 * time.Minute
+
+// SecretProvider fetches secrets by key from a store like Vault or AWS Secrets Manager
+type SecretProvider interface {
+	Get(ctx context.Context, key string) (string, error)
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+type secretStore struct {
+	provider SecretProvider
+	mu       sync.Mutex
+	entries  map[string]cachedSecret
+}
+
+// WithSecretProvider Option to fetch secrets from the supplied provider.
+// String fields of the configuration holding a secret:// reference are replaced with the secret they refer to,
+// so it has to be supplied before options that read the configuration like Datastore.
+// The references are resolved once, configuration values keep the secret fetched then while components needing
+// rotated secrets, for instance credentials that expire, have to obtain them through Service.Secret.
+func WithSecretProvider(provider SecretProvider) Option {
+	return func(s *Service) {
+		s.secrets = &secretStore{provider: provider, entries: map[string]cachedSecret{}}
+		s.resolveConfigSecrets()
+	}
+}
+
+// WithSecretCacheTTL Option to set how long fetched secrets are reused before being fetched again,
+// rotated secrets are picked up by Service.Secret once their cached value expires. By default this is 5 minutes.
+// Secrets resolved into the configuration are not refreshed.
+func WithSecretCacheTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		s.secretCacheTTL = ttl
+	}
+}
+
+// Secret obtains the secret stored under key from the secret provider.
+// Values are cached, when refreshing an expired value fails the previous value is returned so a provider outage
+// does not break a running service.
+func (s *Service) Secret(ctx context.Context, key string) (string, error) {
+
+	if s.secrets == nil {
+		return "", errors.New("no secret provider is setup")
+	}
+
+	ttl := s.secretCacheTTL
+	if ttl <= 0 {
+		ttl = defaultSecretCacheTTL
+	}
+
+	s.secrets.mu.Lock()
+	cached, ok := s.secrets.entries[key]
+	s.secrets.mu.Unlock()
+
+	if ok && time.Since(cached.fetchedAt) < ttl {
+		return cached.value, nil
+	}
+
+	value, err := s.secrets.provider.Get(ctx, key)
+	if err != nil {
+		if ok {
+			s.L(ctx).WithError(err).WithField("secret", key).Warn("could not refresh secret, using the cached value")
+			return cached.value, nil
+		}
+		return "", fmt.Errorf("could not get secret %s : %w", key, err)
+	}
+
+	s.secrets.mu.Lock()
+	s.secrets.entries[key] = cachedSecret{value: value, fetchedAt: time.Now()}
+	s.secrets.mu.Unlock()
+
+	return value, nil
+}
+
+// ResolveSecrets replaces the string fields of the supplied struct pointer, including those of nested structs
+// and string slices, that hold a secret:// reference with the secret they refer to.
+func (s *Service) ResolveSecrets(ctx context.Context, config any) error {
+
+	value := reflect.ValueOf(config)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return nil
+	}
+
+	return s.resolveSecretValue(ctx, value.Elem())
+}
+
+func (s *Service) resolveSecretValue(ctx context.Context, value reflect.Value) error {
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil
+		}
+		return s.resolveSecretValue(ctx, value.Elem())
+	case reflect.Struct:
+		for i := range value.NumField() {
+			field := value.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+
+			err := s.resolveSecretValue(ctx, field)
+			if err != nil {
+				return fmt.Errorf("%s : %w", value.Type().Field(i).Name, err)
+			}
+		}
+	case reflect.Slice:
+		if value.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		for i := range value.Len() {
+			err := s.resolveSecretValue(ctx, value.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		key, ok := strings.CutPrefix(value.String(), SecretReferencePrefix)
+		if !ok || !value.CanSet() {
+			return nil
+		}
+
+		secret, err := s.Secret(ctx, key)
+		if err != nil {
+			return err
+		}
+		value.SetString(secret)
+	}
+	return nil
+}
+
+// resolveConfigSecrets resolves the secret references of the service configuration,
+// it runs while options are applied so a failure is reported when the service is run
+func (s *Service) resolveConfigSecrets() {
+
+	if s.secrets == nil || s.configuration == nil {
+		return
+	}
+
+	ctx := s.lifetimeCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	err := s.ResolveSecrets(ctx, s.configuration)
+	if err != nil {
+		s.secretsErr = errors.Join(s.secretsErr, fmt.Errorf("could not resolve configuration secrets : %w", err))
+	}
+}
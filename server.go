@@ -227,7 +227,17 @@ func (gd *grpcDriver) ListenAndServeTLS(addr, certFile, certKeyFile string, h ht
 
 func (gd *grpcDriver) Shutdown(ctx context.Context) error {
 	if gd.grpcServer != nil {
-		gd.grpcServer.Stop()
+		stopped := make(chan struct{})
+		go func() {
+			gd.grpcServer.GracefulStop()
+			close(stopped)
+		}()
+
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			gd.grpcServer.Stop()
+		}
 	}
 
 	if gd.httpServer != nil {
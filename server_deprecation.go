@@ -0,0 +1,61 @@
+package frame
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// WithDeprecatedRoute Option to flag the routes matching pattern, for example "/v1/*", as deprecated.
+// Responses carry the Deprecation header and a Sunset header announcing when the route goes away,
+// while every use is logged and counted in the frame.http.deprecated.requests metric to help track down remaining callers.
+func WithDeprecatedRoute(pattern string, sunset time.Time) Option {
+	return func(s *Service) {
+		s.httpMiddleware = append(s.httpMiddleware, deprecatedRouteMiddleware(s, pattern, sunset))
+	}
+}
+
+func deprecatedRouteMiddleware(s *Service, pattern string, sunset time.Time) func(http.Handler) http.Handler {
+
+	var counterOnce sync.Once
+	var counter metric.Int64Counter
+
+	usageCounter := func() metric.Int64Counter {
+		counterOnce.Do(func() {
+			provider := s.meterProvider
+			if provider == nil {
+				provider = otel.GetMeterProvider()
+			}
+			counter, _ = provider.Meter(instrumentationName).Int64Counter("frame.http.deprecated.requests",
+				metric.WithDescription("Number of requests served by deprecated routes"))
+		})
+		return counter
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			if !matchesAnyPath(r.URL.Path, []string{pattern}) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+
+			w.Header().Set("Deprecation", "true")
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			usageCounter().Add(ctx, 1, metric.WithAttributes(attribute.String("route", pattern)))
+			s.L(ctx).WithField("route", pattern).WithField("path", r.URL.Path).
+				WithField("user_agent", r.UserAgent()).WithField("sunset", sunset).
+				Info("deprecated route was called")
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package frame
+
+import (
+	"net/http"
+)
+
+// WithFeatureGatedRoute Option to hide the routes matching pattern, for example "/v2/payments/*", responding
+// with 404 Not Found unless flag is enabled for the request context. This allows endpoints to be deployed dark
+// and enabled gradually, flags targeting tenants need the gate registered after the authentication middleware.
+func WithFeatureGatedRoute(pattern string, flag string) Option {
+	return WithFeatureGatedRouteStatus(pattern, flag, http.StatusNotFound)
+}
+
+// WithFeatureGatedRouteStatus Option like WithFeatureGatedRoute responding with status while flag is disabled
+func WithFeatureGatedRouteStatus(pattern string, flag string, status int) Option {
+	return func(s *Service) {
+		s.httpMiddleware = append(s.httpMiddleware, featureGatedRouteMiddleware(s, pattern, flag, status))
+	}
+}
+
+func featureGatedRouteMiddleware(s *Service, pattern string, flag string, status int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			if matchesAnyPath(r.URL.Path, []string{pattern}) && !s.FeatureFlags().Enabled(r.Context(), flag) {
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
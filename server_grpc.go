@@ -0,0 +1,112 @@
+package frame
+
+import (
+	"context"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/recovery"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// GrpcServerOption configures the grpc server created by WithGrpcServerManaged
+type GrpcServerOption func(opts *grpcServerOptions)
+
+type grpcServerOptions struct {
+	audience           string
+	issuer             string
+	serverOptions      []grpc.ServerOption
+	unaryInterceptors  []grpc.UnaryServerInterceptor
+	streamInterceptors []grpc.StreamServerInterceptor
+}
+
+// WithMaxRecvMsgSize sets the largest message in bytes the server accepts, larger ones fail with ResourceExhausted
+func WithMaxRecvMsgSize(bytes int) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.serverOptions = append(opts.serverOptions, grpc.MaxRecvMsgSize(bytes))
+	}
+}
+
+// WithMaxSendMsgSize sets the largest message in bytes the server sends
+func WithMaxSendMsgSize(bytes int) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.serverOptions = append(opts.serverOptions, grpc.MaxSendMsgSize(bytes))
+	}
+}
+
+// WithKeepalive sets how the server keeps connections alive and how often clients are allowed to ping it,
+// clients pinging more often than the enforcement policy permits are disconnected.
+func WithKeepalive(params keepalive.ServerParameters, policy keepalive.EnforcementPolicy) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.serverOptions = append(opts.serverOptions, grpc.KeepaliveParams(params), grpc.KeepaliveEnforcementPolicy(policy))
+	}
+}
+
+// WithGrpcAuthentication sets the audience and issuer the jwt of calls to the server are verified against
+func WithGrpcAuthentication(audience string, issuer string) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.audience = audience
+		opts.issuer = issuer
+	}
+}
+
+// WithGrpcInterceptors adds interceptors that run after the ones installed by frame
+func WithGrpcInterceptors(unary []grpc.UnaryServerInterceptor, stream []grpc.StreamServerInterceptor) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.unaryInterceptors = append(opts.unaryInterceptors, unary...)
+		opts.streamInterceptors = append(opts.streamInterceptors, stream...)
+	}
+}
+
+// WithGrpcServerOptions passes any other raw grpc server options through
+func WithGrpcServerOptions(serverOptions ...grpc.ServerOption) GrpcServerOption {
+	return func(opts *grpcServerOptions) {
+		opts.serverOptions = append(opts.serverOptions, serverOptions...)
+	}
+}
+
+// WithGrpcServerManaged Option that creates the grpc server of the service instead of taking a ready one like GrpcServer.
+// Calls to the server first go through panic recovery, logging, conversion of handler errors into grpc status errors
+// and, when the service runs securely, jwt authentication. The supplied settings are then applied and register
+// is called with the server so the service implementations can be registered.
+func WithGrpcServerManaged(register func(server *grpc.Server), opts ...GrpcServerOption) Option {
+	return func(s *Service) {
+
+		options := &grpcServerOptions{}
+		for _, opt := range opts {
+			opt(options)
+		}
+
+		recoveryOpt := recovery.WithRecoveryHandlerContext(func(ctx context.Context, p any) error {
+			return RecoveryHandlerFun(ToContext(ctx, s), p)
+		})
+
+		// The logger is only setup once all options are applied so it is obtained on every call
+		logger := logging.LoggerFunc(func(ctx context.Context, lvl logging.Level, msg string, fields ...any) {
+			LoggingInterceptor(s.L(ctx)).Log(ctx, lvl, msg, fields...)
+		})
+
+		unaryInterceptors := append([]grpc.UnaryServerInterceptor{
+			recovery.UnaryServerInterceptor(recoveryOpt),
+			logging.UnaryServerInterceptor(logger, GetLoggingOptions()...),
+			UnaryErrorInterceptor(),
+			s.UnaryAuthInterceptor(options.audience, options.issuer),
+		}, options.unaryInterceptors...)
+
+		streamInterceptors := append([]grpc.StreamServerInterceptor{
+			recovery.StreamServerInterceptor(recoveryOpt),
+			logging.StreamServerInterceptor(logger, GetLoggingOptions()...),
+			StreamErrorInterceptor(),
+			s.StreamAuthInterceptor(options.audience, options.issuer),
+		}, options.streamInterceptors...)
+
+		serverOptions := append([]grpc.ServerOption{
+			grpc.ChainUnaryInterceptor(unaryInterceptors...),
+			grpc.ChainStreamInterceptor(streamInterceptors...),
+		}, options.serverOptions...)
+
+		s.grpcServer = grpc.NewServer(serverOptions...)
+		if register != nil {
+			register(s.grpcServer)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package frame
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	grpchello "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+type notFoundGreeter struct {
+	grpchello.UnimplementedGreeterServer
+}
+
+func (g *notFoundGreeter) SayHello(_ context.Context, in *grpchello.HelloRequest) (*grpchello.HelloReply, error) {
+	if in.Name == "panic" {
+		panic("greeter failed")
+	}
+	if in.Name == "missing" {
+		return nil, NewError(ErrorCodeNotFound, "greeter not found")
+	}
+	return &grpchello.HelloReply{Message: "Hello " + in.Name}, nil
+}
+
+func startManagedGrpcService(t *testing.T, opts ...GrpcServerOption) (context.Context, *Service, grpchello.GreeterClient, *grpc.ClientConn) {
+	t.Helper()
+	return startManagedGrpcServiceSecurely(t, false, opts...)
+}
+
+func startManagedGrpcServiceSecurely(t *testing.T, runSecurely bool, opts ...GrpcServerOption) (context.Context, *Service, grpchello.GreeterClient, *grpc.ClientConn) {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	var defConf ConfigurationDefault
+	err := ConfigProcess("", &defConf)
+	if err != nil {
+		t.Fatalf("could not process test configurations %v", err)
+	}
+	defConf.ServerPort = freeTestAddress(t)
+	defConf.RunServiceSecurely = runSecurely
+
+	ctx, srv := NewService("Testing Managed Grpc", Config(&defConf), GrpcServerListener(listener),
+		WithGrpcServerManaged(func(server *grpc.Server) {
+			grpchello.RegisterGreeterServer(server, &notFoundGreeter{})
+		}, opts...))
+
+	go func() {
+		_ = srv.Run(ctx, "")
+	}()
+
+	_, _, conn, err := getBufferedClConn(listener, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("unable to open a connection %s", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		srv.Stop(ctx)
+	})
+	return ctx, srv, grpchello.NewGreeterClient(conn), conn
+}
+
+func freeTestAddress(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not obtain a free port : %s", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestWithGrpcServerManaged_MaxRecvMsgSize(t *testing.T) {
+
+	ctx, _, client, _ := startManagedGrpcService(t, WithMaxRecvMsgSize(1024))
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := client.SayHello(callCtx, &grpchello.HelloRequest{Name: "frame"}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("small messages should be accepted : %s", err)
+	}
+
+	_, err = client.SayHello(callCtx, &grpchello.HelloRequest{Name: strings.Repeat("x", 2048)})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("messages over the limit should be rejected with ResourceExhausted, got %v", err)
+	}
+
+	_, err = client.SayHello(callCtx, &grpchello.HelloRequest{Name: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("frame errors should be converted to grpc status codes, got %v", err)
+	}
+}
+
+func TestWithGrpcServerManaged_Keepalive(t *testing.T) {
+
+	ctx, _, client, conn := startManagedGrpcService(t, WithKeepalive(
+		keepalive.ServerParameters{MaxConnectionAge: 200 * time.Millisecond, MaxConnectionAgeGrace: 100 * time.Millisecond},
+		keepalive.EnforcementPolicy{MinTime: time.Minute}))
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := client.SayHello(callCtx, &grpchello.HelloRequest{Name: "frame"}, grpc.WaitForReady(true))
+	if err != nil {
+		t.Fatalf("could not call the managed server : %s", err)
+	}
+
+	// The server closes connections older than the max connection age, sending the client back to idle
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && conn.GetState() == connectivity.Ready {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	if state := conn.GetState(); state == connectivity.Ready {
+		t.Errorf("connection should be closed once it exceeds the max connection age, state is %s", state)
+	}
+}
+
+func TestWithGrpcServerManaged_Recovery(t *testing.T) {
+
+	ctx, _, client, _ := startManagedGrpcService(t)
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := client.SayHello(callCtx, &grpchello.HelloRequest{Name: "panic"}, grpc.WaitForReady(true))
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("panicking handler returned %v, expected an internal error", err)
+	}
+
+	_, err = client.SayHello(callCtx, &grpchello.HelloRequest{Name: "frame"})
+	if err != nil {
+		t.Errorf("server should keep serving after a handler panics : %s", err)
+	}
+}
+
+func TestWithGrpcServerManaged_Authentication(t *testing.T) {
+
+	ctx, _, client, _ := startManagedGrpcServiceSecurely(t, true)
+
+	callCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	_, err := client.SayHello(callCtx, &grpchello.HelloRequest{Name: "frame"}, grpc.WaitForReady(true))
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without a token returned %v, expected it to be unauthenticated", err)
+	}
+}
@@ -29,8 +29,13 @@ func (s *Service) HealthCheckers() []Checker {
 	return s.healthCheckers
 }
 
-// HandleHealth returns 200 if it is healthy, 500 otherwise.
+// HandleHealth returns 200 if it is healthy, 503 while shutting down and 500 otherwise.
 func (s *Service) HandleHealth(w http.ResponseWriter, _ *http.Request) {
+	if s.IsStopping() {
+		writeNotReady(w)
+		return
+	}
+
 	for _, c := range s.healthCheckers {
 		if err := c.CheckHealth(); err != nil {
 			writeUnhealthy(w)
@@ -70,6 +75,20 @@ func writeUnhealthy(w http.ResponseWriter) {
 	}
 }
 
+func writeNotReady(w http.ResponseWriter) {
+	const (
+		status    = "not ready"
+		statusLen = "9"
+	)
+
+	writeHeaders(statusLen, w)
+	w.WriteHeader(http.StatusServiceUnavailable)
+	_, err := io.WriteString(w, status)
+	if err != nil {
+		return
+	}
+}
+
 func writeHealthy(w http.ResponseWriter) {
 	const (
 		status    = "ok"
@@ -117,6 +136,12 @@ type grpcHealthServer struct {
 
 func (ghs *grpcHealthServer) Check(_ context.Context, _ *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
 
+	if ghs.service.IsStopping() {
+		return &grpc_health_v1.HealthCheckResponse{
+			Status: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		}, nil
+	}
+
 	for _, c := range ghs.service.healthCheckers {
 		if err := c.CheckHealth(); err != nil {
 
@@ -139,6 +164,9 @@ func (ghs *grpcHealthServer) Watch(_ *grpc_health_v1.HealthCheckRequest, stream
 		case <-time.After(5 * time.Second):
 
 			servingStatus := grpc_health_v1.HealthCheckResponse_SERVING
+			if ghs.service.IsStopping() {
+				servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
+			}
 			for _, c := range ghs.service.healthCheckers {
 				if err := c.CheckHealth(); err != nil {
 					servingStatus = grpc_health_v1.HealthCheckResponse_NOT_SERVING
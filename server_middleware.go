@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"context"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedMediaType is returned when a mutating request does not carry a json body
+var ErrUnsupportedMediaType = NewError(ErrorCodeUnsupportedMedia, "content type must be application/json")
+
+// WithHTTPMiddleware Option to wrap the application http handler with the supplied middleware.
+// Middleware is applied in the order supplied, the first one being the outermost.
+func WithHTTPMiddleware(middleware ...func(http.Handler) http.Handler) Option {
+	return func(s *Service) {
+		s.httpMiddleware = append(s.httpMiddleware, middleware...)
+	}
+}
+
+// WithRequireJSON Option that rejects POST, PUT and PATCH requests whose body is not json with a 415 response.
+// Routes matching any of the bypass patterns, for example "/uploads/*", are left untouched
+// so form encoded and multipart endpoints keep working.
+func WithRequireJSON(bypass ...string) Option {
+	return WithHTTPMiddleware(RequireJSON(bypass...))
+}
+
+// RequireJSON creates a middleware enforcing a json content type on mutating requests.
+// Bypass patterns use path.Match syntax and requests without a body are always allowed.
+func RequireJSON(bypass ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			if !isMutatingMethod(r.Method) || r.ContentLength == 0 || matchesAnyPath(r.URL.Path, bypass) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !isJSONContentType(r.Header.Get("Content-Type")) {
+				_ = WriteError(r.Context(), w, ErrUnsupportedMediaType)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isMutatingMethod(method string) bool {
+	return method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch
+}
+
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+func matchesAnyPath(requestPath string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, requestPath); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// WithHeaderDrivenTimeout Option that bounds each request by the duration supplied in header, for example
+// "X-Request-Timeout: 2s", letting trusted callers limit the work done on their behalf.
+// Durations above maxTimeout are clamped to it while missing or invalid values leave the request untouched.
+func WithHeaderDrivenTimeout(header string, maxTimeout time.Duration) Option {
+	return WithHTTPMiddleware(HeaderDrivenTimeout(header, maxTimeout))
+}
+
+// HeaderDrivenTimeout creates a middleware setting the request context deadline from a header
+func HeaderDrivenTimeout(header string, maxTimeout time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			timeout, err := time.ParseDuration(r.Header.Get(header))
+			if err != nil || timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if maxTimeout > 0 && timeout > maxTimeout {
+				timeout = maxTimeout
+			}
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
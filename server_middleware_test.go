@@ -0,0 +1,174 @@
+package frame_test
+
+import (
+	"github.com/pitabwire/frame"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestService_WithRequireJSON(t *testing.T) {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(handler),
+		frame.WithRequireJSON("/uploads/*"))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		contentType string
+		body        string
+		want        int
+	}{
+		{name: "JSON post", method: http.MethodPost, path: "/items", contentType: "application/json", body: `{}`, want: http.StatusOK},
+		{name: "JSON with charset", method: http.MethodPut, path: "/items/1", contentType: "application/json; charset=utf-8", body: `{}`, want: http.StatusOK},
+		{name: "JSON suffix", method: http.MethodPatch, path: "/items/1", contentType: "application/merge-patch+json", body: `{}`, want: http.StatusOK},
+		{name: "Form post", method: http.MethodPost, path: "/items", contentType: "application/x-www-form-urlencoded", body: "a=b", want: http.StatusUnsupportedMediaType},
+		{name: "Missing content type", method: http.MethodPatch, path: "/items/1", body: `{}`, want: http.StatusUnsupportedMediaType},
+		{name: "Bypassed route", method: http.MethodPost, path: "/uploads/avatar", contentType: "multipart/form-data; boundary=x", body: "--x--", want: http.StatusOK},
+		{name: "Get request", method: http.MethodGet, path: "/items", want: http.StatusOK},
+		{name: "Empty post", method: http.MethodPost, path: "/items/1/publish", want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("could not create request : %s", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("could not perform request : %s", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestService_WithHeaderDrivenTimeout(t *testing.T) {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			w.WriteHeader(http.StatusGatewayTimeout)
+		case <-time.After(2 * time.Second):
+			w.WriteHeader(http.StatusOK)
+		}
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(handler),
+		frame.WithHeaderDrivenTimeout("X-Request-Timeout", 300*time.Millisecond))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	tests := []struct {
+		name        string
+		timeout     string
+		want        int
+		maxDuration time.Duration
+		minDuration time.Duration
+	}{
+		{name: "Header bounds slow handler", timeout: "100ms", want: http.StatusGatewayTimeout, minDuration: 100 * time.Millisecond, maxDuration: 250 * time.Millisecond},
+		{name: "Over max value is clamped", timeout: "1h", want: http.StatusGatewayTimeout, minDuration: 300 * time.Millisecond, maxDuration: time.Second},
+		{name: "Invalid value is ignored", timeout: "soon", want: http.StatusOK, minDuration: 2 * time.Second, maxDuration: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+"/slow", nil)
+			if err != nil {
+				t.Fatalf("could not create request : %s", err)
+			}
+			req.Header.Set("X-Request-Timeout", tt.timeout)
+
+			start := time.Now()
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatalf("could not perform request : %s", err)
+			}
+			_ = resp.Body.Close()
+			elapsed := time.Since(start)
+
+			if resp.StatusCode != tt.want {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.want)
+			}
+			if elapsed < tt.minDuration || elapsed > tt.maxDuration {
+				t.Errorf("request took %s, want between %s and %s", elapsed, tt.minDuration, tt.maxDuration)
+			}
+		})
+	}
+}
+
+func TestService_WithDeprecatedRoute(t *testing.T) {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	sunset := time.Date(2027, time.March, 31, 0, 0, 0, 0, time.UTC)
+	meterProvider := newRecordingMeterProvider()
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(handler),
+		frame.MeterProvider(meterProvider), frame.WithDeprecatedRoute("/v1/*", sunset))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	for range 2 {
+		rr := httptest.NewRecorder()
+		srv.H().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/orders", nil))
+
+		if rr.Header().Get("Deprecation") != "true" {
+			t.Errorf("deprecated route should carry the Deprecation header, got %q", rr.Header().Get("Deprecation"))
+		}
+		if rr.Header().Get("Sunset") != "Wed, 31 Mar 2027 00:00:00 GMT" {
+			t.Errorf("deprecated route should carry the Sunset header, got %q", rr.Header().Get("Sunset"))
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	srv.H().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v2/orders", nil))
+	if rr.Header().Get("Deprecation") != "" || rr.Header().Get("Sunset") != "" {
+		t.Errorf("other routes should not be flagged as deprecated, got %v", rr.Header())
+	}
+
+	if used := meterProvider.counter("frame.http.deprecated.requests", "/v1/*"); used != 2 {
+		t.Errorf("deprecated route usage = %d, want 2", used)
+	}
+}
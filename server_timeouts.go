@@ -0,0 +1,73 @@
+package frame
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultHTTPReadTimeout       = 5 * time.Second
+	defaultHTTPReadHeaderTimeout = 2 * time.Second
+	defaultHTTPWriteTimeout      = 10 * time.Second
+	defaultHTTPIdleTimeout       = 120 * time.Second
+)
+
+// WithHTTPReadTimeout Option to bound the time taken to read a whole request including its body.
+// By default this is 5 seconds.
+func WithHTTPReadTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.httpReadTimeout = timeout
+	}
+}
+
+// WithHTTPReadHeaderTimeout Option to bound the time taken to read the request headers,
+// cutting off slow clients that trickle them in to hold connections open. By default this is 2 seconds.
+func WithHTTPReadHeaderTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.httpReadHeaderTimeout = timeout
+	}
+}
+
+// WithHTTPWriteTimeout Option to bound the time taken to write a response, streaming handlers need it raised.
+// By default this is 10 seconds.
+func WithHTTPWriteTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.httpWriteTimeout = timeout
+	}
+}
+
+// WithHTTPIdleTimeout Option to bound how long keep alive connections wait for the next request.
+// By default this is 120 seconds.
+func WithHTTPIdleTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.httpIdleTimeout = timeout
+	}
+}
+
+// applyHTTPServerTimeouts sets the timeouts of the http server, options take precedence over
+// the configuration and unset values fall back to the defaults
+func (s *Service) applyHTTPServerTimeouts(server *http.Server) {
+
+	var readTimeout, readHeaderTimeout, writeTimeout, idleTimeout time.Duration
+	config, ok := s.Config().(ConfigurationHTTPServer)
+	if ok {
+		readTimeout = config.GetHTTPReadTimeout()
+		readHeaderTimeout = config.GetHTTPReadHeaderTimeout()
+		writeTimeout = config.GetHTTPWriteTimeout()
+		idleTimeout = config.GetHTTPIdleTimeout()
+	}
+
+	server.ReadTimeout = firstPositiveDuration(s.httpReadTimeout, readTimeout, defaultHTTPReadTimeout)
+	server.ReadHeaderTimeout = firstPositiveDuration(s.httpReadHeaderTimeout, readHeaderTimeout, defaultHTTPReadHeaderTimeout)
+	server.WriteTimeout = firstPositiveDuration(s.httpWriteTimeout, writeTimeout, defaultHTTPWriteTimeout)
+	server.IdleTimeout = firstPositiveDuration(s.httpIdleTimeout, idleTimeout, defaultHTTPIdleTimeout)
+}
+
+func firstPositiveDuration(durations ...time.Duration) time.Duration {
+	for _, d := range durations {
+		if d > 0 {
+			return d
+		}
+	}
+	return 0
+}
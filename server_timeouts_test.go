@@ -0,0 +1,66 @@
+package frame_test
+
+import (
+	"errors"
+	"github.com/pitabwire/frame"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestService_HTTPReadHeaderTimeout(t *testing.T) {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.HttpHandler(handler),
+		frame.WithHTTPReadHeaderTimeout(200*time.Millisecond))
+	defer srv.Stop(ctx)
+
+	address := freeAddress(t)
+	go func() {
+		_ = srv.Run(ctx, address)
+	}()
+
+	var conn net.Conn
+	var err error
+	for range 50 {
+		conn, err = net.Dial("tcp", address)
+		if err == nil {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("could not connect to the service : %s", err)
+	}
+	defer conn.Close()
+
+	// A slow client sends part of its headers then stalls
+	_, err = conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: localhost\r\n"))
+	if err != nil {
+		t.Fatalf("could not write partial request : %s", err)
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	startedAt := time.Now()
+	_, err = io.ReadAll(conn)
+
+	var netErr net.Error
+	if err != nil && errors.As(err, &netErr) && netErr.Timeout() {
+		t.Fatalf("slow header client was not cut off by the server")
+	}
+
+	if elapsed := time.Since(startedAt); elapsed > time.Second {
+		t.Errorf("slow header client was cut off after %s, expected the read header timeout", elapsed)
+	}
+
+	resp, err := http.Get("http://" + address + "/fast")
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("well behaved clients should still be served %v : %v", resp, err)
+	}
+	_ = resp.Body.Close()
+}
@@ -9,9 +9,11 @@ import (
 	"github.com/panjf2000/ants/v2"
 	"github.com/pitabwire/frame/internal"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/sdk/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
+	"os"
 	"os/signal"
 	"runtime/debug"
 	"syscall"
@@ -21,6 +23,7 @@ import (
 	"net/http"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -44,13 +47,25 @@ type Service struct {
 	logger                     *logrus.Logger
 	traceExporter              trace.SpanExporter
 	traceSampler               trace.Sampler
+	tracerProvider             *trace.TracerProvider
 	handler                    http.Handler
+	httpMiddleware             []func(http.Handler) http.Handler
+	propagatedHeaders          []string
+	secrets                    *secretStore
+	secretCacheTTL             time.Duration
+	secretsErr                 error
+	httpReadTimeout            time.Duration
+	httpReadHeaderTimeout      time.Duration
+	httpWriteTimeout           time.Duration
+	httpIdleTimeout            time.Duration
+	lifetimeCtx                context.Context
 	cancelFunc                 context.CancelFunc
 	errorChannelMutex          sync.Mutex
 	errorChannel               chan error
 	backGroundClient           func(ctx context.Context) error
 	poolWorkerCount            int
 	poolCapacity               int
+	poolFullPolicy             PoolFullPolicy
 	pool                       *ants.MultiPool
 	driver                     any
 	grpcServer                 *grpc.Server
@@ -58,18 +73,56 @@ type Service struct {
 	priListener                net.Listener
 	secListener                net.Listener
 	grpcPort                   string
+	httpAddress                string
 	client                     *http.Client
 	queue                      *queue
 	dataStore                  *store
+	namedDataStores            map[string]*store
 	bundle                     *i18n.Bundle
 	healthCheckers             []Checker
 	healthCheckPath            string
+	versionPath                string
 	startup                    func(s *Service)
 	cleanup                    func(ctx context.Context)
+	namedCleanups              []namedCleanup
+	shutdownHooks              []ShutdownHook
+	queueShutdownTimeout       time.Duration
+	queueAdminEnabled          bool
+	publishRetry               *PublishRetryPolicy
 	eventRegistry              map[string]EventI
+	featureFlags               FeatureFlags
+	configFeatureFlags         FeatureFlags
+	configFeatureFlagsOnce     sync.Once
+	jsonNamingPolicy           JSONNamingPolicy
+	meterProvider              metric.MeterProvider
+	queueMetricsOnce           sync.Once
+	queueMetricsInstance       *queueMetrics
+	queueMetricLabels          map[string]*queueMetricLabeler
+	poolCounters               workerPoolCounters
+	poolMetricsOnce            sync.Once
+	poolMetricsInstance        *poolMetrics
+	authorizationPolicy        AuthorizationFailurePolicy
+	authorizationPolicies      map[string]AuthorizationFailurePolicy
+	authorizationMetricsOnce   sync.Once
+	authorizationDegraded      metric.Int64Counter
 	configuration              any
 	startOnce                  sync.Once
 	stopMutex                  sync.Mutex
+	shutdownSignals            []os.Signal
+	shutdownSignalsMutex       sync.Mutex
+	stopShutdownSignals        func()
+	preStopDelay               time.Duration
+	stopping                   atomic.Bool
+	pubsubStarted              atomic.Bool
+	restResourcesMutex         sync.Mutex
+	restResources              []openAPIResource
+	replicaLagProbe            ReplicaLagProbe
+	replicaLagCacheFor         time.Duration
+	errorReporter              ErrorReporter
+	outboxRelayInterval        time.Duration
+	workerPoolsMutex           sync.Mutex
+	workerPoolSpecs            map[string]workerPoolSpec
+	workerPools                map[string]*ants.MultiPool
 }
 
 type Option func(service *Service)
@@ -85,11 +138,7 @@ func NewService(name string, opts ...Option) (context.Context, *Service) {
 // It is used together with the Init option to setup components of a service that is not yet running.
 func NewServiceWithContext(ctx context.Context, name string, opts ...Option) (context.Context, *Service) {
 
-	ctx, cancel := signal.NotifyContext(ctx,
-		syscall.SIGHUP,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT)
+	ctx, cancel := context.WithCancel(ctx)
 
 	concurrency := runtime.NumCPU() * 10
 
@@ -97,6 +146,7 @@ func NewServiceWithContext(ctx context.Context, name string, opts ...Option) (co
 
 	service := &Service{
 		name:            name,
+		lifetimeCtx:     ctx,
 		cancelFunc:      cancel,
 		errorChannel:    make(chan error, 1),
 		dataStore:       newDataStore(),
@@ -104,12 +154,22 @@ func NewServiceWithContext(ctx context.Context, name string, opts ...Option) (co
 		queue:           q,
 		poolWorkerCount: concurrency,
 		poolCapacity:    100,
+		shutdownSignals: []os.Signal{
+			syscall.SIGHUP,
+			syscall.SIGINT,
+			syscall.SIGTERM,
+			syscall.SIGQUIT,
+		},
 	}
 
 	opts = append(opts, Logger())
 
 	service.Init(opts...)
 
+	if service.stopShutdownSignals == nil {
+		service.listenForShutdownSignals(ctx)
+	}
+
 	poolOptions := []ants.Option{
 		ants.WithLogger(service.L(ctx)),
 		ants.WithNonblocking(true),
@@ -122,6 +182,86 @@ func NewServiceWithContext(ctx context.Context, name string, opts ...Option) (co
 	return ctx1, service
 }
 
+// WithShutdownSignals Option to customize the os signals that trigger a graceful shutdown of the service.
+// By default SIGHUP, SIGINT, SIGTERM and SIGQUIT are all handled.
+// Applied through Init it replaces the signals the service already listens for.
+func WithShutdownSignals(signals ...os.Signal) Option {
+	return func(s *Service) {
+		s.shutdownSignals = signals
+		s.listenForShutdownSignals(s.lifetimeCtx)
+	}
+}
+
+// listenForShutdownSignals waits for any of the configured shutdown signals
+// and cancels the service context once one is received, replacing the signals listened for before.
+func (s *Service) listenForShutdownSignals(ctx context.Context) {
+
+	s.shutdownSignalsMutex.Lock()
+	defer s.shutdownSignalsMutex.Unlock()
+
+	if s.stopShutdownSignals != nil {
+		s.stopShutdownSignals()
+		s.stopShutdownSignals = nil
+	}
+
+	if ctx == nil || len(s.shutdownSignals) == 0 {
+		return
+	}
+
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, s.shutdownSignals...)
+
+	replaced := make(chan struct{})
+	s.stopShutdownSignals = func() {
+		signal.Stop(signalChannel)
+		close(replaced)
+	}
+
+	go func() {
+		defer signal.Stop(signalChannel)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-replaced:
+			return
+		case sig := <-signalChannel:
+			s.L(ctx).WithField("signal", sig.String()).Info("shutdown initiated by signal")
+			s.preStop(ctx)
+			s.cancelFunc()
+		}
+	}()
+}
+
+// WithPreStopDelay Option to keep serving requests for the supplied duration once shutdown starts.
+// During this period the health check reports the service as not ready,
+// giving load balancers time to stop routing traffic before the server closes.
+func WithPreStopDelay(delay time.Duration) Option {
+	return func(s *Service) {
+		s.preStopDelay = delay
+	}
+}
+
+// IsStopping reports whether the service has started shutting down.
+func (s *Service) IsStopping() bool {
+	return s.stopping.Load()
+}
+
+// preStop flags the service as not ready and waits out the configured pre stop delay.
+func (s *Service) preStop(ctx context.Context) {
+
+	if s.stopping.Swap(true) || s.preStopDelay <= 0 {
+		return
+	}
+
+	s.L(ctx).WithField("delay", s.preStopDelay.String()).Info("service marked not ready, waiting before shutdown")
+
+	select {
+	case <-ctx.Done():
+	case <-time.After(s.preStopDelay):
+	}
+}
+
 // ToContext pushes a service instance into the supplied context for easier propagation.
 func ToContext(ctx context.Context, service *Service) context.Context {
 	return context.WithValue(ctx, ctxKeyService, service)
@@ -147,6 +287,13 @@ func (s *Service) Version() string {
 	return s.version
 }
 
+// WithVersion Option to set the release version of the service, reported by Version and BuildInfo.
+func WithVersion(version string) Option {
+	return func(s *Service) {
+		s.version = version
+	}
+}
+
 // Environment gets the runtime environment of the service.
 func (s *Service) Environment() string {
 	return s.environment
@@ -175,7 +322,9 @@ func (s *Service) H() http.Handler {
 	return s.handler
 }
 
-// Init evaluates the options provided as arguments and supplies them to the service object
+// Init evaluates the options provided as arguments and supplies them to the service object.
+// It can be called again to add components, options registering a datastore connection, publisher
+// or subscriber the service already has leave the existing one in place.
 func (s *Service) Init(opts ...Option) {
 	for _, opt := range opts {
 		opt(s)
@@ -225,11 +374,25 @@ func (s *Service) AddHealthCheck(checker Checker) {
 // keep them useful by handling incoming requests
 func (s *Service) Run(ctx context.Context, address string) error {
 
-	err := s.initPubsub(ctx)
+	err := s.datastoreStartupError()
 	if err != nil {
 		return err
 	}
 
+	if s.secretsErr != nil {
+		return s.secretsErr
+	}
+
+	err = s.initPubsub(ctx)
+	if err != nil {
+		return err
+	}
+	s.pubsubStarted.Store(true)
+
+	if s.outboxRelayInterval > 0 {
+		go s.runOutboxRelay(ctx)
+	}
+
 	//connect the background processor
 	if s.backGroundClient != nil {
 		go func() {
@@ -242,6 +405,10 @@ func (s *Service) Run(ctx context.Context, address string) error {
 
 	go func() {
 		err = s.initServer(ctx, address)
+		if errors.Is(err, http.ErrServerClosed) {
+			// Closed servers are the result of a graceful shutdown
+			err = nil
+		}
 		if err != nil || s.backGroundClient == nil {
 			s.sendStopError(ctx, err)
 		}
@@ -320,7 +487,20 @@ func (s *Service) initServer(ctx context.Context, httpPort string) error {
 
 		mux.HandleFunc(s.healthCheckPath, s.HandleHealth)
 
+		versionPath := s.versionPath
+		if versionPath == "" {
+			versionPath = defaultVersionPath
+		}
+		mux.HandleFunc(versionPath, s.HandleVersion)
+
+		for i := len(s.httpMiddleware) - 1; i >= 0; i-- {
+			applicationHandler = s.httpMiddleware[i](applicationHandler)
+		}
+
+		applicationHandler = s.recoveryMiddleware(s.propagationMiddleware(applicationHandler))
+
 		mux.Handle("/", applicationHandler)
+		mux.Handle(OpenAPIPath, s.openAPIHandler(applicationHandler))
 
 		config, ok := s.Config().(ConfigurationCORS)
 		if ok && config.IsCORSEnabled() {
@@ -350,11 +530,9 @@ func (s *Service) initServer(ctx context.Context, httpPort string) error {
 				BaseContext: func(listener net.Listener) context.Context {
 					return ctx
 				},
-				ReadTimeout:  5 * time.Second,
-				WriteTimeout: 10 * time.Second,
-				IdleTimeout:  120 * time.Second,
 			},
 		}
+		s.applyHTTPServerTimeouts(defaultServer.httpServer)
 
 		// If grpc server is setup we should use the correct driver
 		if s.grpcServer != nil {
@@ -394,6 +572,9 @@ func (s *Service) initServer(ctx context.Context, httpPort string) error {
 		s.startup(s)
 	}
 
+	s.httpAddress = httpPort
+	s.logDiagnostics(ctx)
+
 	if s.TLSEnabled() {
 
 		config, _ := s.Config().(ConfigurationTLS)
@@ -422,13 +603,14 @@ func (s *Service) Stop(ctx context.Context) {
 	}
 	defer s.stopMutex.Unlock()
 
-	if s.cleanup != nil {
-		s.cleanup(ctx)
-	}
+	s.preStop(ctx)
+
+	s.shutdown(ctx)
 
 	if s.pool != nil {
 		s.pool.Free()
 	}
+	s.freeWorkerPools()
 
 	if s.cancelFunc != nil {
 		s.cancelFunc()
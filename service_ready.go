@@ -0,0 +1,53 @@
+package frame
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+const readyPollInterval = 10 * time.Millisecond
+
+// WaitReady blocks until Run has initialised the queues and every registered subscriber is listening for messages,
+// so a message published right after it returns is consumed. It returns the context error if that does not happen
+// before the context is done, for example when Run failed or the service is stopping.
+func (s *Service) WaitReady(ctx context.Context) error {
+
+	ticker := time.NewTicker(readyPollInterval)
+	defer ticker.Stop()
+
+	for !s.isReady() {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	return nil
+}
+
+// isReady reports whether the queues are initialised and all subscribers are listening
+func (s *Service) isReady() bool {
+
+	if !s.pubsubStarted.Load() || s.stopping.Load() {
+		return false
+	}
+
+	if s.queue == nil {
+		return true
+	}
+
+	ready := true
+	s.queue.subscriptionQueueMap.Range(func(_, value any) bool {
+		sub := value.(*subscriber)
+		if strings.HasPrefix(sub.url, "http") {
+			return true
+		}
+
+		ready = sub.isInit.Load() && sub.listening.Load()
+		return ready
+	})
+
+	return ready
+}
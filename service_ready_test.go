@@ -0,0 +1,66 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"testing"
+	"time"
+)
+
+type channelHandler struct {
+	messages chan []byte
+}
+
+func (h *channelHandler) Handle(_ context.Context, _ map[string]string, message []byte) error {
+	h.messages <- message
+	return nil
+}
+
+func TestService_WaitReady(t *testing.T) {
+
+	queueURL := "mem://topic-wait-ready"
+	handler := &channelHandler{messages: make(chan []byte, 1)}
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.RegisterPublisher("ready", queueURL),
+		frame.RegisterSubscriber("ready", queueURL, 1, handler))
+	defer srv.Stop(ctx)
+
+	notRunCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	err := srv.WaitReady(notRunCtx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("service should not be ready before it runs, got %v", err)
+	}
+
+	err = srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	readyCtx, cancelReady := context.WithTimeout(ctx, 5*time.Second)
+	defer cancelReady()
+	err = srv.WaitReady(readyCtx)
+	if err != nil {
+		t.Fatalf("service did not become ready : %s", err)
+	}
+
+	if !srv.SubscriptionIsInitiated("ready") {
+		t.Errorf("subscriber should be initiated once the service is ready")
+	}
+
+	err = srv.Publish(ctx, "ready", []byte("first"))
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	select {
+	case message := <-handler.messages:
+		if string(message) != "first" {
+			t.Errorf("received %q instead of the published message", message)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("message published after WaitReady was not consumed")
+	}
+}
@@ -0,0 +1,136 @@
+package frame
+
+import (
+	"context"
+	"github.com/pitabwire/frame/internal"
+	"strings"
+	"time"
+)
+
+const defaultQueueShutdownTimeout = 10 * time.Second
+
+// ShutdownPhase identifies a step of the graceful shutdown sequence
+type ShutdownPhase string
+
+const (
+	// ShutdownPhaseServers stops the http and grpc servers accepting requests and waits for in flight ones to complete
+	ShutdownPhaseServers ShutdownPhase = "servers"
+	// ShutdownPhaseQueues drains subscriptions, waiting for in flight messages to be handled, then closes them and the publishers
+	ShutdownPhaseQueues ShutdownPhase = "queues"
+	// ShutdownPhaseCleanup runs the named cleanup methods in dependency order then the others,
+	// datastore connections are closed here and the spans still batched are exported last
+	ShutdownPhaseCleanup ShutdownPhase = "cleanup"
+)
+
+// ShutdownHook is notified as each shutdown phase starts
+type ShutdownHook func(ctx context.Context, phase ShutdownPhase)
+
+// WithShutdownHook Option to observe the phases of a graceful shutdown.
+// Phases always run in the order servers, queues then cleanup so dependencies
+// such as the datastore stay available until no request is being handled.
+func WithShutdownHook(hook ShutdownHook) Option {
+	return func(s *Service) {
+		s.shutdownHooks = append(s.shutdownHooks, hook)
+	}
+}
+
+// WithQueueShutdownTimeout Option to bound how long closing queues waits for pending acks and sends
+// to be flushed, an unreachable broker otherwise blocks shutdown for as long as the stop context allows.
+func WithQueueShutdownTimeout(timeout time.Duration) Option {
+	return func(s *Service) {
+		s.queueShutdownTimeout = timeout
+	}
+}
+
+// shutdown runs each phase of the graceful shutdown sequence in order
+func (s *Service) shutdown(ctx context.Context) {
+
+	s.enterShutdownPhase(ctx, ShutdownPhaseServers)
+	s.shutdownServers(ctx)
+
+	s.enterShutdownPhase(ctx, ShutdownPhaseQueues)
+	s.shutdownQueues(ctx)
+
+	s.enterShutdownPhase(ctx, ShutdownPhaseCleanup)
+	s.runNamedCleanups(ctx)
+	if s.cleanup != nil {
+		s.cleanup(ctx)
+	}
+
+	s.shutdownTracer(ctx)
+}
+
+func (s *Service) enterShutdownPhase(ctx context.Context, phase ShutdownPhase) {
+	s.L(ctx).WithField("phase", phase).Debug("shutdown phase started")
+	for _, hook := range s.shutdownHooks {
+		hook(ctx, phase)
+	}
+}
+
+func (s *Service) shutdownServers(ctx context.Context) {
+
+	server, ok := s.driver.(internal.Server)
+	if !ok {
+		return
+	}
+
+	err := server.Shutdown(ctx)
+	if err != nil {
+		s.L(ctx).WithError(err).Warn("could not gracefully shutdown server")
+	}
+}
+
+func (s *Service) shutdownQueues(ctx context.Context) {
+
+	if s.queue == nil {
+		return
+	}
+
+	timeout := s.queueShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultQueueShutdownTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	// Draining first lets handlers already working on messages finish and acknowledge them,
+	// messages received from here on are handed back to the queue for another instance to handle
+	s.queue.subscriptionQueueMap.Range(func(key, value any) bool {
+		sub := value.(*subscriber)
+		err := sub.drain(ctx)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("subscriber", sub.reference).Warn("could not drain subscription in time")
+		}
+		return true
+	})
+
+	s.queue.subscriptionQueueMap.Range(func(key, value any) bool {
+		sub := value.(*subscriber)
+		if sub.subscription == nil {
+			return true
+		}
+
+		sub.isInit.Store(false)
+		err := sub.subscription.Shutdown(ctx)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("subscriber", sub.reference).Warn("could not shutdown subscription")
+		}
+		return true
+	})
+
+	s.queue.publishQueueMap.Range(func(key, value any) bool {
+		pub := value.(*publisher)
+
+		// In memory topics are shared across the whole process hence are left open
+		if pub.topic == nil || strings.HasPrefix(pub.url, "mem://") {
+			return true
+		}
+
+		err := pub.topic.Shutdown(ctx)
+		if err != nil {
+			s.L(ctx).WithError(err).WithField("publisher", pub.reference).Warn("could not shutdown publisher")
+		}
+		return true
+	})
+}
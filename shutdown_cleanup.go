@@ -0,0 +1,111 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrCleanupDependency is returned when registering a named cleanup that would make the dependencies inconsistent
+var ErrCleanupDependency = NewError(ErrorCodeInvalidArgument, "invalid cleanup dependency")
+
+type namedCleanup struct {
+	name      string
+	dependsOn []string
+	fn        func(ctx context.Context)
+}
+
+// AddCleanupMethodAfter registers a named cleanup that runs at shutdown only once the cleanups named in dependsOn
+// have completed, for example flushing a cache before closing the client it writes through.
+// Named cleanups run in dependency order, falling back to registration order, before those added with AddCleanupMethod.
+// Dependencies may be registered later, ones never registered are ignored.
+// Registering a duplicate name or a dependency that forms a cycle fails with ErrCleanupDependency.
+func (s *Service) AddCleanupMethodAfter(name string, dependsOn []string, fn func(ctx context.Context)) error {
+	s.stopMutex.Lock()
+	defer s.stopMutex.Unlock()
+
+	for _, cleanup := range s.namedCleanups {
+		if cleanup.name == name {
+			return fmt.Errorf("cleanup %s is already registered : %w", name, ErrCleanupDependency)
+		}
+	}
+
+	cleanups := append(slices.Clone(s.namedCleanups), namedCleanup{name: name, dependsOn: dependsOn, fn: fn})
+
+	path := cleanupCyclePath(cleanups, name)
+	if path != nil {
+		return fmt.Errorf("cleanup dependencies form a cycle %s : %w", strings.Join(path, " -> "), ErrCleanupDependency)
+	}
+
+	s.namedCleanups = cleanups
+	return nil
+}
+
+// cleanupCyclePath returns the dependency path leading from name back to itself if there is one
+func cleanupCyclePath(cleanups []namedCleanup, name string) []string {
+
+	dependencies := make(map[string][]string, len(cleanups))
+	for _, cleanup := range cleanups {
+		dependencies[cleanup.name] = cleanup.dependsOn
+	}
+
+	visited := map[string]bool{}
+	var visit func(current string, path []string) []string
+	visit = func(current string, path []string) []string {
+		for _, dependency := range dependencies[current] {
+			if dependency == name {
+				return append(path, dependency)
+			}
+
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+
+			cycle := visit(dependency, append(path, dependency))
+			if cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+
+	return visit(name, []string{name})
+}
+
+// runNamedCleanups runs the named cleanups so each one starts after all of its registered dependencies,
+// it is called by Stop which already holds the stop mutex
+func (s *Service) runNamedCleanups(ctx context.Context) {
+
+	cleanups := s.namedCleanups
+
+	registered := make(map[string]bool, len(cleanups))
+	for _, cleanup := range cleanups {
+		registered[cleanup.name] = true
+	}
+
+	done := make(map[string]bool, len(cleanups))
+	for len(done) < len(cleanups) {
+		// Registration rejects cycles so every pass runs at least one cleanup
+		for _, cleanup := range cleanups {
+			if done[cleanup.name] {
+				continue
+			}
+
+			ready := true
+			for _, dependency := range cleanup.dependsOn {
+				if registered[dependency] && !done[dependency] {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				s.L(ctx).WithField("cleanup", cleanup.name).Debug("running cleanup")
+				cleanup.fn(ctx)
+				done[cleanup.name] = true
+			}
+		}
+	}
+}
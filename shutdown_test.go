@@ -0,0 +1,301 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func freeAddress(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not obtain a free port : %s", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestService_ShutdownOrder(t *testing.T) {
+
+	var dbClosed atomic.Bool
+	requestStarted := make(chan struct{})
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(requestStarted)
+		time.Sleep(500 * time.Millisecond)
+
+		if dbClosed.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte("db closed"))
+			return
+		}
+		_, _ = w.Write([]byte("db open"))
+	})
+
+	var mu sync.Mutex
+	var phases []frame.ShutdownPhase
+
+	ctx, srv := frame.NewService("Test Srv", frame.HttpHandler(handler),
+		frame.WithShutdownHook(func(_ context.Context, phase frame.ShutdownPhase) {
+			mu.Lock()
+			defer mu.Unlock()
+			phases = append(phases, phase)
+		}))
+
+	srv.AddCleanupMethod(func(ctx context.Context) {
+		dbClosed.Store(true)
+	})
+
+	address := freeAddress(t)
+	go func() {
+		_ = srv.Run(ctx, address)
+	}()
+
+	responses := make(chan string, 1)
+	go func() {
+		for range 50 {
+			resp, err := http.Get(fmt.Sprintf("http://%s/slow", address))
+			if err != nil {
+				time.Sleep(50 * time.Millisecond)
+				continue
+			}
+			body, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			responses <- string(body)
+			return
+		}
+		responses <- "server never started"
+	}()
+
+	select {
+	case <-requestStarted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("request was not received")
+	}
+
+	srv.Stop(ctx)
+
+	select {
+	case body := <-responses:
+		if body != "db open" {
+			t.Errorf("in flight request should complete before the db is closed, got %q", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("in flight request did not complete")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	want := []frame.ShutdownPhase{frame.ShutdownPhaseServers, frame.ShutdownPhaseQueues, frame.ShutdownPhaseCleanup}
+	if fmt.Sprint(phases) != fmt.Sprint(want) {
+		t.Errorf("shutdown phases = %v, want %v", phases, want)
+	}
+}
+
+// slowHandler takes a while on every message, recording when it starts and completes handling them
+type slowHandler struct {
+	started   chan struct{}
+	completed atomic.Int32
+	delay     time.Duration
+}
+
+func (h *slowHandler) Handle(_ context.Context, _ map[string]string, _ []byte) error {
+	select {
+	case h.started <- struct{}{}:
+	default:
+	}
+	time.Sleep(h.delay)
+	h.completed.Add(1)
+	return nil
+}
+
+func TestService_ShutdownDrainsSubscribers(t *testing.T) {
+
+	handler := &slowHandler{started: make(chan struct{}, 1), delay: 500 * time.Millisecond}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-drain", "mem://topicDrain"),
+		frame.RegisterSubscriber("test-drain", "mem://topicDrain", 1, handler),
+		frame.NoopDriver())
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("we couldn't instantiate queue  %s", err)
+	}
+
+	err = srv.Publish(ctx, "test-drain", []byte("in flight"))
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	select {
+	case <-handler.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("message was not received")
+	}
+
+	var handledBeforeClose int32
+	srv.AddCleanupMethod(func(ctx context.Context) {
+		handledBeforeClose = handler.completed.Load()
+	})
+
+	srv.Stop(ctx)
+
+	if handledBeforeClose != 1 {
+		t.Errorf("in flight message should be handled before cleanup, %d were", handledBeforeClose)
+	}
+}
+
+func TestService_ShutdownDrainsNatsSubscribers(t *testing.T) {
+
+	natsURL := frame.GetEnv("TEST_NATS_URL", "nats://localhost:4222")
+	suffix := time.Now().UnixNano()
+	queueURL := fmt.Sprintf("%s?jetstream=true&stream_name=frame_drain_%d&subject=frame.drain.%d", natsURL, suffix, suffix)
+
+	handler := &slowHandler{started: make(chan struct{}, 1), delay: 500 * time.Millisecond}
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.RegisterPublisher("test-nats-drain", queueURL),
+		frame.RegisterSubscriber("test-nats-drain", queueURL, 1, handler, frame.WithDurableConsumer("drain_sub")),
+		frame.NoopDriver())
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		srv.Stop(ctx)
+		t.Fatalf("we couldn't instantiate queue  %s", err)
+	}
+
+	for i := range 3 {
+		err = srv.Publish(ctx, "test-nats-drain", []byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			srv.Stop(ctx)
+			t.Fatalf("could not publish message : %s", err)
+		}
+	}
+
+	select {
+	case <-handler.started:
+	case <-time.After(5 * time.Second):
+		srv.Stop(ctx)
+		t.Fatalf("message was not received")
+	}
+
+	srv.Stop(ctx)
+
+	handled := handler.completed.Load()
+	if handled < 1 {
+		t.Errorf("in flight message should be handled during drain")
+	}
+
+	time.Sleep(time.Second)
+	if handler.completed.Load() != handled {
+		t.Errorf("no messages should be handled once drained")
+	}
+
+	// Messages acknowledged during the drain are not redelivered while the rest still are
+	redelivered := &slowHandler{started: make(chan struct{}, 3)}
+	ctx2, srv2 := frame.NewService("Test Srv",
+		frame.RegisterSubscriber("test-nats-drain", queueURL, 1, redelivered, frame.WithDurableConsumer("drain_sub")),
+		frame.NoopDriver(), frame.WithQueueAdminOperations())
+	defer srv2.Stop(ctx2)
+
+	err = srv2.Run(ctx2, "")
+	if err != nil {
+		t.Fatalf("we couldn't instantiate queue  %s", err)
+	}
+
+	time.Sleep(2 * time.Second)
+	if total := handled + redelivered.completed.Load(); total != 3 {
+		t.Errorf("every message should be handled exactly once across restarts, got %d", total)
+	}
+
+	_ = srv2.PurgeStream(ctx2, "test-nats-drain")
+}
+
+func TestService_AddCleanupMethodAfter(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+
+	var mu sync.Mutex
+	var order []string
+	record := func(name string) func(ctx context.Context) {
+		return func(ctx context.Context) {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+		}
+	}
+
+	srv.AddCleanupMethod(record("datastore"))
+
+	// Registered before its dependencies on purpose, dependencies decide the order not registration
+	err := srv.AddCleanupMethodAfter("metrics", []string{"cache", "queue"}, record("metrics"))
+	if err != nil {
+		t.Fatalf("could not register cleanup : %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("cache", []string{"queue"}, record("cache"))
+	if err != nil {
+		t.Fatalf("could not register cleanup : %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("queue", []string{"unregistered"}, record("queue"))
+	if err != nil {
+		t.Fatalf("could not register cleanup : %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("queue", nil, record("queue"))
+	if !errors.Is(err, frame.ErrCleanupDependency) {
+		t.Errorf("registering a duplicate cleanup should fail, got %v", err)
+	}
+
+	srv.Stop(ctx)
+
+	expected := []string{"queue", "cache", "metrics", "datastore"}
+	if !slices.Equal(order, expected) {
+		t.Errorf("cleanups ran in order %v, want %v", order, expected)
+	}
+}
+
+func TestService_AddCleanupMethodAfterCycle(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	noop := func(ctx context.Context) {}
+
+	err := srv.AddCleanupMethodAfter("a", []string{"b"}, noop)
+	if err != nil {
+		t.Fatalf("could not register cleanup : %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("b", []string{"c"}, noop)
+	if err != nil {
+		t.Fatalf("could not register cleanup : %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("c", []string{"a"}, noop)
+	if !errors.Is(err, frame.ErrCleanupDependency) {
+		t.Fatalf("a dependency cycle should be rejected, got %v", err)
+	}
+
+	if !strings.Contains(err.Error(), "c -> a -> b -> c") {
+		t.Errorf("the cycle should be reported, got %s", err)
+	}
+
+	err = srv.AddCleanupMethodAfter("self", []string{"self"}, noop)
+	if !errors.Is(err, frame.ErrCleanupDependency) {
+		t.Errorf("a cleanup depending on itself should be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,41 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"reflect"
+)
+
+// singleFlightGroup is shared by the whole process so identical work started anywhere is coalesced
+var singleFlightGroup singleflight.Group
+
+// SingleFlight runs fn once for all concurrent callers supplying the same key, each of them receiving its result.
+// This keeps a burst of identical reads, for example on a cache miss, from repeating the same expensive work.
+// fn runs with a context that is not cancelled when the caller that started it gives up,
+// while each caller stops waiting as soon as its own context is done.
+// Only the context of the caller that started fn is used, callers joining it get a result obtained with that caller's
+// claims and tenancy, so keys have to tell apart anything those change. Callers expecting different result types
+// never share work even with the same key.
+func SingleFlight[T any](ctx context.Context, key string, fn func(ctx context.Context) (T, error)) (T, error) {
+
+	key = reflect.TypeFor[T]().String() + ":" + key
+	resultCh := singleFlightGroup.DoChan(key, func() (any, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+
+	var empty T
+	select {
+	case <-ctx.Done():
+		return empty, ctx.Err()
+	case result := <-resultCh:
+		if result.Err != nil {
+			return empty, result.Err
+		}
+		value, ok := result.Val.(T)
+		if !ok && result.Val != nil {
+			return empty, fmt.Errorf("single flight %s produced a %T instead of a %T", key, result.Val, empty)
+		}
+		return value, nil
+	}
+}
@@ -0,0 +1,112 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSingleFlight(t *testing.T) {
+
+	ctx := context.Background()
+	release := make(chan struct{})
+	var runs atomic.Int32
+
+	fn := func(ctx context.Context) (string, error) {
+		runs.Add(1)
+		<-release
+		return "computed", nil
+	}
+
+	const callers = 50
+	results := make(chan string, callers)
+
+	var started, done sync.WaitGroup
+	for range callers {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			value, err := frame.SingleFlight(ctx, "single-flight-key", fn)
+			if err != nil {
+				t.Errorf("coalesced call failed : %v", err)
+			}
+			results <- value
+		}()
+	}
+
+	started.Wait()
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	done.Wait()
+	close(results)
+
+	if runs.Load() != 1 {
+		t.Errorf("fn should run once for concurrent callers, ran %d times", runs.Load())
+	}
+
+	for value := range results {
+		if value != "computed" {
+			t.Errorf("every caller should receive the shared result, got %q", value)
+		}
+	}
+}
+
+func TestSingleFlight_CallerContextDone(t *testing.T) {
+
+	release := make(chan struct{})
+	defer close(release)
+
+	fn := func(ctx context.Context) (int, error) {
+		<-release
+		return 1, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := frame.SingleFlight(ctx, "single-flight-timeout", fn)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("caller should stop waiting once its context is done, got %v", err)
+	}
+}
+
+func TestSingleFlight_DistinctTypes(t *testing.T) {
+
+	ctx := context.Background()
+	release := make(chan struct{})
+
+	var wg sync.WaitGroup
+	var text string
+	var number int
+	var textErr, numberErr error
+
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		text, textErr = frame.SingleFlight(ctx, "shared-key", func(_ context.Context) (string, error) {
+			<-release
+			return "text", nil
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		number, numberErr = frame.SingleFlight(ctx, "shared-key", func(_ context.Context) (int, error) {
+			<-release
+			return 42, nil
+		})
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if textErr != nil || text != "text" || numberErr != nil || number != 42 {
+		t.Errorf("calls expecting different types shared a result, got %q %v and %d %v", text, textErr, number, numberErr)
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
-	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
 )
 
 func (s *Service) initTracer(ctx context.Context) error {
@@ -32,9 +34,10 @@ func (s *Service) initTracer(ctx context.Context) error {
 
 		tp := sdktrace.NewTracerProvider(
 			sdktrace.WithSampler(s.traceSampler),
-			sdktrace.WithSyncer(s.traceExporter),
+			sdktrace.WithBatcher(&resilientExporter{SpanExporter: s.traceExporter, service: s}),
 			sdktrace.WithResource(res))
 
+		s.tracerProvider = tp
 		otel.SetTracerProvider(tp)
 		otel.SetTextMapPropagator(
 			propagation.NewCompositeTextMapPropagator(
@@ -46,6 +49,44 @@ func (s *Service) initTracer(ctx context.Context) error {
 	return nil
 }
 
+// shutdownTracer flushes the spans still batched and stops the exporter, it runs once servers and queues have stopped
+// so the spans of the last requests and messages are exported
+func (s *Service) shutdownTracer(ctx context.Context) {
+	if s.tracerProvider == nil {
+		return
+	}
+
+	err := s.tracerProvider.Shutdown(ctx)
+	if err != nil {
+		s.L(ctx).WithError(err).Warn("could not flush and stop the tracer")
+	}
+}
+
+// resilientExporter reports export failures as warnings instead of surfacing them,
+// spans are exported in batches off the request path and dropped when the buffer overflows
+// so an unreachable exporter never blocks request handling.
+type resilientExporter struct {
+	sdktrace.SpanExporter
+	service     *Service
+	unreachable atomic.Bool
+}
+
+func (re *resilientExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := re.SpanExporter.ExportSpans(ctx, spans)
+	if err != nil {
+		if !re.unreachable.Swap(true) {
+			re.service.L(ctx).WithError(err).WithField("spans", len(spans)).
+				Warn("telemetry exporter is unreachable, spans are being dropped")
+		}
+		return nil
+	}
+
+	if re.unreachable.Swap(false) {
+		re.service.L(ctx).Info("telemetry exporter is reachable again")
+	}
+	return nil
+}
+
 // TraceExporter Option that specify the trace exporter to use
 func TraceExporter(exporter sdktrace.SpanExporter) Option {
 	return func(s *Service) {
@@ -59,3 +100,19 @@ func TraceSampler(sampler sdktrace.Sampler) Option {
 		s.traceSampler = sampler
 	}
 }
+
+// SpanFromContext obtains the span active in the supplied context.
+// When telemetry is disabled a non recording span is returned so callers never have to check for nil.
+func SpanFromContext(ctx context.Context) trace.Span {
+	return trace.SpanFromContext(ctx)
+}
+
+// AddSpanAttributes attaches the supplied attributes to the span active in the context,
+// allowing handlers to enrich it with business data. It does nothing when the span is not recording.
+func AddSpanAttributes(ctx context.Context, attrs ...attribute.KeyValue) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	span.SetAttributes(attrs...)
+}
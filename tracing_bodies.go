@@ -0,0 +1,162 @@
+package frame
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const traceBodiesRedactedValue = `"[REDACTED]"`
+
+// WithTraceBodies Option that captures up to maxBytes of the http request and response bodies
+// as an event on the active span, and as log fields when debug logging is enabled.
+// Only text and json bodies are captured and the values of the supplied redactFields, for example "password",
+// are masked wherever they appear as json keys. Bodies are not captured unless this option is used.
+func WithTraceBodies(maxBytes int, redactFields ...string) Option {
+	return func(s *Service) {
+		s.httpMiddleware = append(s.httpMiddleware, traceBodiesMiddleware(s, maxBytes, redactFields))
+	}
+}
+
+func traceBodiesMiddleware(s *Service, maxBytes int, redactFields []string) func(http.Handler) http.Handler {
+
+	redactor := newBodyRedactor(redactFields)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			ctx := r.Context()
+			span := trace.SpanFromContext(ctx)
+			logDebug := s.L(ctx).Logger.IsLevelEnabled(logrus.DebugLevel)
+
+			if maxBytes <= 0 || (!span.IsRecording() && !logDebug) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var requestBody *boundedBuffer
+			if r.Body != nil && isTextContentType(r.Header.Get("Content-Type")) {
+				requestBody = &boundedBuffer{limit: maxBytes}
+				r.Body = &teeReadCloser{Reader: io.TeeReader(r.Body, requestBody), Closer: r.Body}
+			}
+
+			recorder := &bodyRecordingWriter{ResponseWriter: w, body: boundedBuffer{limit: maxBytes}}
+			next.ServeHTTP(recorder, r)
+
+			attrs := make([]attribute.KeyValue, 0, 4)
+			fields := logrus.Fields{}
+
+			if requestBody != nil && requestBody.buf.Len() > 0 {
+				body := redactor.redact(requestBody.buf.String())
+				attrs = append(attrs,
+					attribute.String("http.request.body", body),
+					attribute.Bool("http.request.body.truncated", requestBody.truncated))
+				fields["request_body"] = body
+			}
+
+			if isTextContentType(recorder.Header().Get("Content-Type")) && recorder.body.buf.Len() > 0 {
+				body := redactor.redact(recorder.body.buf.String())
+				attrs = append(attrs,
+					attribute.String("http.response.body", body),
+					attribute.Bool("http.response.body.truncated", recorder.body.truncated))
+				fields["response_body"] = body
+			}
+
+			if len(attrs) == 0 {
+				return
+			}
+
+			span.AddEvent("http.bodies", trace.WithAttributes(attrs...))
+			if logDebug {
+				s.L(ctx).WithFields(fields).WithField("path", r.URL.Path).Debug("http request bodies")
+			}
+		})
+	}
+}
+
+// isTextContentType reports whether bodies of the content type are readable text worth capturing
+func isTextContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// boundedBuffer keeps the first limit bytes written to it while accepting everything
+type boundedBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.buf.Len()
+	if remaining < len(p) {
+		b.truncated = true
+		if remaining > 0 {
+			b.buf.Write(p[:remaining])
+		}
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyRecordingWriter copies the first bytes of the response while passing everything through
+type bodyRecordingWriter struct {
+	http.ResponseWriter
+	body boundedBuffer
+}
+
+func (w *bodyRecordingWriter) Write(p []byte) (int, error) {
+	_, _ = w.body.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *bodyRecordingWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *bodyRecordingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// bodyRedactor masks the values of sensitive json keys, working on truncated bodies too
+type bodyRedactor struct {
+	pattern *regexp.Regexp
+}
+
+func newBodyRedactor(fields []string) *bodyRedactor {
+	if len(fields) == 0 {
+		return &bodyRedactor{}
+	}
+
+	names := make([]string, 0, len(fields))
+	for _, field := range fields {
+		names = append(names, regexp.QuoteMeta(field))
+	}
+
+	pattern := fmt.Sprintf(`("(?i:%s)"\s*:\s*)("(?:[^"\\]|\\.)*"?|[^,}\]\s]+)`, strings.Join(names, "|"))
+	return &bodyRedactor{pattern: regexp.MustCompile(pattern)}
+}
+
+func (br *bodyRedactor) redact(body string) string {
+	if br.pattern == nil {
+		return body
+	}
+	return br.pattern.ReplaceAllString(body, "${1}"+traceBodiesRedactedValue)
+}
@@ -0,0 +1,229 @@
+package frame_test
+
+import (
+	"context"
+	"errors"
+	"github.com/pitabwire/frame"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAddSpanAttributes(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	ctx, span := provider.Tracer("frame-test").Start(context.Background(), "handle order")
+
+	if frame.SpanFromContext(ctx).SpanContext().SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("SpanFromContext() should return the active span")
+	}
+
+	frame.AddSpanAttributes(ctx, attribute.String("order.id", "ord-123"), attribute.Int("order.amount", 250))
+	span.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Errorf("expected one recorded span, got %d", len(spans))
+		return
+	}
+
+	recorded := map[attribute.Key]attribute.Value{}
+	for _, attr := range spans[0].Attributes() {
+		recorded[attr.Key] = attr.Value
+	}
+
+	if recorded["order.id"].AsString() != "ord-123" {
+		t.Errorf("order.id attribute was not recorded, got %v", recorded["order.id"])
+	}
+
+	if recorded["order.amount"].AsInt64() != 250 {
+		t.Errorf("order.amount attribute was not recorded, got %v", recorded["order.amount"])
+	}
+}
+
+func TestAddSpanAttributesWithoutTelemetry(t *testing.T) {
+
+	ctx := context.Background()
+
+	if frame.SpanFromContext(ctx).IsRecording() {
+		t.Errorf("span should not be recording when telemetry is disabled")
+	}
+
+	frame.AddSpanAttributes(ctx, attribute.String("order.id", "ord-123"))
+}
+
+// unreachableExporter simulates an exporter whose endpoint never answers
+type unreachableExporter struct{}
+
+func (ue *unreachableExporter) ExportSpans(ctx context.Context, _ []sdktrace.ReadOnlySpan) error {
+	<-ctx.Done()
+	return errors.New("exporter endpoint is unreachable")
+}
+
+func (ue *unreachableExporter) Shutdown(_ context.Context) error {
+	return nil
+}
+
+func TestService_UnreachableTraceExporterDoesNotBlock(t *testing.T) {
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, span := otel.Tracer("frame-test").Start(r.Context(), "handle request")
+		span.End()
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ctx, srv := frame.NewService("Test Srv",
+		frame.NoopDriver(),
+		frame.TraceExporter(&unreachableExporter{}),
+		frame.HttpHandler(handler))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("an unreachable exporter should not fail the service : %s", err)
+		return
+	}
+
+	startedAt := time.Now()
+	for range 500 {
+		rr := httptest.NewRecorder()
+		srv.H().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/traced", nil))
+		if rr.Code != http.StatusOK {
+			t.Errorf("request failed with status %d", rr.Code)
+			return
+		}
+	}
+
+	if elapsed := time.Since(startedAt); elapsed > 2*time.Second {
+		t.Errorf("request handling was blocked by the unreachable exporter, took %s", elapsed)
+	}
+}
+
+func TestService_WithTraceBodies(t *testing.T) {
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.ReadAll(r.Body)
+		if r.URL.Path == "/binary" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+		}
+		_, _ = w.Write([]byte(`{"token": "abc123", "status": "accepted"}`))
+	})
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(handler),
+		frame.WithTraceBodies(32, "password", "token"))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	traced := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		spanCtx, span := provider.Tracer("frame-test").Start(r.Context(), r.URL.Path)
+		defer span.End()
+		srv.H().ServeHTTP(w, r.WithContext(spanCtx))
+	})
+
+	send := func(path, contentType, body string) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		traced.ServeHTTP(rr, req)
+		if !strings.Contains(rr.Body.String(), "accepted") {
+			t.Errorf("response body should reach the client untouched, got %s", rr.Body.String())
+		}
+	}
+
+	send("/login", "application/json", `{"user": "jane", "password": "s3cret", "remember": true, "padding": "xxxxxxxx"}`)
+	send("/binary", "application/octet-stream", "raw-bytes")
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Errorf("expected two recorded spans, got %d", len(spans))
+		return
+	}
+
+	bodies := map[string]map[attribute.Key]attribute.Value{}
+	for _, span := range spans {
+		captured := map[attribute.Key]attribute.Value{}
+		for _, event := range span.Events() {
+			for _, attr := range event.Attributes {
+				captured[attr.Key] = attr.Value
+			}
+		}
+		bodies[span.Name()] = captured
+	}
+
+	login := bodies["/login"]
+	requestBody := login["http.request.body"].AsString()
+	if len(requestBody) > 32+len(`"[REDACTED]"`) || !login["http.request.body.truncated"].AsBool() {
+		t.Errorf("request body should be truncated to the limit, got %q", requestBody)
+	}
+	if strings.Contains(requestBody, "s3cret") || !strings.Contains(requestBody, `"password": "[REDACTED]"`) {
+		t.Errorf("password should be redacted, got %q", requestBody)
+	}
+
+	responseBody := login["http.response.body"].AsString()
+	if strings.Contains(responseBody, "abc123") || !strings.Contains(responseBody, `"token": "[REDACTED]"`) {
+		t.Errorf("token should be redacted, got %q", responseBody)
+	}
+
+	if len(bodies["/binary"]) != 0 {
+		t.Errorf("non text bodies should not be captured, got %v", bodies["/binary"])
+	}
+}
+
+// countingExporter counts the spans exported and whether it was shut down
+type countingExporter struct {
+	spans    atomic.Int64
+	shutdown atomic.Bool
+}
+
+func (ce *countingExporter) ExportSpans(_ context.Context, spans []sdktrace.ReadOnlySpan) error {
+	ce.spans.Add(int64(len(spans)))
+	return nil
+}
+
+func (ce *countingExporter) Shutdown(_ context.Context) error {
+	ce.shutdown.Store(true)
+	return nil
+}
+
+func TestService_StopFlushesSpans(t *testing.T) {
+
+	exporter := &countingExporter{}
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.TraceExporter(exporter))
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	_, span := otel.Tracer("frame-test").Start(ctx, "last request")
+	span.End()
+
+	srv.Stop(ctx)
+
+	if exporter.spans.Load() != 1 || !exporter.shutdown.Load() {
+		t.Errorf("stop exported %d spans shutdown %v, expected the batched span flushed and the exporter stopped",
+			exporter.spans.Load(), exporter.shutdown.Load())
+	}
+}
@@ -0,0 +1,41 @@
+package frame
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ErrUploadTooLarge is returned when a streamed upload exceeds the allowed size
+var ErrUploadTooLarge = NewError(ErrorCodeTooLarge, "upload exceeds the maximum allowed size")
+
+// StreamUpload copies the request body into dst without buffering it in memory,
+// returning the number of bytes written. At most maxBytes are written to dst,
+// ErrUploadTooLarge is returned when the body is larger than that.
+func StreamUpload(r *http.Request, dst io.Writer, maxBytes int64) (int64, error) {
+
+	if r.Body == nil || r.Body == http.NoBody {
+		return 0, nil
+	}
+
+	if r.ContentLength > maxBytes {
+		return 0, ErrUploadTooLarge
+	}
+
+	written, err := io.Copy(dst, io.LimitReader(r.Body, maxBytes))
+	if err != nil {
+		return written, err
+	}
+
+	// Probe for any remaining data to detect bodies larger than the cap
+	var probe [1]byte
+	n, err := r.Body.Read(probe[:])
+	if n > 0 {
+		return written, ErrUploadTooLarge
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return written, err
+	}
+
+	return written, nil
+}
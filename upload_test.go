@@ -0,0 +1,120 @@
+package frame_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/pitabwire/frame"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// patternReader generates size bytes without holding them in memory
+type patternReader struct {
+	remaining int64
+}
+
+func (pr *patternReader) Read(p []byte) (int, error) {
+	if pr.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > pr.remaining {
+		p = p[:pr.remaining]
+	}
+	for i := range p {
+		p[i] = 'a'
+	}
+	pr.remaining -= int64(len(p))
+	return len(p), nil
+}
+
+func uploadHandler(maxBytes int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		written, err := frame.StreamUpload(r, io.Discard, maxBytes)
+		if errors.Is(err, frame.ErrUploadTooLarge) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%d", written)
+	})
+}
+
+func TestStreamUploadConstantMemory(t *testing.T) {
+
+	uploadSize := int64(64 << 20)
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.HttpHandler(uploadHandler(uploadSize)))
+	defer srv.Stop(ctx)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Errorf("could not run service : %s", err)
+		return
+	}
+
+	ts := httptest.NewServer(srv.H())
+	defer ts.Close()
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	resp, err := http.Post(ts.URL+"/upload", "application/octet-stream", &patternReader{remaining: uploadSize})
+	if err != nil {
+		t.Errorf("could not upload : %s", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	runtime.ReadMemStats(&after)
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != fmt.Sprintf("%d", uploadSize) {
+		t.Errorf("upload was not fully streamed, status %d body %s", resp.StatusCode, body)
+	}
+
+	allocated := after.TotalAlloc - before.TotalAlloc
+	if allocated > uint64(uploadSize/4) {
+		t.Errorf("streaming a %d byte upload allocated %d bytes, it should not be buffered", uploadSize, allocated)
+	}
+}
+
+func TestStreamUploadSizeCap(t *testing.T) {
+
+	tests := []struct {
+		name       string
+		size       int64
+		maxBytes   int64
+		wantStatus int
+	}{
+		{name: "Within cap", size: 1024, maxBytes: 1024, wantStatus: http.StatusOK},
+		{name: "Exceeds cap", size: 1025, maxBytes: 1024, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			for _, knownLength := range []bool{true, false} {
+				var body io.Reader = &patternReader{remaining: tt.size}
+				if knownLength {
+					body = strings.NewReader(strings.Repeat("a", int(tt.size)))
+				}
+
+				req := httptest.NewRequest(http.MethodPost, "/upload", body)
+				rr := httptest.NewRecorder()
+				uploadHandler(tt.maxBytes).ServeHTTP(rr, req)
+
+				if rr.Code != tt.wantStatus {
+					t.Errorf("StreamUpload() status = %d, want %d (known length %v)", rr.Code, tt.wantStatus, knownLength)
+				}
+			}
+		})
+	}
+}
@@ -3,6 +3,8 @@ package frame
 import (
 	"context"
 	"errors"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
 	"github.com/rs/xid"
 	"runtime/debug"
 	"sync"
@@ -143,15 +145,52 @@ func WithPoolCapacity(capacity int) Option {
 	}
 }
 
+// jobContext carries the values of the context a job was submitted with,
+// while its deadline and cancellation follow the lifetime of the service.
+type jobContext struct {
+	context.Context
+	values context.Context
+}
+
+func (jc *jobContext) Value(key any) any {
+	return jc.values.Value(key)
+}
+
 // SubmitJob used to submit jobs to our worker pool for processing.
 // Once a job is submitted the end user does not need to do any further tasks
 // One can ideally also wait for the results of their processing for their specific job
 // This is done by simply by listening to the jobs ErrChan. Be sure to also check for when its closed
 //
 //	err, ok := <- errChan
+//
+// The job observes the values of the supplied context like the auth claims or trace information,
+// but not its deadline or cancellation, it is only canceled once the service stops.
+// Use SubmitJobWithCancellation to bind the job to the supplied context instead.
+// When every worker is busy the job is handled as set by WithPoolFullPolicy, by default it is rejected with ErrPoolFull.
 func (s *Service) SubmitJob(ctx context.Context, job Job) error {
+	return s.SubmitJobWithCancellation(s.detachedJobContext(ctx), job)
+}
+
+// detachedJobContext keeps the values of ctx for a job that is only canceled once the service stops
+func (s *Service) detachedJobContext(ctx context.Context) context.Context {
+
+	lifetimeCtx := s.lifetimeCtx
+	if lifetimeCtx == nil {
+		lifetimeCtx = context.Background()
+	}
+
+	return &jobContext{Context: lifetimeCtx, values: ctx}
+}
+
+// SubmitJobWithCancellation submits a job to the worker pool that is bound to the deadline
+// and cancellation of the supplied context.
+func (s *Service) SubmitJobWithCancellation(ctx context.Context, job Job) error {
+	return s.submitJob(ctx, s.pool, job)
+}
+
+// submitJob runs job on the worker pool p, retries are submitted to the same pool
+func (s *Service) submitJob(ctx context.Context, p *ants.MultiPool, job Job) error {
 
-	p := s.pool
 	if p.IsClosed() {
 		return errors.New("pool is closed")
 	}
@@ -166,43 +205,49 @@ func (s *Service) SubmitJob(ctx context.Context, job Job) error {
 				return nil
 			}
 
-			return p.Submit(
-				func() {
+			s.poolCounters.queued.Add(1)
+			run := func() {
 
-					defer job.Close()
+				defer s.trackJobRun(ctx)()
+				defer job.Close()
 
-					if job.F() == nil {
-						err := job.WriteResult(ctx, errors.New("implement this function"))
-						if err != nil {
-							return
-						}
+				if job.F() == nil {
+					err := job.WriteResult(ctx, errors.New("implement this function"))
+					if err != nil {
 						return
 					}
-
-					job.IncreaseRuns()
-					err := job.F()(ctx, job)
-					if err != nil {
-						logger := s.L(ctx).WithError(err).
-							WithField("job", job.ID()).
-							WithField("retry", job.Retries())
-
-						if job.CanRun() {
-
-							err1 := s.SubmitJob(ctx, job)
-							if err1 != nil {
-								logger.
-									WithError(err1).
-									WithField("stacktrace", string(debug.Stack())).
-									Info("could not resubmit job for retry")
-								return
-							} else {
-								logger.Debug("job resubmitted for retry")
-								return
-							}
+					return
+				}
+
+				job.IncreaseRuns()
+				err := job.F()(ctx, job)
+				if err != nil {
+					logger := s.L(ctx).WithError(err).
+						WithField("job", job.ID()).
+						WithField("retry", job.Retries())
+
+					if job.CanRun() {
+
+						err1 := s.submitJob(ctx, p, job)
+						if err1 != nil {
+							logger.
+								WithError(err1).
+								WithField("stacktrace", string(debug.Stack())).
+								Info("could not resubmit job for retry")
+							return
+						} else {
+							logger.Debug("job resubmitted for retry")
+							return
 						}
 					}
-				},
-			)
+				}
+			}
+
+			err := s.submitToPool(ctx, p, run)
+			if err != nil {
+				s.poolCounters.queued.Add(-1)
+			}
+			return err
 		}
 	}
 }
@@ -229,3 +274,38 @@ func SafeChannelRead(ctx context.Context, ch <-chan any) (any, bool, error) {
 		return result, ok, nil
 	}
 }
+
+// ReduceSearch folds over batches of search results written to the supplied pipe, typically by a job
+// paging through a repository search, without collecting all the results in memory.
+// Errors written to the pipe or returned by fn stop the reduction, as does the context being done.
+func ReduceSearch[T any, R any](ctx context.Context, pipe JobResultPipe, initial R, fn func(R, []T) (R, error)) (R, error) {
+
+	accumulator := initial
+	for {
+		result, ok, err := pipe.ReadResult(ctx)
+		if err != nil {
+			return accumulator, err
+		}
+
+		if !ok {
+			return accumulator, nil
+		}
+
+		var batch []T
+		switch v := result.(type) {
+		case error:
+			return accumulator, v
+		case []T:
+			batch = v
+		case T:
+			batch = []T{v}
+		default:
+			return accumulator, fmt.Errorf("unexpected search result of type %T", result)
+		}
+
+		accumulator, err = fn(accumulator, batch)
+		if err != nil {
+			return accumulator, err
+		}
+	}
+}
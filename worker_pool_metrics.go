@@ -0,0 +1,109 @@
+package frame
+
+import (
+	"context"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"sync/atomic"
+	"time"
+)
+
+// WorkerPoolStats is a snapshot of the load on the service worker pool
+type WorkerPoolStats struct {
+	// Capacity is the number of jobs the pool can run at the same time
+	Capacity int
+	// Queued is the number of submitted jobs that have not started running yet
+	Queued int64
+	// Active is the number of jobs running right now
+	Active int64
+	// Rejected is the number of jobs turned away because the pool was full
+	Rejected int64
+	// Completed is the number of job runs that finished, retries included
+	Completed int64
+}
+
+type workerPoolCounters struct {
+	queued    atomic.Int64
+	active    atomic.Int64
+	rejected  atomic.Int64
+	completed atomic.Int64
+}
+
+// WorkerPoolStats reports how saturated the worker pool running submitted jobs and subscriber handlers is
+func (s *Service) WorkerPoolStats() WorkerPoolStats {
+
+	stats := WorkerPoolStats{
+		Queued:    s.poolCounters.queued.Load(),
+		Active:    s.poolCounters.active.Load(),
+		Rejected:  s.poolCounters.rejected.Load(),
+		Completed: s.poolCounters.completed.Load(),
+	}
+
+	if s.pool != nil {
+		stats.Capacity = s.pool.Cap()
+	}
+	return stats
+}
+
+type poolMetrics struct {
+	rejected    metric.Int64Counter
+	jobDuration metric.Float64Histogram
+}
+
+// poolMetrics lazily creates the instruments used to record the worker pool load,
+// the queued and active gauges are observed from the pool counters on every collection.
+func (s *Service) poolMetrics() *poolMetrics {
+
+	s.poolMetricsOnce.Do(func() {
+
+		provider := s.meterProvider
+		if provider == nil {
+			provider = otel.GetMeterProvider()
+		}
+
+		meter := provider.Meter(instrumentationName)
+
+		pm := &poolMetrics{}
+		pm.rejected, _ = meter.Int64Counter("frame.pool.jobs.rejected",
+			metric.WithDescription("Number of jobs rejected because the worker pool was full"))
+		pm.jobDuration, _ = meter.Float64Histogram("frame.pool.job.duration",
+			metric.WithDescription("Time taken by worker pool jobs to run"),
+			metric.WithUnit("s"))
+
+		_, _ = meter.Int64ObservableGauge("frame.pool.jobs.queued",
+			metric.WithDescription("Number of submitted jobs waiting for a worker"),
+			metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+				observer.Observe(s.poolCounters.queued.Load())
+				return nil
+			}))
+		_, _ = meter.Int64ObservableGauge("frame.pool.jobs.active",
+			metric.WithDescription("Number of jobs being run by the worker pool"),
+			metric.WithInt64Callback(func(_ context.Context, observer metric.Int64Observer) error {
+				observer.Observe(s.poolCounters.active.Load())
+				return nil
+			}))
+
+		s.poolMetricsInstance = pm
+	})
+
+	return s.poolMetricsInstance
+}
+
+func (s *Service) recordJobRejected(ctx context.Context) {
+	s.poolCounters.rejected.Add(1)
+	s.poolMetrics().rejected.Add(ctx, 1)
+}
+
+// trackJobRun marks a queued job as running, the returned function records its completion
+func (s *Service) trackJobRun(ctx context.Context) func() {
+
+	s.poolCounters.queued.Add(-1)
+	s.poolCounters.active.Add(1)
+	startedAt := time.Now()
+
+	return func() {
+		s.poolCounters.active.Add(-1)
+		s.poolCounters.completed.Add(1)
+		s.poolMetrics().jobDuration.Record(ctx, time.Since(startedAt).Seconds())
+	}
+}
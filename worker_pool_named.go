@@ -0,0 +1,92 @@
+package frame
+
+import (
+	"context"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+)
+
+// InternalWorkerPool names the worker pool handling the messages received by subscribers, it is the only framework
+// work routed to a named pool. Registering it with WithWorkerPool keeps message handling from starving jobs submitted
+// with SubmitJob, without it messages are handled by the shared pool. The subscriber listeners themselves stay on the
+// shared pool, each holds a worker for the lifetime of the service and would otherwise take those meant for messages.
+const InternalWorkerPool = "frame.internal"
+
+// ErrWorkerPoolNotFound is returned when submitting to a worker pool that was not registered with WithWorkerPool
+var ErrWorkerPoolNotFound = NewError(ErrorCodeNotFound, "worker pool not found")
+
+type workerPoolSpec struct {
+	count    int
+	capacity int
+}
+
+// WithWorkerPool Option registers a worker pool dedicated to the work submitted to it with SubmitJobToPool,
+// so a subsystem saturating its pool does not starve the others. Like WithPoolConcurrency and WithPoolCapacity,
+// count sets the number of pools balancing the work and capacity the workers of each.
+func WithWorkerPool(name string, count int, capacity int) Option {
+	return func(s *Service) {
+		if s.workerPoolSpecs == nil {
+			s.workerPoolSpecs = map[string]workerPoolSpec{}
+		}
+		s.workerPoolSpecs[name] = workerPoolSpec{count: count, capacity: capacity}
+	}
+}
+
+// SubmitJobToPool submits a job to the worker pool registered under name with WithWorkerPool.
+// Like SubmitJob the job observes the values of ctx but is only canceled once the service stops.
+func (s *Service) SubmitJobToPool(ctx context.Context, name string, job Job) error {
+	p, err := s.workerPool(name)
+	if err != nil {
+		return err
+	}
+
+	return s.submitJob(s.detachedJobContext(ctx), p, job)
+}
+
+// submitInternalJob submits framework work to the internal worker pool when one is registered
+func (s *Service) submitInternalJob(ctx context.Context, job Job) error {
+	p, err := s.workerPool(InternalWorkerPool)
+	if err != nil {
+		return s.SubmitJob(ctx, job)
+	}
+
+	return s.submitJob(s.detachedJobContext(ctx), p, job)
+}
+
+// workerPool obtains the worker pool registered under name, creating it on first use
+func (s *Service) workerPool(name string) (*ants.MultiPool, error) {
+	s.workerPoolsMutex.Lock()
+	defer s.workerPoolsMutex.Unlock()
+
+	p, ok := s.workerPools[name]
+	if ok {
+		return p, nil
+	}
+
+	spec, ok := s.workerPoolSpecs[name]
+	if !ok {
+		return nil, fmt.Errorf("%w : %s", ErrWorkerPoolNotFound, name)
+	}
+
+	p, err := ants.NewMultiPool(spec.count, spec.capacity, ants.LeastTasks,
+		ants.WithLogger(s.L(s.lifetimeCtx)), ants.WithNonblocking(true))
+	if err != nil {
+		return nil, err
+	}
+
+	if s.workerPools == nil {
+		s.workerPools = map[string]*ants.MultiPool{}
+	}
+	s.workerPools[name] = p
+	return p, nil
+}
+
+// freeWorkerPools releases the registered worker pools created so far
+func (s *Service) freeWorkerPools() {
+	s.workerPoolsMutex.Lock()
+	defer s.workerPoolsMutex.Unlock()
+
+	for _, p := range s.workerPools {
+		p.Free()
+	}
+}
@@ -0,0 +1,68 @@
+package frame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/panjf2000/ants/v2"
+	"time"
+)
+
+const poolFullRetryInterval = 5 * time.Millisecond
+
+// ErrPoolFull is returned when a job is submitted while every worker is busy and the pool full policy rejects it
+var ErrPoolFull = NewError(ErrorCodeUnavailable, "worker pool is full")
+
+// PoolFullPolicy decides what happens to a job submitted while every worker of the pool is busy
+type PoolFullPolicy int
+
+const (
+	// PoolFullReject fails the submission with ErrPoolFull straight away, this is the default
+	PoolFullReject PoolFullPolicy = iota
+	// PoolFullBlock waits for a worker to become free, or for the submission context to be done
+	PoolFullBlock
+	// PoolFullRunInline runs the job synchronously on the goroutine submitting it
+	PoolFullRunInline
+)
+
+// WithPoolFullPolicy Option that sets how jobs submitted to a saturated worker pool are handled.
+// By default they are rejected, blocking trades latency for completeness while running inline
+// slows down the submitter, which naturally applies back pressure to it.
+func WithPoolFullPolicy(policy PoolFullPolicy) Option {
+	return func(s *Service) {
+		s.poolFullPolicy = policy
+	}
+}
+
+// submitToPool hands task to a free worker applying the pool full policy when there is none
+func (s *Service) submitToPool(ctx context.Context, p *ants.MultiPool, task func()) error {
+
+	err := p.Submit(task)
+	if !errors.Is(err, ants.ErrPoolOverload) {
+		return err
+	}
+
+	switch s.poolFullPolicy {
+	case PoolFullRunInline:
+		task()
+		return nil
+
+	case PoolFullBlock:
+		ticker := time.NewTicker(poolFullRetryInterval)
+		defer ticker.Stop()
+
+		for errors.Is(err, ants.ErrPoolOverload) {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+				err = p.Submit(task)
+			}
+		}
+		return err
+
+	default:
+		s.recordJobRejected(ctx)
+		return fmt.Errorf("%w : %w", ErrPoolFull, err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"github.com/pitabwire/frame"
+	"go.opentelemetry.io/otel/trace"
 	"testing"
 	"time"
 )
@@ -197,3 +198,426 @@ func TestService_NewJobWithBufferAndRetry(t *testing.T) {
 		})
 	}
 }
+
+func TestService_SubmitJobPropagatesContextValues(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	claims := frame.AuthenticationClaims{TenantID: "tenant", PartitionID: "partition"}
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	spanContext := trace.NewSpanContext(trace.SpanContextConfig{TraceID: traceID, SpanID: spanID})
+
+	submitCtx, cancel := context.WithCancel(claims.ClaimsToContext(ctx))
+	defer cancel()
+	submitCtx = trace.ContextWithSpanContext(submitCtx, spanContext)
+
+	started := make(chan struct{})
+	job := srv.NewJob(func(jobCtx context.Context, result frame.JobResultPipe) error {
+		<-started
+
+		jobClaims := frame.ClaimsFromContext(jobCtx)
+		if jobClaims == nil || jobClaims.GetTenantId() != "tenant" {
+			return result.WriteResult(jobCtx, errors.New("tenant was not propagated to the job"))
+		}
+
+		if trace.SpanContextFromContext(jobCtx).TraceID() != traceID {
+			return result.WriteResult(jobCtx, errors.New("trace was not propagated to the job"))
+		}
+
+		if jobCtx.Err() != nil {
+			return result.WriteResult(jobCtx, errors.New("job was canceled with the submitting context"))
+		}
+
+		return result.WriteResult(jobCtx, nil)
+	})
+
+	err := srv.SubmitJob(submitCtx, job)
+	if err != nil {
+		t.Errorf("could not submit job : %s", err)
+		return
+	}
+
+	cancel()
+	close(started)
+
+	select {
+	case result := <-job.ResultChan():
+		if result != nil {
+			t.Errorf("%v", result)
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("could not handle job within timelimit")
+	}
+}
+
+func TestService_SubmitJobWithCancellation(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	submitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	started := make(chan struct{})
+	job := srv.NewJob(func(jobCtx context.Context, result frame.JobResultPipe) error {
+		<-started
+		return result.WriteResult(context.Background(), jobCtx.Err())
+	})
+
+	err := srv.SubmitJobWithCancellation(submitCtx, job)
+	if err != nil {
+		t.Errorf("could not submit job : %s", err)
+		return
+	}
+
+	cancel()
+	close(started)
+
+	select {
+	case result := <-job.ResultChan():
+		if !errors.Is(result.(error), context.Canceled) {
+			t.Errorf("job bound to the submitting context should be canceled with it")
+		}
+	case <-time.After(2 * time.Second):
+		t.Errorf("could not handle job within timelimit")
+	}
+}
+
+type searchItem struct {
+	Amount int
+}
+
+func TestReduceSearch(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	tests := []struct {
+		name    string
+		batches []any
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "Sum over batches",
+			batches: []any{
+				[]searchItem{{Amount: 1}, {Amount: 2}},
+				[]searchItem{{Amount: 3}},
+				searchItem{Amount: 4},
+			},
+			want: 10,
+		},
+		{
+			name: "Error in results",
+			batches: []any{
+				[]searchItem{{Amount: 1}},
+				errors.New("search failed"),
+				[]searchItem{{Amount: 5}},
+			},
+			want:    1,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			job := srv.NewJob(func(jobCtx context.Context, result frame.JobResultPipe) error {
+				for _, batch := range tt.batches {
+					err := result.WriteResult(jobCtx, batch)
+					if err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+
+			err := srv.SubmitJob(ctx, job)
+			if err != nil {
+				t.Errorf("could not submit job : %s", err)
+				return
+			}
+
+			total, err := frame.ReduceSearch(ctx, job, 0, func(sum int, batch []searchItem) (int, error) {
+				for _, item := range batch {
+					sum += item.Amount
+				}
+				return sum, nil
+			})
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReduceSearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if total != tt.want {
+				t.Errorf("ReduceSearch() = %d, want %d", total, tt.want)
+			}
+		})
+	}
+}
+
+func TestReduceSearchContextCanceled(t *testing.T) {
+
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver())
+	defer srv.Stop(ctx)
+
+	job := srv.NewJob(func(jobCtx context.Context, result frame.JobResultPipe) error {
+		<-jobCtx.Done()
+		return nil
+	})
+
+	reduceCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	_, err := frame.ReduceSearch(reduceCtx, job, 0, func(sum int, batch []searchItem) (int, error) {
+		return sum, nil
+	})
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("ReduceSearch() should stop once the context is done, got %v", err)
+	}
+}
+
+func TestService_WorkerPoolStats(t *testing.T) {
+
+	meterProvider := newRecordingMeterProvider()
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(), frame.MeterProvider(meterProvider),
+		frame.WithPoolConcurrency(1), frame.WithPoolCapacity(2))
+	defer srv.Stop(ctx)
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+
+	blockingJob := func() frame.Job {
+		return srv.NewJob(func(ctx context.Context, _ frame.JobResultPipe) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+	}
+
+	for range 2 {
+		err := srv.SubmitJob(ctx, blockingJob())
+		if err != nil {
+			t.Fatalf("could not submit job : %s", err)
+		}
+	}
+
+	for range 2 {
+		select {
+		case <-started:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("jobs did not start")
+		}
+	}
+
+	err := srv.SubmitJob(ctx, blockingJob())
+	if err == nil {
+		t.Errorf("a job submitted to a full pool should be rejected")
+	}
+
+	stats := srv.WorkerPoolStats()
+	if stats.Capacity != 2 || stats.Active != 2 || stats.Queued != 0 || stats.Rejected != 1 {
+		t.Errorf("stats of a saturated pool = %+v", stats)
+	}
+
+	if rejected := meterProvider.counter("frame.pool.jobs.rejected", ""); rejected != 1 {
+		t.Errorf("rejected jobs metric = %d, want 1", rejected)
+	}
+
+	close(release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && srv.WorkerPoolStats().Completed < 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	stats = srv.WorkerPoolStats()
+	if stats.Active != 0 || stats.Completed != 2 {
+		t.Errorf("stats once the jobs completed = %+v", stats)
+	}
+}
+
+func TestService_PoolFullPolicy(t *testing.T) {
+
+	tests := []struct {
+		name   string
+		policy []frame.Option
+		check  func(t *testing.T, srv *frame.Service, submit func() error, release func())
+	}{
+		{name: "Reject", check: func(t *testing.T, srv *frame.Service, submit func() error, release func()) {
+			defer release()
+			err := submit()
+			if !errors.Is(err, frame.ErrPoolFull) {
+				t.Errorf("a full pool should reject jobs with ErrPoolFull, got %v", err)
+			}
+		}},
+		{name: "Block", policy: []frame.Option{frame.WithPoolFullPolicy(frame.PoolFullBlock)},
+			check: func(t *testing.T, srv *frame.Service, submit func() error, release func()) {
+				submitted := make(chan error, 1)
+				go func() { submitted <- submit() }()
+
+				select {
+				case err := <-submitted:
+					t.Errorf("submitting to a full pool should block, returned %v", err)
+					return
+				case <-time.After(100 * time.Millisecond):
+				}
+
+				if queued := srv.WorkerPoolStats().Queued; queued != 1 {
+					t.Errorf("blocked job should be reported as queued, got %d", queued)
+				}
+
+				release()
+				select {
+				case err := <-submitted:
+					if err != nil {
+						t.Errorf("blocked job should be submitted once a worker is free, got %v", err)
+					}
+				case <-time.After(5 * time.Second):
+					t.Errorf("blocked job was never submitted")
+				}
+			}},
+		{name: "Run inline", policy: []frame.Option{frame.WithPoolFullPolicy(frame.PoolFullRunInline)},
+			check: func(t *testing.T, srv *frame.Service, _ func() error, release func()) {
+				defer release()
+
+				ran := false
+				job := srv.NewJob(func(ctx context.Context, _ frame.JobResultPipe) error {
+					ran = true
+					return nil
+				})
+
+				err := srv.SubmitJob(context.Background(), job)
+				if err != nil || !ran {
+					t.Errorf("job should run synchronously when the pool is full, ran %v : %v", ran, err)
+				}
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+
+			opts := append([]frame.Option{frame.NoopDriver(), frame.WithPoolConcurrency(1), frame.WithPoolCapacity(1)}, tt.policy...)
+			ctx, srv := frame.NewService("Test Srv", opts...)
+			defer srv.Stop(ctx)
+
+			releaseCh := make(chan struct{})
+			started := make(chan struct{}, 2)
+			submit := func() error {
+				return srv.SubmitJob(ctx, srv.NewJob(func(ctx context.Context, _ frame.JobResultPipe) error {
+					started <- struct{}{}
+					<-releaseCh
+					return nil
+				}))
+			}
+
+			err := submit()
+			if err != nil {
+				t.Fatalf("could not submit job : %s", err)
+			}
+
+			select {
+			case <-started:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("job did not start")
+			}
+
+			tt.check(t, srv, submit, func() { close(releaseCh) })
+		})
+	}
+}
+
+type blockingHandler struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (h *blockingHandler) Handle(_ context.Context, _ map[string]string, _ []byte) error {
+	h.started <- struct{}{}
+	<-h.release
+	return nil
+}
+
+func TestService_WorkerPoolPerSubsystem(t *testing.T) {
+
+	queueURL := "mem://topic-internal-pool"
+	handler := &blockingHandler{started: make(chan struct{}, 1), release: make(chan struct{})}
+
+	// The shared pool only fits the subscriber listening loop and one job
+	ctx, srv := frame.NewService("Test Srv", frame.NoopDriver(),
+		frame.WithPoolConcurrency(1), frame.WithPoolCapacity(2),
+		frame.WithWorkerPool(frame.InternalWorkerPool, 1, 1),
+		frame.WithWorkerPool("reports", 1, 1),
+		frame.RegisterPublisher("internal-pool", queueURL),
+		frame.RegisterSubscriber("internal-pool", queueURL, 1, handler))
+	defer srv.Stop(ctx)
+	defer close(handler.release)
+
+	err := srv.Run(ctx, "")
+	if err != nil {
+		t.Fatalf("could not run service : %s", err)
+	}
+
+	runsPromptly := func(submit func(job frame.Job) error) {
+		t.Helper()
+
+		ran := make(chan struct{})
+		err0 := submit(srv.NewJob(func(_ context.Context, _ frame.JobResultPipe) error {
+			close(ran)
+			return nil
+		}))
+		if err0 != nil {
+			t.Fatalf("could not submit job : %s", err0)
+		}
+
+		select {
+		case <-ran:
+		case <-time.After(2 * time.Second):
+			t.Fatalf("job was starved by a saturated pool")
+		}
+	}
+
+	// Saturate the internal pool with a message whose handling blocks
+	err = srv.Publish(ctx, "internal-pool", []byte("slow"))
+	if err != nil {
+		t.Fatalf("could not publish message : %s", err)
+	}
+
+	select {
+	case <-handler.started:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("message was not handled")
+	}
+
+	runsPromptly(func(job frame.Job) error { return srv.SubmitJob(ctx, job) })
+
+	// Saturate the reports pool, its jobs are isolated from user jobs too
+	reportsRelease := make(chan struct{})
+	defer close(reportsRelease)
+	err = srv.SubmitJobToPool(ctx, "reports", srv.NewJob(func(_ context.Context, _ frame.JobResultPipe) error {
+		<-reportsRelease
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("could not submit job to the reports pool : %s", err)
+	}
+
+	err = srv.SubmitJobToPool(ctx, "reports", srv.NewJob(func(_ context.Context, _ frame.JobResultPipe) error {
+		return nil
+	}))
+	if !errors.Is(err, frame.ErrPoolFull) {
+		t.Errorf("saturated reports pool should reject jobs with ErrPoolFull, got %v", err)
+	}
+
+	runsPromptly(func(job frame.Job) error { return srv.SubmitJob(ctx, job) })
+
+	err = srv.SubmitJobToPool(ctx, "unknown", srv.NewJob(func(_ context.Context, _ frame.JobResultPipe) error {
+		return nil
+	}))
+	if !errors.Is(err, frame.ErrWorkerPoolNotFound) {
+		t.Errorf("submitting to an unregistered pool should fail with ErrWorkerPoolNotFound, got %v", err)
+	}
+}